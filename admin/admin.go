@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/replay"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// Controller the replay service controlled by the admin API.
+type Controller interface {
+	Status() replay.Status
+	Ready() bool
+	Pause()
+	Resume()
+	Acknowledge()
+}
+
+// Server the admin HTTP API of the replay service. It exposes:
+//
+//   - GET /healthz: returns 200 as long as the service is running.
+//   - GET /readyz: returns 200 once the target contract caught up with the source contract, 503 otherwise.
+//     A pause or a later lag doesn't change the readiness, they're reported by the status.
+//   - GET /status: returns the replay status as JSON.
+//   - POST /pause: stops submitting proofs to the target contract, while still following the source contract.
+//   - POST /resume: resumes submitting proofs to the target contract, unless governance changes are unacknowledged.
+//   - POST /acknowledge: acknowledges the detected governance changes and resumes submitting proofs.
+//
+// If a token is set, the status, pause, resume and acknowledge endpoints require an "Authorization: Bearer <token>" header.
+// The health and readiness endpoints are left open for the orchestrators probes.
+type Server struct {
+	logger     tmlog.Logger
+	controller Controller
+	token      string
+	mux        *http.ServeMux
+}
+
+var _ http.Handler = &Server{}
+
+// New creates a new admin server. An empty token disables the authentication.
+func New(logger tmlog.Logger, controller Controller, token string) *Server {
+	s := &Server{
+		logger:     logger,
+		controller: controller,
+		token:      token,
+		mux:        http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/healthz", s.method(http.MethodGet, s.healthz))
+	s.mux.HandleFunc("/readyz", s.method(http.MethodGet, s.readyz))
+	s.mux.HandleFunc("/status", s.method(http.MethodGet, s.authenticated(s.status)))
+	s.mux.HandleFunc("/pause", s.method(http.MethodPost, s.authenticated(s.pause)))
+	s.mux.HandleFunc("/resume", s.method(http.MethodPost, s.authenticated(s.resume)))
+	s.mux.HandleFunc("/acknowledge", s.method(http.MethodPost, s.authenticated(s.acknowledge)))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeText(w, http.StatusOK, "ok")
+}
+
+func (s *Server) readyz(w http.ResponseWriter, _ *http.Request) {
+	if !s.controller.Ready() {
+		writeText(w, http.StatusServiceUnavailable, "catching up")
+		return
+	}
+	writeText(w, http.StatusOK, "ok")
+}
+
+func (s *Server) status(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.logger, s.controller.Status())
+}
+
+func (s *Server) pause(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Pause()
+	writeJSON(w, s.logger, s.controller.Status())
+}
+
+func (s *Server) resume(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Resume()
+	writeJSON(w, s.logger, s.controller.Status())
+}
+
+func (s *Server) acknowledge(w http.ResponseWriter, _ *http.Request) {
+	s.controller.Acknowledge()
+	writeJSON(w, s.logger, s.controller.Status())
+}
+
+// method only allows requests using the provided HTTP method.
+func (s *Server) method(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			writeText(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// authenticated only allows requests containing the bearer token, if set.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+				s.logger.Debug("unauthorized admin request", "path", r.URL.Path, "remote", r.RemoteAddr)
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeText(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func writeText(w http.ResponseWriter, code int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(text + "\n"))
+}
+
+func writeJSON(w http.ResponseWriter, logger tmlog.Logger, value interface{}) {
+	bz, err := json.Marshal(value)
+	if err != nil {
+		logger.Error("couldn't marshal the admin response", "err", err.Error())
+		writeText(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(bz, '\n'))
+}
@@ -0,0 +1,235 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+const testToken = "secret"
+
+// fakeController a controller following the replayer pause semantics: resuming is refused while
+// governance changes are unacknowledged, and acknowledging them resumes the replay.
+type fakeController struct {
+	status replay.Status
+}
+
+var _ Controller = &fakeController{}
+
+func (c *fakeController) Status() replay.Status {
+	return c.status
+}
+
+func (c *fakeController) Ready() bool {
+	return c.status.Ready
+}
+
+func (c *fakeController) Pause() {
+	c.status.Paused = true
+}
+
+func (c *fakeController) Resume() {
+	if len(c.status.GovernanceChanges) == 0 {
+		c.status.Paused = false
+	}
+}
+
+func (c *fakeController) Acknowledge() {
+	c.status.GovernanceChanges = nil
+	c.status.Paused = false
+}
+
+func TestServer(t *testing.T) {
+	governanceChanges := []replay.GovernanceChange{{Chain: "target", Change: "RoleGranted"}}
+	tests := []struct {
+		name          string
+		status        replay.Status
+		method        string
+		path          string
+		authorization string
+		wantCode      int
+		// wantPaused the paused state of the controller after the request.
+		wantPaused bool
+	}{
+		{
+			name:     "healthz without a token",
+			method:   http.MethodGet,
+			path:     "/healthz",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "readyz while catching up",
+			method:   http.MethodGet,
+			path:     "/readyz",
+			wantCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "readyz while paused during the catchup",
+			status:     replay.Status{Paused: true},
+			method:     http.MethodGet,
+			path:       "/readyz",
+			wantCode:   http.StatusServiceUnavailable,
+			wantPaused: true,
+		},
+		{
+			name:     "readyz once caught up",
+			status:   replay.Status{Ready: true},
+			method:   http.MethodGet,
+			path:     "/readyz",
+			wantCode: http.StatusOK,
+		},
+		{
+			// the lag is reported by the status, the metrics and the notifications, not by the readiness
+			name:       "readyz while paused and lagging after the catchup",
+			status:     replay.Status{Ready: true, Paused: true, SourceLatestBlock: 2000, TargetLatestBlock: 1000, LagBlocks: 1000},
+			method:     http.MethodGet,
+			path:       "/readyz",
+			wantCode:   http.StatusOK,
+			wantPaused: true,
+		},
+		{
+			name:     "status without a token",
+			method:   http.MethodGet,
+			path:     "/status",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "status with a wrong token",
+			method:        http.MethodGet,
+			path:          "/status",
+			authorization: "Bearer wrong",
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:          "status with a token prefix",
+			method:        http.MethodGet,
+			path:          "/status",
+			authorization: "Bearer " + testToken[:3],
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:          "status with the token without the bearer scheme",
+			method:        http.MethodGet,
+			path:          "/status",
+			authorization: testToken,
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:          "status with the token",
+			method:        http.MethodGet,
+			path:          "/status",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          "status with a wrong method",
+			method:        http.MethodPost,
+			path:          "/status",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusMethodNotAllowed,
+		},
+		{
+			name:          "pause with a wrong token",
+			method:        http.MethodPost,
+			path:          "/pause",
+			authorization: "Bearer wrong",
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			name:          "pause",
+			method:        http.MethodPost,
+			path:          "/pause",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusOK,
+			wantPaused:    true,
+		},
+		{
+			name:          "pause with a wrong method",
+			method:        http.MethodGet,
+			path:          "/pause",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusMethodNotAllowed,
+		},
+		{
+			name:          "resume with a wrong token",
+			status:        replay.Status{Paused: true},
+			method:        http.MethodPost,
+			path:          "/resume",
+			authorization: "Bearer wrong",
+			wantCode:      http.StatusUnauthorized,
+			wantPaused:    true,
+		},
+		{
+			name:          "resume",
+			status:        replay.Status{Paused: true},
+			method:        http.MethodPost,
+			path:          "/resume",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          "resume with unacknowledged governance changes",
+			status:        replay.Status{Paused: true, GovernanceChanges: governanceChanges},
+			method:        http.MethodPost,
+			path:          "/resume",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusOK,
+			wantPaused:    true,
+		},
+		{
+			name:          "acknowledge",
+			status:        replay.Status{Paused: true, GovernanceChanges: governanceChanges},
+			method:        http.MethodPost,
+			path:          "/acknowledge",
+			authorization: "Bearer " + testToken,
+			wantCode:      http.StatusOK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			controller := &fakeController{status: test.status}
+			server := New(tmlog.NewNopLogger(), controller, testToken)
+
+			request := httptest.NewRequest(test.method, test.path, nil)
+			if test.authorization != "" {
+				request.Header.Set("Authorization", test.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			server.ServeHTTP(recorder, request)
+
+			require.Equal(t, test.wantCode, recorder.Code, recorder.Body.String())
+			assert.Equal(t, test.wantPaused, controller.Status().Paused)
+			switch recorder.Code {
+			case http.StatusUnauthorized:
+				assert.Equal(t, "Bearer", recorder.Header().Get("WWW-Authenticate"))
+			case http.StatusMethodNotAllowed:
+				assert.NotEqual(t, test.method, recorder.Header().Get("Allow"))
+			case http.StatusOK:
+				if test.authorization == "" {
+					return
+				}
+				// the authenticated endpoints return the status after the request
+				var status replay.Status
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &status))
+				assert.Equal(t, controller.Status(), status)
+			}
+		})
+	}
+}
+
+// TestServerWithoutToken checks that an empty token disables the authentication.
+func TestServerWithoutToken(t *testing.T) {
+	controller := &fakeController{}
+	server := New(tmlog.NewNopLogger(), controller, "")
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, controller.Status().Paused)
+}
@@ -0,0 +1,237 @@
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The kinds of audit log entries.
+const (
+	// KindReplayed a proof was replayed to the target contract.
+	KindReplayed = "replayed"
+	// KindVerified a data commitment matched the one generated by the trusted endpoint.
+	KindVerified = "verified"
+	// KindMismatch a data commitment didn't match the one generated by the trusted endpoint.
+	KindMismatch = "mismatch"
+)
+
+// Entry an audit log entry. Each entry contains the hash of the previous one, and is signed
+// by the operator key, which makes the log tamper-evident.
+type Entry struct {
+	Index uint64    `json:"index"`
+	Time  time.Time `json:"time"`
+	Kind  string    `json:"kind"`
+	// ChainID the chain ID of the contract the entry is about.
+	ChainID        string `json:"chain_id,omitempty"`
+	Contract       string `json:"contract"`
+	SourceContract string `json:"source_contract,omitempty"`
+	ProofNonce     int64  `json:"proof_nonce"`
+	StartBlock     uint64 `json:"start_block"`
+	EndBlock       uint64 `json:"end_block"`
+	DataCommitment string `json:"data_commitment"`
+	// TrustedDataCommitment the data commitment returned by the trusted endpoint. Empty if it wasn't queried.
+	TrustedDataCommitment string `json:"trusted_data_commitment,omitempty"`
+	TrustedEndpoint       string `json:"trusted_endpoint,omitempty"`
+	SourceTxHash          string `json:"source_tx_hash,omitempty"`
+	TargetTxHash          string `json:"target_tx_hash,omitempty"`
+
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+	Signer    string `json:"signer"`
+	Signature string `json:"signature"`
+}
+
+// digest returns the keccak256 hash of the entry, excluding its hash and signature.
+func (e Entry) digest() ([]byte, error) {
+	e.Hash = ""
+	e.Signature = ""
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256(bz), nil
+}
+
+// Log an append-only audit log file. Appending to an existing log continues its chain.
+type Log struct {
+	key    *ecdsa.PrivateKey
+	signer ethcmn.Address
+
+	mu       sync.Mutex
+	file     *os.File
+	index    uint64
+	lastHash string
+}
+
+// Open opens the audit log at the provided path, creating it if it doesn't exist.
+// The entries are signed using the provided key.
+func Open(path string, key *ecdsa.PrivateKey) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// the chain is only continued from a valid log, for an edited entry not to be covered by the new ones
+	_, last, err := verify(file, nil)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("verifying the audit log %s: %w", path, err)
+	}
+	l := &Log{
+		key:    key,
+		signer: crypto.PubkeyToAddress(key.PublicKey),
+		file:   file,
+	}
+	if last != nil {
+		l.index = last.Index + 1
+		l.lastHash = last.Hash
+	}
+	return l, nil
+}
+
+// Append chains, signs and appends the entry to the log. The index, time, hashes,
+// signer and signature are set by the log.
+func (l *Log) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.Index = l.index
+	entry.Time = time.Now().UTC()
+	entry.PrevHash = l.lastHash
+	entry.Signer = l.signer.Hex()
+	digest, err := entry.digest()
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.Sign(digest, l.key)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hex.EncodeToString(digest)
+	entry.Signature = hex.EncodeToString(signature)
+
+	bz, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(bz, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.index++
+	l.lastHash = entry.Hash
+	return nil
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// VerifyResult the result of a successful audit log verification.
+type VerifyResult struct {
+	Entries int
+	Signers []ethcmn.Address
+}
+
+// Verify checks that the entries of the log are correctly chained and signed. If trusted signers
+// are provided, the entries must be signed by one of them. It returns an error describing the first
+// invalid entry.
+func Verify(reader io.Reader, trustedSigners []ethcmn.Address) (VerifyResult, error) {
+	result, _, err := verify(reader, trustedSigners)
+	if err != nil {
+		return result, err
+	}
+	if result.Entries == 0 {
+		return result, errors.New("the audit log is empty")
+	}
+	return result, nil
+}
+
+// verify checks the entries of the log like Verify, and returns the last entry, or nil if the log is empty.
+func verify(reader io.Reader, trustedSigners []ethcmn.Address) (VerifyResult, *Entry, error) {
+	trusted := make(map[ethcmn.Address]struct{}, len(trustedSigners))
+	for _, signer := range trustedSigners {
+		trusted[signer] = struct{}{}
+	}
+
+	var (
+		result   VerifyResult
+		seen     = make(map[ethcmn.Address]struct{})
+		prevHash string
+		last     *Entry
+	)
+	scanner := newScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return result, nil, fmt.Errorf("line %d: invalid entry: %w", line, err)
+		}
+		if entry.Index != uint64(result.Entries) {
+			return result, nil, fmt.Errorf("line %d: expected index %d, got %d", line, result.Entries, entry.Index)
+		}
+		if entry.PrevHash != prevHash {
+			return result, nil, fmt.Errorf("line %d: broken chain, expected previous hash %q, got %q", line, prevHash, entry.PrevHash)
+		}
+		digest, err := entry.digest()
+		if err != nil {
+			return result, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if hex.EncodeToString(digest) != strings.ToLower(entry.Hash) {
+			return result, nil, fmt.Errorf("line %d: the entry hash doesn't match its content", line)
+		}
+		signature, err := hex.DecodeString(entry.Signature)
+		if err != nil {
+			return result, nil, fmt.Errorf("line %d: invalid signature encoding: %w", line, err)
+		}
+		pubKey, err := crypto.SigToPub(digest, signature)
+		if err != nil {
+			return result, nil, fmt.Errorf("line %d: invalid signature: %w", line, err)
+		}
+		signer := crypto.PubkeyToAddress(*pubKey)
+		if !ethcmn.IsHexAddress(entry.Signer) || signer != ethcmn.HexToAddress(entry.Signer) {
+			return result, nil, fmt.Errorf("line %d: the entry is signed by %s instead of %s", line, signer.Hex(), entry.Signer)
+		}
+		if len(trusted) != 0 {
+			if _, ok := trusted[signer]; !ok {
+				return result, nil, fmt.Errorf("line %d: the entry is signed by the untrusted signer %s", line, signer.Hex())
+			}
+		}
+		if _, ok := seen[signer]; !ok {
+			seen[signer] = struct{}{}
+			result.Signers = append(result.Signers, signer)
+		}
+		prevHash = entry.Hash
+		last = &entry
+		result.Entries++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, nil, err
+	}
+	return result, last, nil
+}
+
+func newScanner(reader io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	return scanner
+}
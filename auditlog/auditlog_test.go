@@ -0,0 +1,211 @@
+package auditlog
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeLog appends an entry per end block to a new audit log signed with the key, and returns its path.
+func writeLog(t *testing.T, key *ecdsa.PrivateKey, endBlocks ...uint64) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := Open(path, key)
+	require.NoError(t, err)
+	for i, endBlock := range endBlocks {
+		require.NoError(t, log.Append(Entry{
+			Kind:           KindReplayed,
+			Contract:       "0x0000000000000000000000000000000000000001",
+			ProofNonce:     int64(i + 1),
+			StartBlock:     endBlock - 10,
+			EndBlock:       endBlock,
+			DataCommitment: strings.Repeat("ab", 32),
+		}))
+	}
+	require.NoError(t, log.Close())
+	return path
+}
+
+// readLines returns the non-empty lines of the file.
+func readLines(t *testing.T, path string) [][]byte {
+	t.Helper()
+	bz, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return bytes.Split(bytes.TrimSpace(bz), []byte{'\n'})
+}
+
+// resign sets the hash and signature of the entry after it was edited, signing it with the key.
+func resign(t *testing.T, line []byte, key *ecdsa.PrivateKey, edit func(entry *Entry)) []byte {
+	t.Helper()
+	var entry Entry
+	require.NoError(t, json.Unmarshal(line, &entry))
+	edit(&entry)
+	entry.Signer = crypto.PubkeyToAddress(key.PublicKey).Hex()
+	digest, err := entry.digest()
+	require.NoError(t, err)
+	signature, err := crypto.Sign(digest, key)
+	require.NoError(t, err)
+	entry.Hash = hex.EncodeToString(digest)
+	entry.Signature = hex.EncodeToString(signature)
+	bz, err := json.Marshal(entry)
+	require.NoError(t, err)
+	return bz
+}
+
+func TestAppendVerify(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	path := writeLog(t, key, 1010, 1020)
+
+	// reopening the log continues its chain
+	log, err := Open(path, key)
+	require.NoError(t, err)
+	require.NoError(t, log.Append(Entry{Kind: KindVerified, ProofNonce: 3, StartBlock: 1020, EndBlock: 1030}))
+	require.NoError(t, log.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	result, err := Verify(file, []ethcmn.Address{crypto.PubkeyToAddress(key.PublicKey)})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Entries)
+	assert.Equal(t, []ethcmn.Address{crypto.PubkeyToAddress(key.PublicKey)}, result.Signers)
+
+	lines := readLines(t, path)
+	require.Len(t, lines, 3)
+	var last Entry
+	require.NoError(t, json.Unmarshal(lines[2], &last))
+	assert.Equal(t, uint64(2), last.Index)
+	assert.Equal(t, KindVerified, last.Kind)
+}
+
+func TestVerify(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		// tamper edits the lines of a valid log of three entries.
+		tamper         func(t *testing.T, lines [][]byte) [][]byte
+		trustedSigners []ethcmn.Address
+		wantErr        string
+	}{
+		{
+			name:           "valid log",
+			tamper:         func(_ *testing.T, lines [][]byte) [][]byte { return lines },
+			trustedSigners: []ethcmn.Address{crypto.PubkeyToAddress(key.PublicKey)},
+		},
+		{
+			name: "edited field",
+			tamper: func(_ *testing.T, lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"end_block":1020`), []byte(`"end_block":1021`), 1)
+				return lines
+			},
+			wantErr: "line 2: the entry hash doesn't match its content",
+		},
+		{
+			name: "reordered lines",
+			tamper: func(_ *testing.T, lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantErr: "line 2: expected index 1, got 2",
+		},
+		{
+			name: "dropped line",
+			tamper: func(_ *testing.T, lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2])
+			},
+			wantErr: "line 2: expected index 1, got 2",
+		},
+		{
+			name: "dropped first line",
+			tamper: func(_ *testing.T, lines [][]byte) [][]byte {
+				return lines[1:]
+			},
+			wantErr: "line 1: expected index 0, got 1",
+		},
+		{
+			name: "wrong previous hash",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				lines[2] = resign(t, lines[2], key, func(entry *Entry) { entry.PrevHash = strings.Repeat("00", 32) })
+				return lines
+			},
+			wantErr: "line 3: broken chain",
+		},
+		{
+			name: "edited signer",
+			tamper: func(_ *testing.T, lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(crypto.PubkeyToAddress(key.PublicKey).Hex()), []byte(crypto.PubkeyToAddress(otherKey.PublicKey).Hex()), 1)
+				return lines
+			},
+			wantErr: "line 2: the entry hash doesn't match its content",
+		},
+		{
+			name: "untrusted signer",
+			tamper: func(t *testing.T, lines [][]byte) [][]byte {
+				// the rest of the log is chained to the entry signed again by the other key
+				for i := range lines {
+					var prevHash string
+					if i > 0 {
+						var prev Entry
+						require.NoError(t, json.Unmarshal(lines[i-1], &prev))
+						prevHash = prev.Hash
+					}
+					signer := key
+					if i == 1 {
+						signer = otherKey
+					}
+					lines[i] = resign(t, lines[i], signer, func(entry *Entry) { entry.PrevHash = prevHash })
+				}
+				return lines
+			},
+			trustedSigners: []ethcmn.Address{crypto.PubkeyToAddress(key.PublicKey)},
+			wantErr:        "line 2: the entry is signed by the untrusted signer " + crypto.PubkeyToAddress(otherKey.PublicKey).Hex(),
+		},
+		{
+			name: "empty log",
+			tamper: func(_ *testing.T, _ [][]byte) [][]byte {
+				return nil
+			},
+			wantErr: "the audit log is empty",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := test.tamper(t, readLines(t, writeLog(t, key, 1010, 1020, 1030)))
+			result, err := Verify(bytes.NewReader(bytes.Join(lines, []byte{'\n'})), test.trustedSigners)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 3, result.Entries)
+		})
+	}
+}
+
+// TestOpenTamperedLog checks that a tampered log isn't continued, even if only its last entry was edited.
+func TestOpenTamperedLog(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	path := writeLog(t, key, 1010, 1020)
+	lines := readLines(t, path)
+	lines[1] = bytes.Replace(lines[1], []byte(`"proof_nonce":2`), []byte(`"proof_nonce":3`), 1)
+	require.NoError(t, os.WriteFile(path, append(bytes.Join(lines, []byte{'\n'}), '\n'), 0o644))
+
+	_, err = Open(path, key)
+	require.ErrorContains(t, err, "line 2: the entry hash doesn't match its content")
+}
@@ -0,0 +1,60 @@
+package blobstreamxext
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+)
+
+// ABI the ABI of the BlobstreamX functions that are not part of the upstream BlobstreamX Go bindings,
+// which were generated before they were added to the contract.
+const ABI = `[
+	{"type":"function","name":"updateGenesisState","stateMutability":"nonpayable","inputs":[{"name":"_height","type":"uint32"},{"name":"_header","type":"bytes32"}],"outputs":[]},
+	{"type":"function","name":"updateFreeze","stateMutability":"nonpayable","inputs":[{"name":"_freeze","type":"bool"}],"outputs":[]},
+	{"type":"function","name":"frozen","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+// BlobstreamX a binding of the BlobstreamX functions that are not part of the upstream bindings.
+type BlobstreamX struct {
+	contract *bind.BoundContract
+}
+
+// New creates a binding of the BlobstreamX contract deployed at the provided address.
+func New(address ethcmn.Address, backend bind.ContractBackend) (*BlobstreamX, error) {
+	parsed, err := abi.JSON(strings.NewReader(ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &BlobstreamX{
+		contract: bind.NewBoundContract(address, parsed, backend, backend, backend),
+	}, nil
+}
+
+// UpdateGenesisState sets the contract latest block to the provided height, trusting the provided header hash.
+// Only the guardian can update the genesis state.
+func (b *BlobstreamX) UpdateGenesisState(opts *bind.TransactOpts, height uint64, header [32]byte) (*coregethtypes.Transaction, error) {
+	if height > math.MaxUint32 {
+		return nil, fmt.Errorf("the genesis height %d doesn't fit in 32 bits", height)
+	}
+	return b.contract.Transact(opts, "updateGenesisState", uint32(height), header)
+}
+
+// UpdateFreeze freezes or unfreezes the contract. While frozen, the contract doesn't accept new proofs.
+// Only the guardian can freeze the contract.
+func (b *BlobstreamX) UpdateFreeze(opts *bind.TransactOpts, freeze bool) (*coregethtypes.Transaction, error) {
+	return b.contract.Transact(opts, "updateFreeze", freeze)
+}
+
+// Frozen returns true if the contract is frozen.
+func (b *BlobstreamX) Frozen(opts *bind.CallOpts) (bool, error) {
+	var out []interface{}
+	if err := b.contract.Call(opts, &out, "frozen"); err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
@@ -0,0 +1,340 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Command the admin command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "admin",
+		Short:        "BlobstreamX administration commands",
+		Long:         "administers a BlobstreamX contract: every command shows the current and new contract state, then asks for confirmation before sending the transactions",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		FreezeCommand(),
+		UnfreezeCommand(),
+		UpdateGenesisCommand(),
+		UpdateFunctionIDsCommand(),
+		UpdateGatewayCommand(),
+		TransferOwnershipCommand(),
+	)
+
+	cmd.SetHelpCommand(&cobra.Command{})
+
+	return cmd
+}
+
+// FreezeCommand the admin freeze command
+func FreezeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "freeze",
+		Short:        "Freezes the BlobstreamX contract so that it stops accepting new proofs",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd, updateFreeze(true))
+		},
+	}
+	return addFlags(cmd)
+}
+
+// UnfreezeCommand the admin unfreeze command
+func UnfreezeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "unfreeze",
+		Short:        "Unfreezes the BlobstreamX contract",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return run(cmd, updateFreeze(false))
+		},
+	}
+	return addFlags(cmd)
+}
+
+func updateFreeze(freeze bool) buildOperation {
+	return func(ctx context.Context, _ Config, contracts contracts) (operation, error) {
+		frozen, err := contracts.ext.Frozen(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return operation{}, err
+		}
+		op := operation{changes: []change{{field: "frozen", current: strconv.FormatBool(frozen), new: strconv.FormatBool(freeze)}}}
+		if frozen != freeze {
+			op.calls = []call{{
+				description: fmt.Sprintf("updateFreeze(%t)", freeze),
+				send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return contracts.ext.UpdateFreeze(opts, freeze)
+				},
+			}}
+		}
+		return op, nil
+	}
+}
+
+// UpdateGenesisCommand the admin update-genesis command
+func UpdateGenesisCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "update-genesis <height> <header hash>",
+		Short:        "Resets the BlobstreamX contract latest block to the provided height and trusted header hash",
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			height, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid genesis height %q: %w", args[0], err)
+			}
+			header, err := parseHash(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid genesis header hash: %w", err)
+			}
+			return run(cmd, func(ctx context.Context, _ Config, contracts contracts) (operation, error) {
+				callOpts := &bind.CallOpts{Context: ctx}
+				latestBlock, err := contracts.blobstreamX.LatestBlock(callOpts)
+				if err != nil {
+					return operation{}, err
+				}
+				latestHeader, err := contracts.blobstreamX.BlockHeightToHeaderHash(callOpts, latestBlock)
+				if err != nil {
+					return operation{}, err
+				}
+				return operation{
+					changes: []change{
+						{field: "latest block", current: strconv.FormatUint(latestBlock, 10), new: strconv.FormatUint(height, 10)},
+						{field: "latest header", current: ethcmn.Hash(latestHeader).Hex(), new: header.Hex()},
+					},
+					calls: []call{{
+						description: fmt.Sprintf("updateGenesisState(%d, %s)", height, header.Hex()),
+						send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+							return contracts.ext.UpdateGenesisState(opts, height, header)
+						},
+					}},
+				}, nil
+			})
+		},
+	}
+	return addFlags(cmd)
+}
+
+// UpdateFunctionIDsCommand the admin update-function-ids command
+func UpdateFunctionIDsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "update-function-ids",
+		Short:        "Updates the BlobstreamX contract header range and/or next header function IDs",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			headerRangeFunctionID, err := parseOptionalFunctionID(FlagHeaderRangeFunctionID)
+			if err != nil {
+				return err
+			}
+			nextHeaderFunctionID, err := parseOptionalFunctionID(FlagNextHeaderFunctionID)
+			if err != nil {
+				return err
+			}
+			if headerRangeFunctionID == nil && nextHeaderFunctionID == nil {
+				return fmt.Errorf("please set at least one of --%s and --%s", FlagHeaderRangeFunctionID, FlagNextHeaderFunctionID)
+			}
+			return run(cmd, func(ctx context.Context, _ Config, contracts contracts) (operation, error) {
+				callOpts := &bind.CallOpts{Context: ctx}
+				var op operation
+				if headerRangeFunctionID != nil {
+					current, err := contracts.blobstreamX.HeaderRangeFunctionId(callOpts)
+					if err != nil {
+						return operation{}, err
+					}
+					op.add("header range function ID", current, *headerRangeFunctionID, "updateHeaderRangeId", contracts.blobstreamX.UpdateHeaderRangeId)
+				}
+				if nextHeaderFunctionID != nil {
+					current, err := contracts.blobstreamX.NextHeaderFunctionId(callOpts)
+					if err != nil {
+						return operation{}, err
+					}
+					op.add("next header function ID", current, *nextHeaderFunctionID, "updateNextHeaderId", contracts.blobstreamX.UpdateNextHeaderId)
+				}
+				return op, nil
+			})
+		},
+	}
+	return addFunctionIDsFlags(cmd)
+}
+
+// add adds the function ID change, and its call if the function ID changed.
+func (op *operation) add(
+	field string,
+	current, updated [32]byte,
+	method string,
+	update func(opts *bind.TransactOpts, functionID [32]byte) (*coregethtypes.Transaction, error),
+) {
+	op.changes = append(op.changes, change{field: field, current: ethcmn.Hash(current).Hex(), new: ethcmn.Hash(updated).Hex()})
+	if current == updated {
+		return
+	}
+	op.calls = append(op.calls, call{
+		description: fmt.Sprintf("%s(%s)", method, ethcmn.Hash(updated).Hex()),
+		send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+			return update(opts, updated)
+		},
+	})
+}
+
+// UpdateGatewayCommand the admin update-gateway command
+func UpdateGatewayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "update-gateway <gateway address>",
+		Short:        "Updates the succinct gateway used by the BlobstreamX contract",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gateway, err := parseAddress(args[0])
+			if err != nil {
+				return err
+			}
+			return run(cmd, func(ctx context.Context, _ Config, contracts contracts) (operation, error) {
+				current, err := contracts.blobstreamX.Gateway(&bind.CallOpts{Context: ctx})
+				if err != nil {
+					return operation{}, err
+				}
+				op := operation{changes: []change{{field: "gateway", current: current.Hex(), new: gateway.Hex()}}}
+				if current != gateway {
+					op.calls = []call{{
+						description: fmt.Sprintf("updateGateway(%s)", gateway.Hex()),
+						send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+							return contracts.blobstreamX.UpdateGateway(opts, gateway)
+						},
+					}}
+				}
+				return op, nil
+			})
+		},
+	}
+	return addFlags(cmd)
+}
+
+// TransferOwnershipCommand the admin transfer-ownership command
+func TransferOwnershipCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transfer-ownership <new owner address>",
+		Short: "Transfers the roles held by the sender on the BlobstreamX contract to a new owner",
+		Long: "grants each role held by the sender, i.e. the default admin, guardian and timelock roles, to the new owner, " +
+			"then renounces it. The default admin role is transferred last so that the sender can still grant the other roles",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			newOwner, err := parseAddress(args[0])
+			if err != nil {
+				return err
+			}
+			return run(cmd, func(ctx context.Context, config Config, contracts contracts) (operation, error) {
+				if config.From == (ethcmn.Address{}) {
+					return operation{}, fmt.Errorf("the sender is needed to find the roles to transfer, please set --%s", FlagFrom)
+				}
+				if config.From == newOwner {
+					return operation{}, fmt.Errorf("the new owner is already the sender %s", newOwner.Hex())
+				}
+				return transferOwnership(ctx, contracts, config.From, newOwner)
+			})
+		},
+	}
+	return addFlags(cmd)
+}
+
+func transferOwnership(ctx context.Context, contracts contracts, from, newOwner ethcmn.Address) (operation, error) {
+	callOpts := &bind.CallOpts{Context: ctx}
+	adminRole, err := contracts.blobstreamX.DEFAULTADMINROLE(callOpts)
+	if err != nil {
+		return operation{}, err
+	}
+	guardianRole, err := contracts.blobstreamX.GUARDIANROLE(callOpts)
+	if err != nil {
+		return operation{}, err
+	}
+	timelockRole, err := contracts.blobstreamX.TIMELOCKROLE(callOpts)
+	if err != nil {
+		return operation{}, err
+	}
+
+	var op operation
+	roles := []struct {
+		name string
+		id   [32]byte
+	}{
+		{name: "guardian", id: guardianRole},
+		{name: "timelock", id: timelockRole},
+		{name: "default admin", id: adminRole},
+	}
+	for _, role := range roles {
+		held, err := contracts.blobstreamX.HasRole(callOpts, role.id, from)
+		if err != nil {
+			return operation{}, err
+		}
+		if !held {
+			continue
+		}
+		op.changes = append(op.changes, change{field: role.name + " role", current: from.Hex(), new: newOwner.Hex()})
+		op.calls = append(op.calls,
+			call{
+				description: fmt.Sprintf("grantRole(%s, %s)", role.name, newOwner.Hex()),
+				send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return contracts.blobstreamX.GrantRole(opts, role.id, newOwner)
+				},
+			},
+			call{
+				description: fmt.Sprintf("renounceRole(%s, %s)", role.name, from.Hex()),
+				send: func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return contracts.blobstreamX.RenounceRole(opts, role.id, from)
+				},
+			},
+		)
+	}
+	if len(op.calls) == 0 {
+		return operation{}, fmt.Errorf("the sender %s holds no role on the BlobstreamX contract", from.Hex())
+	}
+	return op, nil
+}
+
+func parseOptionalFunctionID(flag string) (*[32]byte, error) {
+	raw := viper.GetString(flag)
+	if raw == "" {
+		return nil, nil
+	}
+	functionID, err := fulfillcall.ParseFunctionID(raw)
+	if err != nil {
+		return nil, fmt.Errorf("--%s: %w", flag, err)
+	}
+	return &functionID, nil
+}
+
+func parseAddress(raw string) (ethcmn.Address, error) {
+	if !ethcmn.IsHexAddress(raw) {
+		return ethcmn.Address{}, fmt.Errorf("invalid address %q", raw)
+	}
+	address := ethcmn.HexToAddress(raw)
+	if address == (ethcmn.Address{}) {
+		return ethcmn.Address{}, fmt.Errorf("the address cannot be zero")
+	}
+	return address, nil
+}
+
+func parseHash(raw string) (ethcmn.Hash, error) {
+	decoded, err := hexutil.Decode(raw)
+	if err != nil {
+		return ethcmn.Hash{}, fmt.Errorf("%q: %w", raw, err)
+	}
+	if len(decoded) != ethcmn.HashLength {
+		return ethcmn.Hash{}, fmt.Errorf("%q: expected %d bytes, got %d", raw, ethcmn.HashLength, len(decoded))
+	}
+	return ethcmn.BytesToHash(decoded), nil
+}
@@ -0,0 +1,163 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signerFlags the flags of the BlobstreamX guardian or admin key or external signer, e.g. evm.private-key.
+var signerFlags = cmdutil.NewSignerFlags("evm")
+
+const (
+	FlagEVMRPC             = "evm.rpc"
+	FlagEVMContractAddress = "evm.contract-address"
+
+	FlagDryRun = "dry-run"
+	FlagFrom   = "from"
+	FlagYes    = "yes"
+
+	FlagHeaderRangeFunctionID = "circuits.header-range.functionID"
+	FlagNextHeaderFunctionID  = "circuits.next-header.functionID"
+
+	FlagLogLevel  = "log.level"
+	FlagLogFormat = "log.format"
+)
+
+func addFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagEVMRPC,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the ethereum rpc address. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMRPC)
+
+	cmd.Flags().String(
+		FlagEVMContractAddress,
+		"",
+		fmt.Sprintf("Specify the contract at which the BlobstreamX is deployed. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMContractAddress)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMContractAddress)
+
+	// the signer isn't needed with --dry-run
+	signerFlags.Add(cmd, "the BlobstreamX guardian or admin")
+
+	cmd.Flags().Bool(
+		FlagDryRun,
+		false,
+		fmt.Sprintf("Set to print the transactions calldata instead of sending them, e.g. to submit them through a multisig. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagDryRun)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagDryRun)
+
+	cmd.Flags().String(
+		FlagFrom,
+		"",
+		fmt.Sprintf("Specify the address sending the transactions when using --%s without a private key, e.g. the multisig. Corresponding environment variable %s", FlagDryRun, cmdutil.ToEnvVariableFormat(FlagFrom)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagFrom)
+
+	cmd.Flags().Bool(
+		FlagYes,
+		false,
+		fmt.Sprintf("Set to send the transactions without asking for confirmation. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagYes)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagYes)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+func addFunctionIDsFlags(cmd *cobra.Command) *cobra.Command {
+	cmd.Flags().String(
+		FlagHeaderRangeFunctionID,
+		"",
+		fmt.Sprintf("Specify the new function ID of the header range circuit, in hex format. If not set, it's not updated. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeaderRangeFunctionID)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagHeaderRangeFunctionID)
+
+	cmd.Flags().String(
+		FlagNextHeaderFunctionID,
+		"",
+		fmt.Sprintf("Specify the new function ID of the next header circuit, in hex format. If not set, it's not updated. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNextHeaderFunctionID)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNextHeaderFunctionID)
+
+	return addFlags(cmd)
+}
+
+type Config struct {
+	EVMRPC             string
+	EVMContractAddress string
+	// Signer the guardian or admin key or external signer. Not set if only printing the calldata.
+	Signer cmdutil.SignerConfig
+	// From the address sending the transactions. Defaults to the signer address.
+	From      ethcmn.Address
+	DryRun    bool
+	Yes       bool
+	LogLevel  string
+	LogFormat string
+}
+
+func (cfg Config) ValidateBasics() error {
+	if cfg.EVMContractAddress == "" {
+		return fmt.Errorf("the EVM address cannot be empty: flag --%s or environment variable %s", FlagEVMContractAddress, cmdutil.ToEnvVariableFormat(FlagEVMContractAddress))
+	}
+	if !ethcmn.IsHexAddress(cfg.EVMContractAddress) {
+		return errors.New("valid EVM address is required: flag --" + FlagEVMContractAddress)
+	}
+	if !cfg.Signer.IsSet() && !cfg.DryRun {
+		return fmt.Errorf("please set the private key --%s or %s, or another signer, or use --%s", signerFlags.PrivateKey, cmdutil.ToEnvVariableFormat(signerFlags.PrivateKey), FlagDryRun)
+	}
+	if cfg.Signer.IsSet() && cfg.From != cfg.Signer.Address() {
+		return fmt.Errorf("the --%s address doesn't match the signer address", FlagFrom)
+	}
+	return nil
+}
+
+func parseFlags() (Config, error) {
+	// the signer is validated against --dry-run in ValidateBasics
+	signerConfig, err := signerFlags.Parse(true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var from ethcmn.Address
+	if rawFrom := viper.GetString(FlagFrom); rawFrom != "" {
+		if !ethcmn.IsHexAddress(rawFrom) {
+			return Config{}, fmt.Errorf("invalid --%s address %q", FlagFrom, rawFrom)
+		}
+		from = ethcmn.HexToAddress(rawFrom)
+	} else if signerConfig.IsSet() {
+		from = signerConfig.Address()
+	}
+
+	return Config{
+		EVMRPC:             viper.GetString(FlagEVMRPC),
+		EVMContractAddress: viper.GetString(FlagEVMContractAddress),
+		Signer:             signerConfig,
+		From:               from,
+		DryRun:             viper.GetBool(FlagDryRun),
+		Yes:                viper.GetBool(FlagYes),
+		LogLevel:           viper.GetString(FlagLogLevel),
+		LogFormat:          viper.GetString(FlagLogFormat),
+	}, nil
+}
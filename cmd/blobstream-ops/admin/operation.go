@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/blobstreamxext"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/spf13/cobra"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// dryRunGasLimit the gas limit set on the transactions built with --dry-run, so that they're not estimated:
+// the estimation reverts if the sender isn't allowed to perform the operation.
+const dryRunGasLimit = 500000
+
+// change a contract state change performed by an operation.
+type change struct {
+	field   string
+	current string
+	new     string
+}
+
+// call a transaction performed by an operation.
+type call struct {
+	description string
+	send        func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error)
+}
+
+// operation an admin operation on the BlobstreamX contract.
+type operation struct {
+	changes []change
+	calls   []call
+}
+
+// contracts the bindings of the administered BlobstreamX contract.
+type contracts struct {
+	address     ethcmn.Address
+	blobstreamX *blobstreamxwrapper.BlobstreamX
+	ext         *blobstreamxext.BlobstreamX
+}
+
+// buildOperation reads the current contract state and builds the operation to perform.
+type buildOperation func(ctx context.Context, config Config, contracts contracts) (operation, error)
+
+// run runs the operation built by the provided function: it prints the state changes, then either prints the
+// transactions calldata if --dry-run is set, or sends the transactions once confirmed.
+func run(cmd *cobra.Command, build buildOperation) error {
+	config, err := parseFlags()
+	if err != nil {
+		return err
+	}
+	if err := config.ValidateBasics(); err != nil {
+		return err
+	}
+
+	logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	// Listen for and trap any OS signal to graceful shutdown and exit
+	go cmdutil.TrapSignal(logger, cancel)
+
+	// the admin operations aren't recorded, the tape only dials the endpoint
+	tape, err := rpcrecord.New("", "")
+	if err != nil {
+		return err
+	}
+	evmClient, err := cmdutil.DialEVMClient(ctx, tape, "evm", config.EVMRPC)
+	if err != nil {
+		return err
+	}
+	defer evmClient.Close()
+
+	address := ethcmn.HexToAddress(config.EVMContractAddress)
+	blobstreamX, err := blobstreamxwrapper.NewBlobstreamX(address, evmClient)
+	if err != nil {
+		return err
+	}
+	ext, err := blobstreamxext.New(address, evmClient)
+	if err != nil {
+		return err
+	}
+
+	op, err := build(ctx, config, contracts{address: address, blobstreamX: blobstreamX, ext: ext})
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	printChanges(out, address, op.changes)
+	if len(op.calls) == 0 {
+		fmt.Fprintln(out, "nothing to do")
+		return nil
+	}
+
+	opts, closeSigner, err := transactOpts(ctx, config, evmClient)
+	if err != nil {
+		return err
+	}
+	defer closeSigner()
+
+	if config.DryRun {
+		opts.NoSend = true
+		opts.GasLimit = dryRunGasLimit
+		for _, c := range op.calls {
+			tx, err := c.send(opts)
+			if err != nil {
+				return fmt.Errorf("%s: %w", c.description, err)
+			}
+			fmt.Fprintf(out, "\n%s\n  to:   %s\n  data: %s\n", c.description, tx.To().Hex(), hexutil.Encode(tx.Data()))
+		}
+		return nil
+	}
+
+	if !config.Yes {
+		confirmed, err := confirm(cmd.InOrStdin(), out)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	for _, c := range op.calls {
+		if err := send(ctx, logger, evmClient, opts, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transactOpts creates the options of the admin transactions. Without a signer, i.e. with --dry-run, the
+// transactions are built unsigned. The returned function closes the connection to the external signer.
+func transactOpts(ctx context.Context, config Config, evmClient *ethclient.Client) (*bind.TransactOpts, func(), error) {
+	if !config.Signer.IsSet() {
+		return &bind.TransactOpts{
+			From: config.From,
+			Signer: func(_ ethcmn.Address, tx *coregethtypes.Transaction) (*coregethtypes.Transaction, error) {
+				return tx, nil
+			},
+			Context: ctx,
+		}, func() {}, nil
+	}
+	chainID, err := evmClient.ChainID(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	txSigner, closeSigner, err := config.Signer.NewSigner(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return signer.NewTransactOpts(ctx, txSigner, chainID), closeSigner, nil
+}
+
+// send sends the call transaction and waits for it to succeed. The nonce is left to the backend so that
+// subsequent calls use the next one.
+func send(ctx context.Context, logger tmlog.Logger, evmClient *ethclient.Client, opts *bind.TransactOpts, c call) error {
+	tx, err := c.send(opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.description, err)
+	}
+	logger.Info("transaction submitted", "what", c.description, "hash", tx.Hash().Hex())
+	receipt, err := bind.WaitMined(ctx, evmClient, tx)
+	if err != nil {
+		return fmt.Errorf("waiting for the %s transaction %s: %w", c.description, tx.Hash().Hex(), err)
+	}
+	if receipt.Status != coregethtypes.ReceiptStatusSuccessful {
+		return fmt.Errorf("the %s transaction %s reverted", c.description, tx.Hash().Hex())
+	}
+	logger.Info("transaction confirmed", "what", c.description, "hash", tx.Hash().Hex(), "block", receipt.BlockNumber.Uint64())
+	return nil
+}
+
+// printChanges prints the current and new state of the changed fields.
+func printChanges(out io.Writer, address ethcmn.Address, changes []change) {
+	fmt.Fprintf(out, "BlobstreamX %s\n", address.Hex())
+	for _, c := range changes {
+		if c.current == c.new {
+			fmt.Fprintf(out, "  %s: %s (unchanged)\n", c.field, c.current)
+			continue
+		}
+		fmt.Fprintf(out, "  %s:\n    - %s\n    + %s\n", c.field, c.current, c.new)
+	}
+}
+
+// confirm asks for confirmation before sending the transactions.
+func confirm(in io.Reader, out io.Writer) (bool, error) {
+	fmt.Fprint(out, "Proceed? [y/N] ")
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/celestiaorg/blobstream-ops/auditlog"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/spf13/cobra"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// Command the audit command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "audit",
+		Short:        "BlobstreamX operations auditing",
+		Long:         "tooling for auditing the BlobstreamX operations",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		VerifyLogCommand(),
+		DeploymentsCommand(),
+	)
+
+	cmd.SetHelpCommand(&cobra.Command{})
+
+	return cmd
+}
+
+// VerifyLogCommand the audit log verification command.
+func VerifyLogCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "verify-log <path> <flags>",
+		Short: "Verifies the hash chain and signatures of an audit log",
+		Long: "verifies, offline, that the entries of an audit log written by the replay and verify commands " +
+			"are correctly chained and signed, optionally by one of the trusted signers",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := parseVerifyLogFlags(args[0])
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(config.Path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			result, err := auditlog.Verify(file, config.Signers)
+			if err != nil {
+				logger.Error("audit log verification failed", "path", config.Path, "err", err.Error())
+				return err
+			}
+			signers := make([]string, 0, len(result.Signers))
+			for _, signer := range result.Signers {
+				signers = append(signers, signer.Hex())
+			}
+			logger.Info("audit log verified", "path", config.Path, "entries", result.Entries, "signers", signers)
+			return nil
+		},
+	}
+	return addVerifyLogFlags(command)
+}
+
+// DeploymentsCommand the deployments security audit command.
+func DeploymentsCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "deployments <expected values file> <flags>",
+		Short: "Audits the security relevant configuration of BlobstreamX deployments",
+		Long: "reads the proxy implementation, role holders, frozen flag, gateway, function IDs and verifiers of the " +
+			"BlobstreamX deployments listed in the expected values file, and fails if any of them drifted from " +
+			"the expected values",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := parseDeploymentsFlags(args[0])
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			targets, err := deploy.LoadAuditTargets(config.Path)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			reports := make([]deploy.AuditReport, 0, len(targets.Deployments))
+			failed := 0
+			for _, target := range targets.Deployments {
+				report := auditDeployment(ctx, logger, config, target)
+				if !report.Passed() {
+					failed++
+				}
+				reports = append(reports, report)
+			}
+
+			if err := writeReports(config.Output, reports); err != nil {
+				return err
+			}
+			if failed != 0 {
+				return fmt.Errorf("%d of %d deployments failed the audit", failed, len(reports))
+			}
+			logger.Info("all the deployments passed the audit", "deployments", len(reports))
+			return nil
+		},
+	}
+	return addDeploymentsFlags(command)
+}
+
+// auditDeployment audits the deployment and compares it against its expected configuration, if any.
+func auditDeployment(ctx context.Context, logger tmlog.Logger, config DeploymentsConfig, target deploy.AuditTarget) deploy.AuditReport {
+	report := deploy.AuditReport{Name: target.Name, Address: target.Address}
+	logger = logger.With("deployment", target.Name, "address", target.Address.Hex())
+
+	// the audit isn't recorded, the tape only dials the endpoint
+	tape, err := rpcrecord.New("", "")
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	evmClient, err := cmdutil.DialEVMClient(ctx, tape, "evm", target.RPC)
+	if err != nil {
+		logger.Error("couldn't dial the deployment chain", "err", err.Error())
+		report.Error = err.Error()
+		return report
+	}
+	defer evmClient.Close()
+
+	report.Actual, err = deploy.Audit(ctx, logger, evmClient, target.Address, target.FromBlock, config.FilterRange)
+	if err != nil {
+		logger.Error("couldn't audit the deployment", "err", err.Error())
+		report.Error = err.Error()
+		return report
+	}
+	if target.Expected == nil {
+		logger.Info("no expected values, the deployment configuration is only reported")
+		return report
+	}
+	report.Drifts = deploy.Compare(*target.Expected, report.Actual)
+	for _, drift := range report.Drifts {
+		logger.Error("configuration drift", "field", drift.Field, "expected", drift.Expected, "actual", drift.Actual)
+	}
+	if len(report.Drifts) == 0 {
+		logger.Info("the deployment matches the expected values")
+	}
+	return report
+}
+
+// writeReports writes the audit reports as JSON to the provided path, or to the standard output if it's "-".
+func writeReports(path string, reports []deploy.AuditReport) error {
+	if path == "" {
+		return nil
+	}
+	bz, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return err
+	}
+	bz = append(bz, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(bz)
+		return err
+	}
+	return os.WriteFile(path, bz, 0o644)
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	FlagSigners = "signers"
+
+	FlagEVMFilterRange = "evm.filter-range"
+	FlagOutput         = "output"
+
+	FlagLogLevel  = "log.level"
+	FlagLogFormat = "log.format"
+)
+
+func addVerifyLogFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().StringSlice(
+		FlagSigners,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of the EVM addresses trusted to sign the audit log entries. If not set, any signer is accepted and reported. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSigners)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSigners)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+type VerifyLogConfig struct {
+	Path      string
+	Signers   []ethcmn.Address
+	LogLevel  string
+	LogFormat string
+}
+
+func (cfg VerifyLogConfig) ValidateBasics() error {
+	if cfg.Path == "" {
+		return fmt.Errorf("the audit log path cannot be empty")
+	}
+	return nil
+}
+
+func parseVerifyLogFlags(path string) (VerifyLogConfig, error) {
+	var signers []ethcmn.Address
+	for _, signer := range viper.GetStringSlice(FlagSigners) {
+		if !ethcmn.IsHexAddress(signer) {
+			return VerifyLogConfig{}, fmt.Errorf("invalid signer address %q: flag --%s", signer, FlagSigners)
+		}
+		signers = append(signers, ethcmn.HexToAddress(signer))
+	}
+
+	return VerifyLogConfig{
+		Path:      path,
+		Signers:   signers,
+		LogLevel:  viper.GetString(FlagLogLevel),
+		LogFormat: viper.GetString(FlagLogFormat),
+	}, nil
+}
+
+func addDeploymentsFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().Int64(
+		FlagEVMFilterRange,
+		5000,
+		fmt.Sprintf("Specify the eth_getLogs filter range used to find the role holders. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMFilterRange)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMFilterRange)
+
+	cmd.Flags().String(
+		FlagOutput,
+		"",
+		fmt.Sprintf("Specify the file the JSON audit report is written to, or - for the standard output. If not set, the report is only logged. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagOutput)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+type DeploymentsConfig struct {
+	Path        string
+	FilterRange int64
+	Output      string
+	LogLevel    string
+	LogFormat   string
+}
+
+func (cfg DeploymentsConfig) ValidateBasics() error {
+	if cfg.Path == "" {
+		return fmt.Errorf("the expected values file path cannot be empty")
+	}
+	if cfg.FilterRange <= 0 {
+		return fmt.Errorf("the filter range should be positive: flag --%s", FlagEVMFilterRange)
+	}
+	return nil
+}
+
+func parseDeploymentsFlags(path string) DeploymentsConfig {
+	return DeploymentsConfig{
+		Path:        path,
+		FilterRange: viper.GetInt64(FlagEVMFilterRange),
+		Output:      viper.GetString(FlagOutput),
+		LogLevel:    viper.GetString(FlagLogLevel),
+		LogFormat:   viper.GetString(FlagLogFormat),
+	}
+}
@@ -0,0 +1,187 @@
+package cmdutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/signer"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// SignerFlags the names of the flags setting an EVM signer: either a private key, a keystore, a mnemonic, or
+// an external signer. They share the same prefix, e.g. evm.private-key and evm.remote-signer.
+type SignerFlags struct {
+	PrivateKey          string
+	Keystore            string
+	KeystorePassphrase  string
+	Mnemonic            string
+	DerivationPath      string
+	RemoteSigner        string
+	RemoteSignerAddress string
+}
+
+// NewSignerFlags returns the names of the signer flags using the provided prefix.
+func NewSignerFlags(prefix string) SignerFlags {
+	return SignerFlags{
+		PrivateKey:          prefix + ".private-key",
+		Keystore:            prefix + ".keystore",
+		KeystorePassphrase:  prefix + ".keystore-passphrase-file",
+		Mnemonic:            prefix + ".mnemonic-file",
+		DerivationPath:      prefix + ".derivation-path",
+		RemoteSigner:        prefix + ".remote-signer",
+		RemoteSignerAddress: prefix + ".remote-signer-address",
+	}
+}
+
+// Add adds the signer flags to the command. The account describes the signing account in the flags usage,
+// e.g. "the deployer".
+func (f SignerFlags) Add(cmd *cobra.Command, account string) {
+	cmd.Flags().String(
+		f.PrivateKey,
+		"",
+		fmt.Sprintf("Specify the EVM private key, in hex format, of %s. Corresponding environment variable %s", account, ToEnvVariableFormat(f.PrivateKey)),
+	)
+	BindFlagAndEnvVar(cmd, f.PrivateKey)
+
+	cmd.Flags().String(
+		f.Keystore,
+		"",
+		fmt.Sprintf("Specify the path to a geth encrypted JSON keystore file holding the EVM key of %s, instead of the private key. Requires --%s. Corresponding environment variable %s", account, f.KeystorePassphrase, ToEnvVariableFormat(f.Keystore)),
+	)
+	BindFlagAndEnvVar(cmd, f.Keystore)
+
+	cmd.Flags().String(
+		f.KeystorePassphrase,
+		"",
+		fmt.Sprintf("Specify the path to the file containing the keystore passphrase. Corresponding environment variable %s", ToEnvVariableFormat(f.KeystorePassphrase)),
+	)
+	BindFlagAndEnvVar(cmd, f.KeystorePassphrase)
+
+	cmd.Flags().String(
+		f.Mnemonic,
+		"",
+		fmt.Sprintf("Specify the path to the file containing a BIP-39 mnemonic the EVM key of %s is derived from, instead of the private key. Corresponding environment variable %s", account, ToEnvVariableFormat(f.Mnemonic)),
+	)
+	BindFlagAndEnvVar(cmd, f.Mnemonic)
+
+	cmd.Flags().String(
+		f.DerivationPath,
+		signer.DefaultDerivationPath,
+		fmt.Sprintf("Specify the BIP-32 derivation path of the EVM key derived from the mnemonic. Corresponding environment variable %s", ToEnvVariableFormat(f.DerivationPath)),
+	)
+	BindFlagAndEnvVar(cmd, f.DerivationPath)
+
+	cmd.Flags().String(
+		f.RemoteSigner,
+		"",
+		fmt.Sprintf("Specify the rpc address of an external signer compatible with clef's account_signTransaction, signing the transactions of %s instead of the private key. Requires --%s. Corresponding environment variable %s", account, f.RemoteSignerAddress, ToEnvVariableFormat(f.RemoteSigner)),
+	)
+	BindFlagAndEnvVar(cmd, f.RemoteSigner)
+
+	cmd.Flags().String(
+		f.RemoteSignerAddress,
+		"",
+		fmt.Sprintf("Specify the address of the external signer account signing the transactions of %s. Corresponding environment variable %s", account, ToEnvVariableFormat(f.RemoteSignerAddress)),
+	)
+	BindFlagAndEnvVar(cmd, f.RemoteSignerAddress)
+}
+
+// SignerConfig an EVM signer set using the signer flags: either a local key, or an external signer.
+type SignerConfig struct {
+	// PrivateKey the EVM key, set using the private key, keystore or mnemonic flags. Nil if a remote signer
+	// is used.
+	PrivateKey *ecdsa.PrivateKey
+	// RemoteSigner the external signer rpc address. Empty if the EVM key is held locally.
+	RemoteSigner        string
+	RemoteSignerAddress ethcmn.Address
+}
+
+// IsSet returns true if a signer is set.
+func (c SignerConfig) IsSet() bool {
+	return c.PrivateKey != nil || c.RemoteSigner != ""
+}
+
+// Address returns the address of the signing account. Zero if no signer is set.
+func (c SignerConfig) Address() ethcmn.Address {
+	if c.PrivateKey == nil {
+		return c.RemoteSignerAddress
+	}
+	return crypto.PubkeyToAddress(c.PrivateKey.PublicKey)
+}
+
+// NewSigner creates the signer: either the locally held EVM key, or the external signer. It returns a nil
+// signer if none is set. The returned function closes the connection to the external signer.
+func (c SignerConfig) NewSigner(ctx context.Context) (signer.Signer, func(), error) {
+	if c.PrivateKey != nil {
+		return signer.NewKey(c.PrivateKey), func() {}, nil
+	}
+	if c.RemoteSigner == "" {
+		return nil, func() {}, nil
+	}
+	remote, err := signer.DialRemote(ctx, c.RemoteSigner, c.RemoteSignerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return remote, remote.Close, nil
+}
+
+// Parse loads the signer set using the flags. Exactly one of the private key, keystore, mnemonic or external
+// signer flags should be set, unless the signer is optional, in which case an empty config is returned if
+// none is set.
+func (f SignerFlags) Parse(optional bool) (SignerConfig, error) {
+	var set []string
+	for _, flag := range []string{f.PrivateKey, f.Keystore, f.Mnemonic, f.RemoteSigner} {
+		if viper.GetString(flag) != "" {
+			set = append(set, "--"+flag)
+		}
+	}
+	if len(set) == 0 && optional {
+		return SignerConfig{}, nil
+	}
+	if len(set) == 0 {
+		return SignerConfig{}, fmt.Errorf(
+			"please set the private key --%s or %s, or one of the flags --%s, --%s or --%s",
+			f.PrivateKey,
+			ToEnvVariableFormat(f.PrivateKey),
+			f.Keystore,
+			f.Mnemonic,
+			f.RemoteSigner,
+		)
+	}
+	if len(set) > 1 {
+		return SignerConfig{}, fmt.Errorf("only one signer can be set, got %s", strings.Join(set, ", "))
+	}
+
+	var key *signer.Key
+	var err error
+	switch {
+	case viper.GetString(f.PrivateKey) != "":
+		key, err = signer.ParseKey(viper.GetString(f.PrivateKey))
+	case viper.GetString(f.Keystore) != "":
+		passphraseFile := viper.GetString(f.KeystorePassphrase)
+		if passphraseFile == "" {
+			return SignerConfig{}, fmt.Errorf("flag --%s is set but the passphrase file is not set. Please set --%s or environment variable %s", f.Keystore, f.KeystorePassphrase, ToEnvVariableFormat(f.KeystorePassphrase))
+		}
+		key, err = signer.LoadKeystore(viper.GetString(f.Keystore), passphraseFile)
+	case viper.GetString(f.Mnemonic) != "":
+		key, err = signer.FromMnemonicFile(viper.GetString(f.Mnemonic), viper.GetString(f.DerivationPath))
+	default:
+		rawAddress := viper.GetString(f.RemoteSignerAddress)
+		if !ethcmn.IsHexAddress(rawAddress) {
+			return SignerConfig{}, fmt.Errorf("valid EVM address is required: flag --%s or environment variable %s", f.RemoteSignerAddress, ToEnvVariableFormat(f.RemoteSignerAddress))
+		}
+		return SignerConfig{
+			RemoteSigner:        viper.GetString(f.RemoteSigner),
+			RemoteSignerAddress: ethcmn.HexToAddress(rawAddress),
+		}, nil
+	}
+	if err != nil {
+		return SignerConfig{}, err
+	}
+	return SignerConfig{PrivateKey: key.PrivateKey()}, nil
+}
@@ -2,13 +2,18 @@ package cmdutil
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -16,6 +21,7 @@ import (
 	"github.com/rs/zerolog"
 	tmconfig "github.com/tendermint/tendermint/config"
 	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
 )
 
 // GetLogger creates a new logger and returns
@@ -68,3 +74,70 @@ func BindFlagAndEnvVar(cmd *cobra.Command, flag string) {
 		os.Exit(1)
 	}
 }
+
+// BindExecutedCommandFlags binds the flags of the executed command to viper. BindFlagAndEnvVar binds the
+// flags when the commands are created, so when several commands define the same flag, e.g. the log.level
+// and core.rpc flags of the verify and replay commands, viper reads the flag of the last created command
+// and ignores the value set on the executed one. It's meant to be used as the root PersistentPreRunE.
+func BindExecutedCommandFlags(cmd *cobra.Command, _ []string) error {
+	return viper.BindPFlags(cmd.Flags())
+}
+
+// ServeHTTP serves the handler on the provided address until the context is canceled,
+// then gracefully shuts down the server.
+func ServeHTTP(ctx context.Context, logger tmlog.Logger, address string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		logger.Info("starting HTTP server", "address", address)
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// DialEVMClient connects to an EVM endpoint through the provided tape. The name
+// identifies the endpoint in the recorded cassettes.
+func DialEVMClient(ctx context.Context, tape rpcrecord.Tape, name string, url string) (*ethclient.Client, error) {
+	rpcClient, err := tape.DialEVM(ctx, name, url)
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// StartTendermintRPC creates and starts a Celestia consensus network RPC client going through
+// the provided tape. The name identifies the endpoint in the recorded cassettes.
+func StartTendermintRPC(tape rpcrecord.Tape, name string, remote string) (*tmhttp.HTTP, error) {
+	remote, err := tape.TendermintRemote(name, remote)
+	if err != nil {
+		return nil, err
+	}
+	trpc, err := tmhttp.New(remote, "/websocket")
+	if err != nil {
+		return nil, err
+	}
+	if err := trpc.Start(); err != nil {
+		return nil, err
+	}
+	return trpc, nil
+}
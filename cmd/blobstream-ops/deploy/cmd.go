@@ -0,0 +1,129 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/buildmeta"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/spf13/cobra"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// Command the deploy command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Deploys the BlobstreamX stack to a new chain",
+		Long: "deploys the succinct gateway, registers the header range and next header verifiers, then deploys and " +
+			"initializes a BlobstreamX contract, and writes a deployment manifest that can be used by the replay command",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseFlags()
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			buildInfo := buildmeta.GetBuildInfo()
+			logger.Info("initializing deployment", "version", buildInfo.SemanticVersion, "build_date", buildInfo.BuildTime)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			// the deployment isn't recorded, the tape only dials the endpoints
+			tape, err := rpcrecord.New("", "")
+			if err != nil {
+				return err
+			}
+
+			genesis := replay.Genesis{Height: config.GenesisHeight, Header: config.GenesisHeader}
+			if config.genesisFromSource() {
+				genesis, err = findGenesis(ctx, logger, tape, config)
+				if err != nil {
+					return err
+				}
+			}
+
+			evmClient, err := cmdutil.DialEVMClient(ctx, tape, "evm", config.EVMRPC)
+			if err != nil {
+				return err
+			}
+			defer evmClient.Close()
+
+			txSigner, closeSigner, err := config.Signer.NewSigner(ctx)
+			if err != nil {
+				return err
+			}
+			defer closeSigner()
+
+			manifest, err := deploy.Deploy(ctx, logger, evmClient, deploy.Config{
+				Signer:              txSigner,
+				HeaderRangeVerifier: config.HeaderRangeVerifier,
+				NextHeaderVerifier:  config.NextHeaderVerifier,
+				Guardian:            config.Guardian,
+				Prover:              config.Prover,
+				FeeVault:            config.FeeVault,
+				ProxyBytecode:       config.ProxyBytecode,
+				GenesisHeight:       genesis.Height,
+				GenesisHeader:       genesis.Header,
+			})
+			if err != nil {
+				return err
+			}
+			if err := manifest.Write(config.Output); err != nil {
+				return err
+			}
+			logger.Info("deployment manifest written", "path", config.Output)
+			return nil
+		},
+	}
+
+	cmd.SetHelpCommand(&cobra.Command{})
+
+	return addFlags(cmd)
+}
+
+// findGenesis reads the genesis from the source BlobstreamX contract.
+func findGenesis(ctx context.Context, logger tmlog.Logger, tape rpcrecord.Tape, config Config) (replay.Genesis, error) {
+	sourceEVMClient, err := cmdutil.DialEVMClient(ctx, tape, "source-evm", config.SourceEVMRPC)
+	if err != nil {
+		return replay.Genesis{}, err
+	}
+	defer sourceEVMClient.Close()
+
+	var trpc *tmhttp.HTTP
+	if config.Verify {
+		trpc, err = cmdutil.StartTendermintRPC(tape, "core", config.CoreRPC)
+		if err != nil {
+			return replay.Genesis{}, err
+		}
+		defer func(trpc *tmhttp.HTTP) {
+			if !trpc.IsRunning() {
+				return
+			}
+			err := trpc.Stop()
+			if err != nil {
+				logger.Error("error stopping tendermint RPC", "err", err.Error())
+			}
+		}(trpc)
+	}
+
+	return replay.FindGenesis(ctx, logger, replay.Config{
+		SourceBlobstreamContractAddress: config.SourceContractAddress,
+		FilterRange:                     config.FilterRange,
+	}, sourceEVMClient, trpc, config.Height)
+}
@@ -0,0 +1,361 @@
+package deploy
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// signerFlags the flags of the deployer key or external signer, e.g. evm.private-key.
+var signerFlags = cmdutil.NewSignerFlags("evm")
+
+const (
+	FlagEVMRPC = "evm.rpc"
+
+	FlagSourceEVMRPC             = "evm.source.rpc"
+	FlagSourceEVMContractAddress = "evm.source.contract-address"
+	FlagEVMFilterRange           = "evm.filter-range"
+	FlagHeight                   = "height"
+	FlagVerify                   = "verify"
+	FlagCoreRPC                  = "core.rpc"
+
+	FlagGenesisHeight = "genesis.height"
+	FlagGenesisHeader = "genesis.header"
+
+	FlagHeaderRangeVerifier         = "circuits.header-range.verifier"
+	FlagHeaderRangeVerifierBytecode = "circuits.header-range.verifier-bytecode"
+	FlagNextHeaderVerifier          = "circuits.next-header.verifier"
+	FlagNextHeaderVerifierBytecode  = "circuits.next-header.verifier-bytecode"
+
+	FlagGuardian      = "guardian"
+	FlagProver        = "gateway.prover"
+	FlagFeeVault      = "gateway.fee-vault"
+	FlagProxyBytecode = "proxy-bytecode"
+
+	FlagOutput = "output"
+
+	FlagLogLevel  = "log.level"
+	FlagLogFormat = "log.format"
+)
+
+func addFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagEVMRPC,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the Ethereum rpc address of the EVM chain to deploy to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMRPC)
+
+	signerFlags.Add(cmd, "the deployer, which owns the gateway and the registered functions")
+
+	cmd.Flags().String(
+		FlagSourceEVMRPC,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the Ethereum rpc address of the source EVM chain the genesis is read from. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceEVMRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceEVMRPC)
+
+	cmd.Flags().String(
+		FlagSourceEVMContractAddress,
+		"",
+		fmt.Sprintf("Specify the source BlobstreamX contract the genesis is read from. Not needed if the genesis height and header are set. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceEVMContractAddress)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceEVMContractAddress)
+
+	cmd.Flags().Int64(
+		FlagEVMFilterRange,
+		5000,
+		fmt.Sprintf("Specify the eth_getLogs filter range used to find the source proofs. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMFilterRange)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMFilterRange)
+
+	cmd.Flags().Uint64(
+		FlagHeight,
+		0,
+		fmt.Sprintf("Specify the start block of the source proof the genesis is read from. If not set, the latest source proof is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeight)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagHeight)
+
+	cmd.Flags().Bool(
+		FlagVerify,
+		false,
+		fmt.Sprintf("Set to confirm the source genesis header hash against the core rpc. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagVerify)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagVerify)
+
+	cmd.Flags().String(
+		FlagCoreRPC,
+		"tcp://localhost:26657",
+		fmt.Sprintf("The celestia app rpc address. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagCoreRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagCoreRPC)
+
+	cmd.Flags().Uint64(
+		FlagGenesisHeight,
+		0,
+		fmt.Sprintf("Specify the genesis height of the BlobstreamX contract, instead of reading it from the source contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGenesisHeight)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGenesisHeight)
+
+	cmd.Flags().String(
+		FlagGenesisHeader,
+		"",
+		fmt.Sprintf("Specify the genesis header hash, in hex format, of the BlobstreamX contract, instead of reading it from the source contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGenesisHeader)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGenesisHeader)
+
+	cmd.Flags().String(
+		FlagHeaderRangeVerifier,
+		"",
+		fmt.Sprintf("Specify the address of an already deployed header range circuit verifier. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeaderRangeVerifier)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagHeaderRangeVerifier)
+
+	cmd.Flags().String(
+		FlagHeaderRangeVerifierBytecode,
+		"",
+		fmt.Sprintf("Specify a file containing the hex encoded creation bytecode of the header range circuit verifier, deployed by the gateway. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeaderRangeVerifierBytecode)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagHeaderRangeVerifierBytecode)
+
+	cmd.Flags().String(
+		FlagNextHeaderVerifier,
+		"",
+		fmt.Sprintf("Specify the address of an already deployed next header circuit verifier. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNextHeaderVerifier)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNextHeaderVerifier)
+
+	cmd.Flags().String(
+		FlagNextHeaderVerifierBytecode,
+		"",
+		fmt.Sprintf("Specify a file containing the hex encoded creation bytecode of the next header circuit verifier, deployed by the gateway. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNextHeaderVerifierBytecode)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNextHeaderVerifierBytecode)
+
+	cmd.Flags().String(
+		FlagGuardian,
+		"",
+		fmt.Sprintf("Specify the guardian of the BlobstreamX contract. Defaults to the deployer address. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGuardian)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGuardian)
+
+	cmd.Flags().String(
+		FlagProver,
+		"",
+		fmt.Sprintf("Specify the gateway default prover, i.e. the account allowed to submit the proofs, e.g. the replay signer. Defaults to the deployer address. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProver)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProver)
+
+	cmd.Flags().String(
+		FlagFeeVault,
+		"",
+		fmt.Sprintf("Specify the gateway fee vault address. If not set, the gateway has no fee vault. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagFeeVault)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagFeeVault)
+
+	cmd.Flags().String(
+		FlagProxyBytecode,
+		"",
+		fmt.Sprintf("Specify a file containing the hex encoded creation bytecode of the ERC1967 proxy the contracts are deployed behind, e.g. the OpenZeppelin ERC1967Proxy. If not set, a minimal ERC1967 proxy is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyBytecode)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyBytecode)
+
+	cmd.Flags().String(
+		FlagOutput,
+		"deployment.json",
+		fmt.Sprintf("Specify the file the deployment manifest is written to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagOutput)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+type Config struct {
+	EVMRPC                string
+	Signer                cmdutil.SignerConfig
+	SourceEVMRPC          string
+	SourceContractAddress string
+	FilterRange           int64
+	Height                uint64
+	Verify                bool
+	CoreRPC               string
+	GenesisHeight         uint64
+	GenesisHeader         [32]byte
+	HeaderRangeVerifier   deploy.Verifier
+	NextHeaderVerifier    deploy.Verifier
+	Guardian              ethcmn.Address
+	Prover                ethcmn.Address
+	FeeVault              ethcmn.Address
+	ProxyBytecode         []byte
+	Output                string
+	LogLevel              string
+	LogFormat             string
+}
+
+// genesisFromSource returns true if the genesis should be read from the source contract.
+func (cfg Config) genesisFromSource() bool {
+	return cfg.GenesisHeight == 0 && cfg.GenesisHeader == [32]byte{}
+}
+
+func (cfg Config) ValidateBasics() error {
+	if cfg.genesisFromSource() {
+		if !ethcmn.IsHexAddress(cfg.SourceContractAddress) {
+			return fmt.Errorf("please set either the genesis --%s and --%s, or a valid source contract address --%s", FlagGenesisHeight, FlagGenesisHeader, FlagSourceEVMContractAddress)
+		}
+	} else if cfg.GenesisHeight == 0 || cfg.GenesisHeader == [32]byte{} {
+		return fmt.Errorf("flags --%s and --%s should be set together", FlagGenesisHeight, FlagGenesisHeader)
+	}
+	if cfg.Verify && cfg.CoreRPC == "" {
+		return fmt.Errorf("flag --%s is set but the core RPC flag --%s is not set", FlagVerify, FlagCoreRPC)
+	}
+	if cfg.Output == "" {
+		return fmt.Errorf("please set the deployment manifest output --%s", FlagOutput)
+	}
+	return nil
+}
+
+func parseFlags() (Config, error) {
+	signerConfig, err := signerFlags.Parse(false)
+	if err != nil {
+		return Config{}, err
+	}
+	deployer := signerConfig.Address()
+
+	var genesisHeader [32]byte
+	if rawGenesisHeader := viper.GetString(FlagGenesisHeader); rawGenesisHeader != "" {
+		bz, err := hex.DecodeString(strings.TrimPrefix(rawGenesisHeader, "0x"))
+		if err != nil || len(bz) != 32 {
+			return Config{}, fmt.Errorf("invalid genesis header hash %q, expected 32 hex encoded bytes: flag --%s", rawGenesisHeader, FlagGenesisHeader)
+		}
+		copy(genesisHeader[:], bz)
+	}
+
+	headerRangeVerifier, err := parseVerifier(FlagHeaderRangeVerifier, FlagHeaderRangeVerifierBytecode)
+	if err != nil {
+		return Config{}, err
+	}
+
+	nextHeaderVerifier, err := parseVerifier(FlagNextHeaderVerifier, FlagNextHeaderVerifierBytecode)
+	if err != nil {
+		return Config{}, err
+	}
+
+	guardian, err := parseAddress(FlagGuardian, deployer)
+	if err != nil {
+		return Config{}, err
+	}
+
+	prover, err := parseAddress(FlagProver, deployer)
+	if err != nil {
+		return Config{}, err
+	}
+
+	feeVault, err := parseAddress(FlagFeeVault, ethcmn.Address{})
+	if err != nil {
+		return Config{}, err
+	}
+
+	var proxyBytecode []byte
+	if path := viper.GetString(FlagProxyBytecode); path != "" {
+		proxyBytecode, err = readBytecode(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagProxyBytecode)
+		}
+	}
+
+	return Config{
+		EVMRPC:                viper.GetString(FlagEVMRPC),
+		Signer:                signerConfig,
+		SourceEVMRPC:          viper.GetString(FlagSourceEVMRPC),
+		SourceContractAddress: viper.GetString(FlagSourceEVMContractAddress),
+		FilterRange:           viper.GetInt64(FlagEVMFilterRange),
+		Height:                viper.GetUint64(FlagHeight),
+		Verify:                viper.GetBool(FlagVerify),
+		CoreRPC:               viper.GetString(FlagCoreRPC),
+		GenesisHeight:         viper.GetUint64(FlagGenesisHeight),
+		GenesisHeader:         genesisHeader,
+		HeaderRangeVerifier:   headerRangeVerifier,
+		NextHeaderVerifier:    nextHeaderVerifier,
+		Guardian:              guardian,
+		Prover:                prover,
+		FeeVault:              feeVault,
+		ProxyBytecode:         proxyBytecode,
+		Output:                viper.GetString(FlagOutput),
+		LogLevel:              viper.GetString(FlagLogLevel),
+		LogFormat:             viper.GetString(FlagLogFormat),
+	}, nil
+}
+
+// parseVerifier parses a circuit verifier set either using its address or its bytecode flag.
+func parseVerifier(addressFlag string, bytecodeFlag string) (deploy.Verifier, error) {
+	rawAddress, path := viper.GetString(addressFlag), viper.GetString(bytecodeFlag)
+	switch {
+	case rawAddress != "" && path != "":
+		return deploy.Verifier{}, fmt.Errorf("flags --%s and --%s cannot be set at the same time", addressFlag, bytecodeFlag)
+	case rawAddress != "":
+		if !ethcmn.IsHexAddress(rawAddress) {
+			return deploy.Verifier{}, fmt.Errorf("valid EVM address is required: flag --%s", addressFlag)
+		}
+		return deploy.Verifier{Address: ethcmn.HexToAddress(rawAddress)}, nil
+	case path != "":
+		bytecode, err := readBytecode(path)
+		if err != nil {
+			return deploy.Verifier{}, fmt.Errorf("%s: flag --%s", err.Error(), bytecodeFlag)
+		}
+		return deploy.Verifier{Bytecode: bytecode}, nil
+	default:
+		return deploy.Verifier{}, fmt.Errorf("please set the verifier using --%s or --%s", addressFlag, bytecodeFlag)
+	}
+}
+
+// parseAddress parses the address set using the provided flag, or returns the default one if it's not set.
+func parseAddress(flag string, defaultAddress ethcmn.Address) (ethcmn.Address, error) {
+	rawAddress := viper.GetString(flag)
+	if rawAddress == "" {
+		return defaultAddress, nil
+	}
+	if !ethcmn.IsHexAddress(rawAddress) {
+		return ethcmn.Address{}, fmt.Errorf("valid EVM address is required: flag --%s", flag)
+	}
+	return ethcmn.HexToAddress(rawAddress), nil
+}
+
+// readBytecode reads a hex encoded bytecode file.
+func readBytecode(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bytecode, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(raw)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytecode in %s: %w", path, err)
+	}
+	if len(bytecode) == 0 {
+		return nil, errors.New("empty bytecode in " + path)
+	}
+	return bytecode, nil
+}
@@ -0,0 +1,122 @@
+package devnet
+
+import (
+	"context"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/buildmeta"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/rpcproxy"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/spf13/cobra"
+)
+
+// Command the devnet command
+func Command() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "devnet",
+		Short:        "Tooling for testing Blobstream deployments",
+		Long:         "tooling for testing the Blobstream operations against local or faulty networks",
+		SilenceUsage: true,
+	}
+
+	cmd.AddCommand(
+		ProxyCommand(),
+		SignerCommand(),
+	)
+
+	cmd.SetHelpCommand(&cobra.Command{})
+
+	return cmd
+}
+
+// ProxyCommand the fault injecting RPC proxy command.
+func ProxyCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "proxy <flags>",
+		Short: "Starts a fault injecting JSON-RPC proxy in front of an EVM endpoint",
+		Long: "starts a JSON-RPC proxy in front of an EVM endpoint that injects the configured errors, delays, " +
+			"dropped subscriptions, stale reads, reordered and truncated logs. Used for chaos testing the replay and verify commands",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseProxyFlags()
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			buildInfo := buildmeta.GetBuildInfo()
+			logger.Info("initializing RPC proxy", "version", buildInfo.SemanticVersion, "build_date", buildInfo.BuildTime)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			logger.Info(
+				"starting RPC proxy",
+				"proxy.listen",
+				config.ListenAddress,
+				"proxy.upstream",
+				config.Upstream,
+				"proxy.upstream-ws",
+				config.UpstreamWS,
+				"rules",
+				len(config.Faults.Rules),
+			)
+
+			proxy := rpcproxy.New(logger, config.Upstream, config.UpstreamWS, config.Faults, config.Seed)
+			return cmdutil.ServeHTTP(ctx, logger, config.ListenAddress, proxy)
+		},
+	}
+	return addProxyFlags(command)
+}
+
+// SignerCommand the local external signer command.
+func SignerCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "signer <flags>",
+		Short: "Starts a local external signer compatible with clef's account_signTransaction",
+		Long: "starts a JSON-RPC server signing the transactions with the provided private key using clef's account_list " +
+			"and account_signTransaction methods. Used as a local stand-in for clef to test the remote signer of the replay command",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseSignerFlags()
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			buildInfo := buildmeta.GetBuildInfo()
+			logger.Info("initializing local signer", "version", buildInfo.SemanticVersion, "build_date", buildInfo.BuildTime)
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			server, err := signer.NewServer(config.Key)
+			if err != nil {
+				return err
+			}
+			defer server.Stop()
+
+			logger.Info("starting local signer", "signer.listen", config.ListenAddress, "address", config.Key.Address().Hex())
+			return cmdutil.ServeHTTP(ctx, logger, config.ListenAddress, server)
+		},
+	}
+	return addSignerFlags(command)
+}
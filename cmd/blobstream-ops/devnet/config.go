@@ -0,0 +1,204 @@
+package devnet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/rpcproxy"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	FlagProxyListen     = "proxy.listen"
+	FlagProxyUpstream   = "proxy.upstream"
+	FlagProxyUpstreamWS = "proxy.upstream-ws"
+	FlagProxyFaults     = "proxy.faults"
+	FlagProxyPresets    = "proxy.presets"
+	FlagProxySeed       = "proxy.seed"
+
+	FlagSignerListen     = "signer.listen"
+	FlagSignerPrivateKey = "signer.private-key"
+
+	FlagLogLevel  = "log.level"
+	FlagLogFormat = "log.format"
+)
+
+func addProxyFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagProxyListen,
+		"localhost:8546",
+		fmt.Sprintf("Specify the address the proxy listens on. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyListen)
+
+	cmd.Flags().String(
+		FlagProxyUpstream,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the HTTP rpc address of the EVM endpoint behind the proxy. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyUpstream)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyUpstream)
+
+	cmd.Flags().String(
+		FlagProxyUpstreamWS,
+		"",
+		fmt.Sprintf("Specify the websocket rpc address of the EVM endpoint behind the proxy. If not set, websocket connections are refused. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyUpstreamWS)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyUpstreamWS)
+
+	cmd.Flags().String(
+		FlagProxyFaults,
+		"",
+		fmt.Sprintf("Specify the path to a JSON file containing the faults rules to inject. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyFaults)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyFaults)
+
+	cmd.Flags().StringSlice(
+		FlagProxyPresets,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of faults presets to inject (nonce-too-low|replacement-underpriced|stale-reads|dropped-subscriptions|flaky-logs|slow|unavailable). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxyPresets)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxyPresets)
+
+	cmd.Flags().Int64(
+		FlagProxySeed,
+		1,
+		fmt.Sprintf("Specify the seed used to decide which requests are faulted. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProxySeed)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProxySeed)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+type ProxyConfig struct {
+	ListenAddress string
+	Upstream      string
+	UpstreamWS    string
+	Faults        rpcproxy.Faults
+	Seed          int64
+	LogLevel      string
+	LogFormat     string
+}
+
+func (cfg ProxyConfig) ValidateBasics() error {
+	if cfg.ListenAddress == "" {
+		return fmt.Errorf("please set the listen address --%s or %s", FlagProxyListen, cmdutil.ToEnvVariableFormat(FlagProxyListen))
+	}
+	if cfg.Upstream == "" {
+		return fmt.Errorf("please set the upstream --%s or %s", FlagProxyUpstream, cmdutil.ToEnvVariableFormat(FlagProxyUpstream))
+	}
+	if len(cfg.Faults.Rules) == 0 {
+		return errors.New("no faults to inject. Please set a faults file --" + FlagProxyFaults + " or a set of presets --" + FlagProxyPresets)
+	}
+	return cfg.Faults.ValidateBasics()
+}
+
+func parseProxyFlags() (ProxyConfig, error) {
+	var faults rpcproxy.Faults
+	if path := viper.GetString(FlagProxyFaults); path != "" {
+		var err error
+		faults, err = rpcproxy.LoadFaults(path)
+		if err != nil {
+			return ProxyConfig{}, err
+		}
+	}
+	presetRules, err := rpcproxy.PresetRules(viper.GetStringSlice(FlagProxyPresets)...)
+	if err != nil {
+		return ProxyConfig{}, err
+	}
+	faults.Rules = append(faults.Rules, presetRules...)
+
+	return ProxyConfig{
+		ListenAddress: viper.GetString(FlagProxyListen),
+		Upstream:      viper.GetString(FlagProxyUpstream),
+		UpstreamWS:    viper.GetString(FlagProxyUpstreamWS),
+		Faults:        faults,
+		Seed:          viper.GetInt64(FlagProxySeed),
+		LogLevel:      viper.GetString(FlagLogLevel),
+		LogFormat:     viper.GetString(FlagLogFormat),
+	}, nil
+}
+
+func addSignerFlags(cmd *cobra.Command) *cobra.Command {
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagSignerListen,
+		"localhost:8550",
+		fmt.Sprintf("Specify the address the signer listens on. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSignerListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSignerListen)
+
+	cmd.Flags().String(
+		FlagSignerPrivateKey,
+		"",
+		fmt.Sprintf("Specify the EVM private key, in hex format, signing the transactions. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSignerPrivateKey)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSignerPrivateKey)
+
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+
+	return cmd
+}
+
+type SignerConfig struct {
+	ListenAddress string
+	Key           *signer.Key
+	LogLevel      string
+	LogFormat     string
+}
+
+func (cfg SignerConfig) ValidateBasics() error {
+	if cfg.ListenAddress == "" {
+		return fmt.Errorf("please set the listen address --%s or %s", FlagSignerListen, cmdutil.ToEnvVariableFormat(FlagSignerListen))
+	}
+	return nil
+}
+
+func parseSignerFlags() (SignerConfig, error) {
+	rawPrivateKey := viper.GetString(FlagSignerPrivateKey)
+	if rawPrivateKey == "" {
+		return SignerConfig{}, fmt.Errorf("please set the private key --%s or %s", FlagSignerPrivateKey, cmdutil.ToEnvVariableFormat(FlagSignerPrivateKey))
+	}
+	key, err := signer.ParseKey(rawPrivateKey)
+	if err != nil {
+		return SignerConfig{}, err
+	}
+
+	return SignerConfig{
+		ListenAddress: viper.GetString(FlagSignerListen),
+		Key:           key,
+		LogLevel:      viper.GetString(FlagLogLevel),
+		LogFormat:     viper.GetString(FlagLogFormat),
+	}, nil
+}
@@ -0,0 +1,183 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+)
+
+const (
+	FlagExportFromHeight = "from-height"
+	FlagArchiveOutput    = "archive.output"
+)
+
+// ExportCommand the replay export command. It writes the source proofs to an archive that can be replayed
+// without connecting to the source chain.
+func ExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "export",
+		Short:        "Exports the proofs of a source BlobstreamX contract to an archive",
+		Long:         "decodes the proofs of the source BlobstreamX contract and writes them, along with their events and source transactions metadata, to a versioned and content-hashed archive, which can be replayed using the import command without connecting to the source chain",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			sourceContractAddress := viper.GetString(FlagSourceEVMContractAddress)
+			if err := ValidateEVMAddress(sourceContractAddress); err != nil {
+				return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagSourceEVMContractAddress, cmdutil.ToEnvVariableFormat(FlagSourceEVMContractAddress))
+			}
+			sourceChainGateway := viper.GetString(FlagSourceChainGateway)
+			if sourceChainGateway != "" {
+				if err := ValidateEVMAddress(sourceChainGateway); err != nil {
+					return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagSourceChainGateway, cmdutil.ToEnvVariableFormat(FlagSourceChainGateway))
+				}
+			}
+			filterRange := viper.GetInt64(FlagEVMFilterRange)
+			if filterRange <= 0 {
+				return fmt.Errorf("the filter range should be positive: flag --%s", FlagEVMFilterRange)
+			}
+			output := viper.GetString(FlagArchiveOutput)
+			if output == "" {
+				return fmt.Errorf("please set the archive output file --%s", FlagArchiveOutput)
+			}
+
+			logger, err := cmdutil.GetLogger(viper.GetString(FlagLogLevel), viper.GetString(FlagLogFormat))
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			tape, err := rpcrecord.New("", "")
+			if err != nil {
+				return err
+			}
+
+			sourceEVMClient, err := cmdutil.DialEVMClient(ctx, tape, "source-evm", viper.GetString(FlagSourceEVMRPC))
+			if err != nil {
+				return err
+			}
+			defer sourceEVMClient.Close()
+
+			if sourceChainGateway == "" {
+				sourceBlobstreamReader, err := blobstreamxwrapper.NewBlobstreamXCaller(ethcmn.HexToAddress(sourceContractAddress), sourceEVMClient)
+				if err != nil {
+					return err
+				}
+				gateway, err := sourceBlobstreamReader.Gateway(&bind.CallOpts{Context: ctx})
+				if err != nil {
+					return err
+				}
+				sourceChainGateway = gateway.Hex()
+				logger.Info("found source chain gateway", "address", sourceChainGateway)
+			}
+
+			archive, err := replay.ExportArchive(
+				ctx,
+				logger,
+				replay.Config{
+					SourceBlobstreamContractAddress: sourceContractAddress,
+					SourceChainGatewayAddress:       sourceChainGateway,
+					FilterRange:                     filterRange,
+				},
+				sourceEVMClient,
+				viper.GetUint64(FlagExportFromHeight),
+			)
+			if err != nil {
+				return err
+			}
+			if err := archive.Write(output); err != nil {
+				return err
+			}
+			logger.Info("proofs exported", "path", output, "proofs", len(archive.Proofs), "latest_block", archive.LatestBlock(), "hash", archive.Hash.Hex())
+			return nil
+		},
+	}
+
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagSourceEVMRPC,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the Ethereum rpc address of the source EVM chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceEVMRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceEVMRPC)
+
+	cmd.Flags().String(
+		FlagSourceEVMContractAddress,
+		"",
+		fmt.Sprintf("Specify the source contract at which the source BlobstreamX contract is deployed. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceEVMContractAddress)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceEVMContractAddress)
+
+	cmd.Flags().String(
+		FlagSourceChainGateway,
+		"",
+		fmt.Sprintf("Specify the source chain succinct gateway contract address the proofs are submitted to. If not set, it's read from the source BlobstreamX contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceChainGateway)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceChainGateway)
+
+	cmd.Flags().Int64(
+		FlagEVMFilterRange,
+		5000,
+		fmt.Sprintf("Specify the eth_getLogs filter range. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMFilterRange)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMFilterRange)
+
+	cmd.Flags().Uint64(
+		FlagExportFromHeight,
+		0,
+		fmt.Sprintf("Specify the start block of the first proof to export. If not set, all the source proofs are exported. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagExportFromHeight)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagExportFromHeight)
+
+	cmd.Flags().String(
+		FlagArchiveOutput,
+		"proofs-archive.json",
+		fmt.Sprintf("Specify the file the archive is written to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagArchiveOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagArchiveOutput)
+
+	addLogFlags(cmd)
+
+	return cmd
+}
+
+// ImportCommand the replay import command. It replays the proofs of an archive written by the export
+// command to the target contract, without connecting to the source chain.
+func ImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "import <archive>",
+		Short:        "Replays the proofs of an archive to a target BlobstreamX contract",
+		Long:         "replays the proofs of an archive written by the export command to the target BlobstreamX contract, without connecting to the source chain. It's equivalent to running the replay with --" + FlagSource + " " + ArchiveSourceScheme + "<archive>",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := parseFlags(false)
+			if err != nil {
+				return err
+			}
+			if config.Archive != nil {
+				return fmt.Errorf("the archive is already set using --%s", FlagSource)
+			}
+			archive, err := replay.LoadArchive(args[0])
+			if err != nil {
+				return err
+			}
+			config.useArchive(archive)
+			return run(cmd, config)
+		},
+	}
+
+	return addFlags(cmd)
+}
@@ -0,0 +1,317 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+const (
+	FlagBundle          = "bundle"
+	FlagBundleOutput    = "output"
+	FlagPrepareFrom     = "from"
+	FlagPrepareGasPrice = "gas-price"
+)
+
+// PrepareCommand the replay prepare command. It writes the unsigned proof submission transactions needed for
+// the target contract to catch up to a bundle, to be signed offline.
+func PrepareCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "prepare",
+		Short:        "Prepares the unsigned catchup transactions of a BlobstreamX deployment",
+		Long:         "plans and decodes the proofs needed for the target BlobstreamX contract to catch up with the source one, and writes their unsigned submission transactions to a bundle to be signed offline by the sign command, then sent by the broadcast command",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseFlags(true)
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+
+			from := config.Signer.Address()
+			if rawFrom := viper.GetString(FlagPrepareFrom); rawFrom != "" {
+				if err := ValidateEVMAddress(rawFrom); err != nil {
+					return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagPrepareFrom, cmdutil.ToEnvVariableFormat(FlagPrepareFrom))
+				}
+				from = ethcmn.HexToAddress(rawFrom)
+			}
+			if from == (ethcmn.Address{}) {
+				return fmt.Errorf("please set the address of the offline signer --%s or environment variable %s", FlagPrepareFrom, cmdutil.ToEnvVariableFormat(FlagPrepareFrom))
+			}
+
+			var gasPrice *big.Int
+			if rawGasPrice := viper.GetString(FlagPrepareGasPrice); rawGasPrice != "" {
+				var ok bool
+				gasPrice, ok = new(big.Int).SetString(rawGasPrice, 10)
+				if !ok || gasPrice.Sign() <= 0 {
+					return fmt.Errorf("invalid gas price %q: flag --%s", rawGasPrice, FlagPrepareGasPrice)
+				}
+			}
+
+			output := viper.GetString(FlagBundleOutput)
+			if output == "" {
+				return fmt.Errorf("please set the bundle output file --%s", FlagBundleOutput)
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			tape, err := rpcrecord.New(config.RecordRPC, config.PlaybackRPC)
+			if err != nil {
+				return err
+			}
+			defer func(tape rpcrecord.Tape) {
+				err := tape.Close()
+				if err != nil {
+					logger.Error("error closing the RPC tape", "err", err.Error())
+				}
+			}(tape)
+
+			contracts, err := dialContracts(ctx, tape, config)
+			if err != nil {
+				return err
+			}
+			defer contracts.Close()
+
+			replayConfig, err := contracts.replayConfig(ctx, logger, config)
+			if err != nil {
+				return err
+			}
+
+			var trpc *tmhttp.HTTP
+			if config.Verify {
+				trpc, err = cmdutil.StartTendermintRPC(tape, "core", config.CoreRPC)
+				if err != nil {
+					return err
+				}
+				defer func(trpc *tmhttp.HTTP) {
+					if !trpc.IsRunning() {
+						return
+					}
+					err := trpc.Stop()
+					if err != nil {
+						logger.Error("error stopping tendermint RPC", "err", err.Error())
+					}
+				}(trpc)
+			}
+
+			notifier, err := notify.New(logger, config.Webhooks, config.NotifyCooldown)
+			if err != nil {
+				return err
+			}
+
+			replayer, err := replay.NewReplayer(
+				logger,
+				replayConfig,
+				trpc,
+				contracts.sourceEVMClient,
+				contracts.targetEVMClient,
+				metrics.NewReplay(metrics.NewRegistry()),
+				notifier,
+				eventstream.New(logger),
+				nil,
+			)
+			if err != nil {
+				return err
+			}
+
+			bundle, err := replayer.Prepare(ctx, replay.PrepareConfig{From: from, GasPrice: gasPrice})
+			if err != nil {
+				return err
+			}
+			if err := bundle.Write(output); err != nil {
+				return err
+			}
+			logger.Info("bundle prepared", "path", output, "transactions", len(bundle.Transactions), "from", from.Hex(), "chain_id", bundle.ChainID.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().String(
+		FlagPrepareFrom,
+		"",
+		fmt.Sprintf("Specify the address of the offline signer the transactions are prepared for. Defaults to the EVM signer address, if set. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPrepareFrom)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPrepareFrom)
+
+	cmd.Flags().String(
+		FlagPrepareGasPrice,
+		"",
+		fmt.Sprintf("Specify the gas price of the transactions, in wei. If not set, the gas price suggested by the target chain is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPrepareGasPrice)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPrepareGasPrice)
+
+	cmd.Flags().String(
+		FlagBundleOutput,
+		"replay-bundle.json",
+		fmt.Sprintf("Specify the file the bundle is written to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagBundleOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagBundleOutput)
+
+	return addFlags(cmd)
+}
+
+// SignCommand the replay sign command. It signs a prepared bundle without any connection to the target chain.
+func SignCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "sign",
+		Short:        "Signs a prepared bundle offline",
+		Long:         "signs the transactions of a bundle written by the prepare command using the EVM key, without connecting to any chain",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path := viper.GetString(FlagBundle)
+			if path == "" {
+				return fmt.Errorf("please set the bundle file --%s or environment variable %s", FlagBundle, cmdutil.ToEnvVariableFormat(FlagBundle))
+			}
+			output := viper.GetString(FlagBundleOutput)
+			if output == "" {
+				output = path
+			}
+
+			signerConfig, err := evmSignerFlags.Parse(false)
+			if err != nil {
+				return err
+			}
+
+			logger, err := cmdutil.GetLogger(viper.GetString(FlagLogLevel), viper.GetString(FlagLogFormat))
+			if err != nil {
+				return err
+			}
+
+			bundle, err := replay.LoadBundle(path)
+			if err != nil {
+				return err
+			}
+
+			txSigner, closeSigner, err := signerConfig.NewSigner(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeSigner()
+
+			if err := bundle.Sign(cmd.Context(), txSigner); err != nil {
+				return err
+			}
+			if err := bundle.Write(output); err != nil {
+				return err
+			}
+			logger.Info("bundle signed", "path", output, "transactions", len(bundle.Transactions), "from", bundle.From.Hex())
+			return nil
+		},
+	}
+
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagBundle,
+		"replay-bundle.json",
+		fmt.Sprintf("Specify the bundle file written by the prepare command. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagBundle)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagBundle)
+
+	cmd.Flags().String(
+		FlagBundleOutput,
+		"",
+		fmt.Sprintf("Specify the file the signed bundle is written to. Defaults to the bundle file. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagBundleOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagBundleOutput)
+
+	addSignerFlags(cmd)
+	addLogFlags(cmd)
+
+	return cmd
+}
+
+// BroadcastCommand the replay broadcast command. It sends the transactions of a signed bundle in order.
+func BroadcastCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "broadcast",
+		Short:        "Broadcasts a signed bundle to the target chain",
+		Long:         "sends the transactions of a bundle signed by the sign command to the target chain in order, waiting for each one to be included. An interrupted broadcast can be resumed by running it again",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path := viper.GetString(FlagBundle)
+			if path == "" {
+				return fmt.Errorf("please set the bundle file --%s or environment variable %s", FlagBundle, cmdutil.ToEnvVariableFormat(FlagBundle))
+			}
+
+			logger, err := cmdutil.GetLogger(viper.GetString(FlagLogLevel), viper.GetString(FlagLogFormat))
+			if err != nil {
+				return err
+			}
+
+			bundle, err := replay.LoadBundle(path)
+			if err != nil {
+				return err
+			}
+			if !bundle.Signed() {
+				return fmt.Errorf("the bundle %s isn't signed, please sign it using the sign command", path)
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			tape, err := rpcrecord.New("", "")
+			if err != nil {
+				return err
+			}
+
+			targetEVMClient, err := cmdutil.DialEVMClient(ctx, tape, "target-evm", viper.GetString(FlagTargetEVMRPC))
+			if err != nil {
+				return err
+			}
+			defer targetEVMClient.Close()
+
+			logger.Info("broadcasting the bundle", "path", path, "transactions", len(bundle.Transactions), "from", bundle.From.Hex())
+			if err := replay.BroadcastBundle(ctx, logger, targetEVMClient, bundle); err != nil {
+				return err
+			}
+			logger.Info("bundle broadcast", "transactions", len(bundle.Transactions))
+			return nil
+		},
+	}
+
+	viper.AutomaticEnv()
+
+	cmd.Flags().String(
+		FlagBundle,
+		"replay-bundle.json",
+		fmt.Sprintf("Specify the signed bundle file. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagBundle)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagBundle)
+
+	cmd.Flags().String(
+		FlagTargetEVMRPC,
+		"http://localhost:8545",
+		fmt.Sprintf("Specify the Ethereum rpc address of the target EVM chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTargetEVMRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTargetEVMRPC)
+
+	addLogFlags(cmd)
+
+	return cmd
+}
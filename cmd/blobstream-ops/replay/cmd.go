@@ -2,16 +2,24 @@ package replay
 
 import (
 	"context"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/celestiaorg/blobstream-ops/admin"
+	"github.com/celestiaorg/blobstream-ops/auditlog"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/buildmeta"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
 	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/tracing"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	ethcmn "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
-	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
-	"github.com/tendermint/tendermint/rpc/client/http"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
 )
 
 // Command the replay command
@@ -22,144 +30,282 @@ func Command() *cobra.Command {
 		Long:         "verifies that a BlobstreamX contract is committing to valid data",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			config, err := parseFlags()
+			config, err := parseFlags(false)
 			if err != nil {
 				return err
 			}
-			if err := config.ValidateBasics(); err != nil {
-				return err
-			}
+			return run(cmd, config)
+		},
+	}
 
-			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
-			if err != nil {
-				return err
-			}
+	cmd.AddCommand(
+		PreflightCommand(),
+		InitCommand(),
+		PrepareCommand(),
+		SignCommand(),
+		BroadcastCommand(),
+		ExportCommand(),
+		ImportCommand(),
+	)
 
-			buildInfo := buildmeta.GetBuildInfo()
-			logger.Info("initializing replay service", "version", buildInfo.SemanticVersion, "build_date", buildInfo.BuildTime)
+	cmd.SetHelpCommand(&cobra.Command{})
 
-			ctx, cancel := context.WithCancel(cmd.Context())
-			defer cancel()
+	return addFlags(cmd)
+}
 
-			// Listen for and trap any OS signal to graceful shutdown and exit
-			go cmdutil.TrapSignal(logger, cancel)
+// run runs the replay service: it catches up with the source proofs, then follows the new ones. When
+// replaying from a proofs archive, it returns once the archived proofs are replayed.
+func run(cmd *cobra.Command, config Config) error {
+	if err := config.ValidateBasics(); err != nil {
+		return err
+	}
 
-			// connecting to the source BlobstreamX contract
-			sourceEVMClient, err := ethclient.Dial(config.SourceEVMRPC)
-			if err != nil {
-				return err
-			}
-			defer sourceEVMClient.Close()
+	logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+	if err != nil {
+		return err
+	}
+
+	buildInfo := buildmeta.GetBuildInfo()
+	logger.Info("initializing replay service", "version", buildInfo.SemanticVersion, "build_date", buildInfo.BuildTime)
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
 
-			sourceBlobstreamReader, err := blobstreamxwrapper.NewBlobstreamXCaller(
-				ethcmn.HexToAddress(config.SourceContractAddress),
-				sourceEVMClient,
-			)
+	shutdownTracing, err := tracing.Setup(ctx, "blobstream-ops-replay", buildInfo.SemanticVersion, config.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := shutdownTracing(shutdownCtx)
+		if err != nil {
+			logger.Error("error shutting down tracing", "err", err.Error())
+		}
+	}()
+
+	// Listen for and trap any OS signal to graceful shutdown and exit
+	go cmdutil.TrapSignal(logger, cancel)
+
+	tape, err := rpcrecord.New(config.RecordRPC, config.PlaybackRPC)
+	if err != nil {
+		return err
+	}
+	defer func(tape rpcrecord.Tape) {
+		err := tape.Close()
+		if err != nil {
+			logger.Error("error closing the RPC tape", "err", err.Error())
+		}
+	}(tape)
+
+	contracts, err := dialContracts(ctx, tape, config)
+	if err != nil {
+		return err
+	}
+	defer contracts.Close()
+
+	logger.Info(
+		"starting replay service",
+		"evm.source.rpc",
+		config.SourceEVMRPC,
+		"evm.source.contract-address",
+		config.SourceContractAddress,
+		"evm.target.rpc",
+		config.TargetEVMRPC,
+		"evm.target.contract-address",
+		config.TargetContractAddress,
+		"core.rpc",
+		config.CoreRPC,
+	)
+
+	if config.Archive != nil {
+		logger.Info("replaying from proofs archive", "proofs", len(config.Archive.Proofs), "latest_block", config.Archive.LatestBlock(), "hash", config.Archive.Hash.Hex())
+	} else {
+		latestSourceBlock, err := contracts.sourceBlobstreamReader.LatestBlock(&bind.CallOpts{})
+		if err != nil {
+			return err
+		}
+		logger.Info("found source blobstreamX contract", "latest_block", latestSourceBlock)
+	}
+
+	latestTargetBlock, err := contracts.targetBlobstreamReader.LatestBlock(&bind.CallOpts{})
+	if err != nil {
+		return err
+	}
+	logger.Info("found target blobstreamX contract", "latest_block", latestTargetBlock)
+
+	replayConfig, err := contracts.replayConfig(ctx, logger, config)
+	if err != nil {
+		return err
+	}
+
+	// the preflight checks read the source chain
+	if config.Archive == nil {
+		if err := runPreflight(ctx, logger, replayConfig, config.Preflight, contracts); err != nil {
+			return err
+		}
+	}
+
+	var trpc *tmhttp.HTTP
+	if config.Verify {
+		trpc, err = cmdutil.StartTendermintRPC(tape, "core", config.CoreRPC)
+		if err != nil {
+			return err
+		}
+		defer func(trpc *tmhttp.HTTP) {
+			if !trpc.IsRunning() {
+				return
+			}
+			err := trpc.Stop()
 			if err != nil {
-				return err
+				logger.Error("error stopping tendermint RPC", "err", err.Error())
 			}
+		}(trpc)
+	}
 
-			// connecting to the target BlobstreamX contract
-			targetEVMClient, err := ethclient.Dial(config.TargetEVMRPC)
+	if config.GuardianSigner.IsSet() {
+		guardian, stopGuardian, err := newGuardian(ctx, logger, tape, config)
+		if err != nil {
+			return err
+		}
+		defer stopGuardian()
+		replayConfig.Guardian = guardian
+		logger.Info("guardian enabled", "address", guardian.Address().Hex(), "trusted_core_endpoints", len(config.GuardianCoreRPCs))
+	}
+
+	registry := metrics.NewRegistry()
+	replayMetrics := metrics.NewReplay(registry)
+	if config.MetricsListen != "" {
+		go func() {
+			err := cmdutil.ServeHTTP(ctx, logger, config.MetricsListen, metrics.Handler(registry))
 			if err != nil {
-				return err
+				logger.Error("metrics server stopped", "err", err.Error())
+				cancel()
 			}
-			defer targetEVMClient.Close()
+		}()
+	}
+
+	notifier, err := notify.New(logger, config.Webhooks, config.NotifyCooldown)
+	if err != nil {
+		return err
+	}
 
-			targetBlobstreamReader, err := blobstreamxwrapper.NewBlobstreamXCaller(
-				ethcmn.HexToAddress(config.TargetContractAddress),
-				targetEVMClient,
-			)
+	events, err := newEventStream(ctx, logger, config, cancel)
+	if err != nil {
+		return err
+	}
+	defer func(events *eventstream.Stream) {
+		err := events.Close()
+		if err != nil {
+			logger.Error("error closing the replay events stream", "err", err.Error())
+		}
+	}(events)
+
+	var auditLog *auditlog.Log
+	if config.AuditLog != "" {
+		auditLog, err = auditlog.Open(config.AuditLog, config.AuditPrivateKey)
+		if err != nil {
+			return err
+		}
+		defer func(auditLog *auditlog.Log) {
+			err := auditLog.Close()
 			if err != nil {
-				return err
+				logger.Error("error closing the audit log", "err", err.Error())
 			}
+		}(auditLog)
+	}
+
+	replayer, err := replay.NewReplayer(
+		logger,
+		replayConfig,
+		trpc,
+		contracts.sourceEVMClient,
+		contracts.targetEVMClient,
+		replayMetrics,
+		notifier,
+		events,
+		auditLog,
+	)
+	if err != nil {
+		return err
+	}
 
-			logger.Info(
-				"starting replay service",
-				"evm.source.rpc",
-				config.SourceEVMRPC,
-				"evm.source.contract-address",
-				config.SourceContractAddress,
-				"evm.target.rpc",
-				config.TargetEVMRPC,
-				"evm.target.contract-address",
-				config.TargetContractAddress,
-				"core.rpc",
-				config.CoreRPC,
-			)
-
-			latestSourceBlock, err := sourceBlobstreamReader.LatestBlock(&bind.CallOpts{})
+	if config.AdminListen != "" {
+		go func() {
+			err := cmdutil.ServeHTTP(ctx, logger, config.AdminListen, admin.New(logger, replayer, config.AdminToken))
 			if err != nil {
-				return err
+				logger.Error("admin server stopped", "err", err.Error())
+				cancel()
 			}
-			logger.Info("found source blobstreamX contract", "latest_block", latestSourceBlock)
+		}()
+	}
 
-			latestTargetBlock, err := targetBlobstreamReader.LatestBlock(&bind.CallOpts{})
+	if config.GovernancePollInterval != 0 {
+		go func() {
+			err := replayer.WatchGovernance(ctx, config.GovernancePollInterval)
 			if err != nil {
-				return err
-			}
-			logger.Info("found target blobstreamX contract", "latest_block", latestTargetBlock)
-
-			var trpc *http.HTTP
-			if config.Verify {
-				trpc, err = http.New(config.CoreRPC, "/websocket")
-				if err != nil {
-					return err
-				}
-				err = trpc.Start()
-				if err != nil {
-					return err
-				}
-				defer func(trpc *http.HTTP) {
-					err := trpc.Stop()
-					if err != nil {
-						logger.Error("error stopping tendermint RPC", "err", err.Error())
-					}
-				}(trpc)
+				logger.Error("governance watch stopped", "err", err.Error())
+				cancel()
 			}
+		}()
+	}
 
-			if latestSourceBlock > latestTargetBlock {
-				err = replay.Catchup(
-					ctx,
-					logger,
-					config.Verify,
-					trpc,
-					sourceEVMClient,
-					targetEVMClient,
-					config.SourceContractAddress,
-					config.TargetContractAddress,
-					config.TargetChainGateway,
-					config.PrivateKey,
-					config.HeaderRangeFunctionID,
-					config.NextHeaderFunctionID,
-					config.FilterRange,
-				)
-				if err != nil {
-					return err
-				}
-			} else {
-				logger.Info("target contract is already up to date")
-			}
+	err = replayer.Catchup(ctx)
+	if err != nil {
+		return err
+	}
+	if config.Archive != nil {
+		logger.Info("archived proofs replayed")
+		return nil
+	}
 
-			return replay.Follow(
-				ctx,
-				logger,
-				config.Verify,
-				trpc,
-				sourceEVMClient,
-				targetEVMClient,
-				config.SourceContractAddress,
-				config.TargetContractAddress,
-				config.TargetChainGateway,
-				config.PrivateKey,
-				config.HeaderRangeFunctionID,
-				config.NextHeaderFunctionID,
-				config.FilterRange,
-			)
-		},
+	return replayer.Follow(ctx)
+}
+
+// newEventStream creates the replay events stream publishing to the configured sinks.
+// The server-sent events endpoint is served until the context is canceled.
+func newEventStream(ctx context.Context, logger tmlog.Logger, config Config, cancel context.CancelFunc) (*eventstream.Stream, error) {
+	var sinks []eventstream.Sink
+	closeSinks := func() {
+		for _, sink := range sinks {
+			_ = sink.Close()
+		}
 	}
 
-	cmd.SetHelpCommand(&cobra.Command{})
+	switch config.EventsOutput {
+	case "":
+	case "-":
+		sinks = append(sinks, eventstream.NewWriterSink(logger, os.Stdout))
+	default:
+		sink, err := eventstream.NewFileSink(logger, config.EventsOutput)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
 
-	return addFlags(cmd)
+	if config.EventsSocket != "" {
+		sink, err := eventstream.NewUnixSocketSink(logger, config.EventsSocket)
+		if err != nil {
+			closeSinks()
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if config.EventsSSEListen != "" {
+		sink := eventstream.NewSSESink(logger)
+		sinks = append(sinks, sink)
+		mux := http.NewServeMux()
+		mux.Handle("/events", sink)
+		go func() {
+			err := cmdutil.ServeHTTP(ctx, logger, config.EventsSSEListen, mux)
+			if err != nil {
+				logger.Error("replay events server stopped", "err", err.Error())
+				cancel()
+			}
+		}()
+	}
+
+	return eventstream.New(logger, sinks...), nil
 }
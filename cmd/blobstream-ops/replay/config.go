@@ -2,12 +2,19 @@ package replay
 
 import (
 	"crypto/ecdsa"
-	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
+	"time"
 
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/proofverify"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/tracing"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/viper"
 
@@ -21,11 +28,19 @@ const (
 	FlagTargetEVMRPC             = "evm.target.rpc"
 	FlagTargetEVMContractAddress = "evm.target.contract-address"
 	FlagTargetChainGateway       = "evm.target.gateway"
+	FlagSourceChainGateway       = "evm.source.gateway"
 	FlagEVMPrivateKey            = "evm.private-key"
+	FlagEVMKeystore              = "evm.keystore"
+	FlagEVMKeystorePassphrase    = "evm.keystore-passphrase-file"
+	FlagEVMMnemonic              = "evm.mnemonic-file"
+	FlagEVMDerivationPath        = "evm.derivation-path"
+	FlagEVMRemoteSigner          = "evm.remote-signer"
+	FlagEVMRemoteSignerAddress   = "evm.remote-signer-address"
 	FlagEVMFilterRange           = "evm.filter-range"
 
 	FlagHeaderRangeFunctionID = "circuits.header-range.functionID"
 	FlagNextHeaderFunctionID  = "circuits.next-header.functionID"
+	FlagFunctionIDs           = "circuits.function-ids"
 
 	FlagVerify = "verify"
 
@@ -33,11 +48,82 @@ const (
 	FlagLogFormat = "log.format"
 
 	FlagCoreRPC = "core.rpc"
+
+	FlagRecordRPC   = "record-rpc"
+	FlagPlaybackRPC = "playback-rpc"
+
+	FlagMetricsListen = "metrics.listen"
+
+	FlagTracingExporter = "tracing.exporter"
+	FlagTracingEndpoint = "tracing.endpoint"
+	FlagTracingFile     = "tracing.file"
+
+	FlagAdminListen = "admin.listen"
+	FlagAdminToken  = "admin.token"
+
+	FlagNotifyWebhookURL            = "notify.webhook-url"
+	FlagNotifySlackURL              = "notify.slack-url"
+	FlagNotifyPagerDutyRoutingKey   = "notify.pagerduty-routing-key"
+	FlagNotifyPagerDutyURL          = "notify.pagerduty-url"
+	FlagNotifyCooldown              = "notify.cooldown"
+	FlagNotifyLagThreshold          = "notify.lag-threshold"
+	FlagNotifyMinBalance            = "notify.min-balance"
+	FlagNotifyMaxSubmissionFailures = "notify.max-submission-failures"
+
+	FlagEventsOutput    = "events.output"
+	FlagEventsSocket    = "events.socket"
+	FlagEventsSSEListen = "events.sse-listen"
+
+	FlagAuditLog        = "audit.log"
+	FlagAuditPrivateKey = "audit.private-key"
+
+	FlagProofVerificationKeys         = "proof-verification.keys"
+	FlagProofVerificationArtifactsDir = "proof-verification.artifacts-dir"
+
+	FlagDeployment = "deployment"
+
+	FlagSourceChainID        = "evm.source.chain-id"
+	FlagTargetChainID        = "evm.target.chain-id"
+	FlagPreflightProofs      = "preflight.proofs"
+	FlagPreflightGasPerProof = "preflight.gas-per-proof"
+
+	FlagGuardianCoreRPCs = "guardian.core-rpcs"
+	FlagGuardianQuorum   = "guardian.quorum"
+
+	FlagGovernanceWatch        = "governance.watch"
+	FlagGovernancePollInterval = "governance.poll-interval"
+
+	FlagSender               = "sender"
+	FlagSenderRelayerURL     = "sender.relayer-url"
+	FlagSenderRelayerToken   = "sender.relayer-token"
+	FlagSenderRelayerAddress = "sender.relayer-address"
+	FlagSenderPrivateRPC     = "sender.private-rpc"
+
+	FlagSource = "source"
+)
+
+// ArchiveSourceScheme the scheme of the source flag value replaying from a proofs archive.
+const ArchiveSourceScheme = "archive://"
+
+const (
+	// SenderDirect signs the transactions and sends them to the target chain.
+	SenderDirect = "direct"
+	// SenderRelayer posts the unsigned transactions to a relayer service.
+	SenderRelayer = "relayer"
+	// SenderPrivate signs the transactions and sends them to a private transactions endpoint.
+	SenderPrivate = "private"
 )
 
 func addFlags(cmd *cobra.Command) *cobra.Command {
 	viper.AutomaticEnv()
 
+	cmd.Flags().String(
+		FlagDeployment,
+		"",
+		fmt.Sprintf("Specify the deployment manifest written by the deploy command. The target contract, target gateway and function IDs flags default to its values. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagDeployment)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagDeployment)
+
 	cmd.Flags().String(
 		FlagSourceEVMRPC,
 		"http://localhost:8545",
@@ -74,18 +160,20 @@ func addFlags(cmd *cobra.Command) *cobra.Command {
 	cmdutil.BindFlagAndEnvVar(cmd, FlagTargetChainGateway)
 
 	cmd.Flags().String(
-		FlagLogLevel,
-		"info",
-		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+		FlagSourceChainGateway,
+		"",
+		fmt.Sprintf("Specify the source chain succinct gateway contract address the proofs are submitted to. If not set, it's read from the source BlobstreamX contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceChainGateway)),
 	)
-	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceChainGateway)
 
 	cmd.Flags().String(
-		FlagLogFormat,
-		"plain",
-		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+		FlagSource,
+		"",
+		fmt.Sprintf("Specify where the source proofs are read from. If not set, they're read from the source EVM chain. Set to %s<path> to replay the proofs archive written by the export command, without connecting to the source chain. Corresponding environment variable %s", ArchiveSourceScheme, cmdutil.ToEnvVariableFormat(FlagSource)),
 	)
-	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSource)
+
+	addLogFlags(cmd)
 
 	cmd.Flags().String(
 		FlagCoreRPC,
@@ -101,27 +189,64 @@ func addFlags(cmd *cobra.Command) *cobra.Command {
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagVerify)
 
+	addSignerFlags(cmd)
+
+	cmd.Flags().String(
+		FlagSender,
+		SenderDirect,
+		fmt.Sprintf("Specify how the proof submission transactions are sent (direct|relayer|private). direct signs and sends them to the target chain, relayer posts them unsigned to a relayer service which signs and sends them, and private signs and sends them to a private transactions endpoint. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSender)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSender)
+
+	cmd.Flags().String(
+		FlagSenderRelayerURL,
+		"",
+		fmt.Sprintf("Specify the URL of the relayer service the transactions are posted to. Required if the sender is relayer. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSenderRelayerURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSenderRelayerURL)
+
+	cmd.Flags().String(
+		FlagSenderRelayerToken,
+		"",
+		fmt.Sprintf("Specify the bearer token sent to the relayer service. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSenderRelayerToken)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSenderRelayerToken)
+
 	cmd.Flags().String(
-		FlagEVMPrivateKey,
+		FlagSenderRelayerAddress,
 		"",
-		fmt.Sprintf("Specify the EVM private key, in hex format, to use for replaying transaction in the target chain. Corresponding account should be funded. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMPrivateKey)),
+		fmt.Sprintf("Specify the address of the account the relayer sends the transactions from. Only used to track its balance. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSenderRelayerAddress)),
 	)
-	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMPrivateKey)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSenderRelayerAddress)
+
+	cmd.Flags().String(
+		FlagSenderPrivateRPC,
+		"",
+		fmt.Sprintf("Specify the rpc address of the private transactions endpoint the signed transactions are sent to. Required if the sender is private. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSenderPrivateRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSenderPrivateRPC)
 
 	cmd.Flags().String(
 		FlagHeaderRangeFunctionID,
 		"",
-		fmt.Sprintf("Specify the function ID of the header range circuit in the target BlobstreamX contract, in hex format. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeaderRangeFunctionID)),
+		fmt.Sprintf("Specify the function ID of the header range circuit in the target BlobstreamX contract, in hex format. If not set, it's read from the target BlobstreamX contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagHeaderRangeFunctionID)),
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagHeaderRangeFunctionID)
 
 	cmd.Flags().String(
 		FlagNextHeaderFunctionID,
 		"",
-		fmt.Sprintf("Specify the function ID of the next header circuit in the target BlobstreamX contract, in hex format. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNextHeaderFunctionID)),
+		fmt.Sprintf("Specify the function ID of the next header circuit in the target BlobstreamX contract, in hex format. If not set, it's read from the target BlobstreamX contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNextHeaderFunctionID)),
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagNextHeaderFunctionID)
 
+	cmd.Flags().StringSlice(
+		FlagFunctionIDs,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of <source function ID>=<target function ID>[:<encoding>] mappings, in hex format, to replay the proofs of other circuits. The encoding of the circuit input and output is one of header-range, next-header or unchecked, in which case the proofs are replayed without checking them against their events. If not set, it's detected from the input length, and the proofs of circuits whose encoding isn't detected are rejected. The header range and next header circuits are mapped by default. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagFunctionIDs)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagFunctionIDs)
+
 	cmd.Flags().Int64(
 		FlagEVMFilterRange,
 		5000,
@@ -129,23 +254,323 @@ func addFlags(cmd *cobra.Command) *cobra.Command {
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMFilterRange)
 
+	cmd.Flags().String(
+		FlagRecordRPC,
+		"",
+		fmt.Sprintf("Specify a directory to record all the JSON-RPC requests and responses into. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagRecordRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagRecordRPC)
+
+	cmd.Flags().String(
+		FlagPlaybackRPC,
+		"",
+		fmt.Sprintf("Specify a directory containing recorded JSON-RPC requests and responses to serve instead of connecting to the endpoints. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPlaybackRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPlaybackRPC)
+
+	cmd.Flags().String(
+		FlagMetricsListen,
+		"",
+		fmt.Sprintf("Specify the address to expose the Prometheus metrics on, e.g. localhost:9090. If not set, the metrics are not exposed. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagMetricsListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagMetricsListen)
+
+	cmd.Flags().String(
+		FlagTracingExporter,
+		tracing.ExporterNone,
+		fmt.Sprintf("Specify the OpenTelemetry traces exporter: %s, %s, %s or %s. Corresponding environment variable %s", tracing.ExporterNone, tracing.ExporterOTLPGRPC, tracing.ExporterOTLPHTTP, tracing.ExporterFile, cmdutil.ToEnvVariableFormat(FlagTracingExporter)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingExporter)
+
+	cmd.Flags().String(
+		FlagTracingEndpoint,
+		"",
+		fmt.Sprintf("Specify the OTLP collector URL the traces are exported to, e.g. http://localhost:4317. If not set, the OTLP exporters default is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTracingEndpoint)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingEndpoint)
+
+	cmd.Flags().String(
+		FlagTracingFile,
+		"",
+		fmt.Sprintf("Specify the file the traces are written to when using the file exporter. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTracingFile)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingFile)
+
+	cmd.Flags().String(
+		FlagAdminListen,
+		"",
+		fmt.Sprintf("Specify the address to expose the admin HTTP API on, e.g. localhost:9091. If not set, the admin API is not exposed. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAdminListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAdminListen)
+
+	cmd.Flags().String(
+		FlagAdminToken,
+		"",
+		fmt.Sprintf("Specify the bearer token required to query the admin API status, pause and resume endpoints. If not set, the admin API is not authenticated. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAdminToken)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAdminToken)
+
+	cmd.Flags().StringSlice(
+		FlagNotifyWebhookURL,
+		nil,
+		fmt.Sprintf("Specify the URLs the alerts are posted to as generic JSON payloads. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyWebhookURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyWebhookURL)
+
+	cmd.Flags().StringSlice(
+		FlagNotifySlackURL,
+		nil,
+		fmt.Sprintf("Specify the Slack incoming webhook URLs the alerts are posted to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifySlackURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifySlackURL)
+
+	cmd.Flags().String(
+		FlagNotifyPagerDutyRoutingKey,
+		"",
+		fmt.Sprintf("Specify the PagerDuty Events API v2 routing key used to trigger incidents. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyPagerDutyRoutingKey)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyPagerDutyRoutingKey)
+
+	cmd.Flags().String(
+		FlagNotifyPagerDutyURL,
+		notify.DefaultPagerDutyURL,
+		fmt.Sprintf("Specify the PagerDuty Events API v2 compatible endpoint. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyPagerDutyURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyPagerDutyURL)
+
+	cmd.Flags().Duration(
+		FlagNotifyCooldown,
+		30*time.Minute,
+		fmt.Sprintf("Specify the minimum duration between two identical alerts. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyCooldown)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyCooldown)
+
+	cmd.Flags().Duration(
+		FlagNotifyLagThreshold,
+		0,
+		fmt.Sprintf("Specify the lag behind the source contract above which an alert is sent, e.g. 2h. Zero disables the alert. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyLagThreshold)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyLagThreshold)
+
+	cmd.Flags().String(
+		FlagNotifyMinBalance,
+		"",
+		fmt.Sprintf("Specify the signer balance, in wei, below which an alert is sent. If not set, the alert is disabled. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyMinBalance)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyMinBalance)
+
+	cmd.Flags().Int(
+		FlagNotifyMaxSubmissionFailures,
+		3,
+		fmt.Sprintf("Specify the number of consecutive submission failures after which an alert is sent. Zero disables the alert. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyMaxSubmissionFailures)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyMaxSubmissionFailures)
+
+	cmd.Flags().String(
+		FlagEventsOutput,
+		"",
+		fmt.Sprintf("Specify the file the replay events are appended to as NDJSON, or - for stdout. If not set, the events are not written. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEventsOutput)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEventsOutput)
+
+	cmd.Flags().String(
+		FlagEventsSocket,
+		"",
+		fmt.Sprintf("Specify the path of a Unix domain socket streaming the replay events as NDJSON to its clients. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEventsSocket)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEventsSocket)
+
+	cmd.Flags().String(
+		FlagEventsSSEListen,
+		"",
+		fmt.Sprintf("Specify the address to stream the replay events on, as HTTP server-sent events on /events, e.g. localhost:9092. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEventsSSEListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEventsSSEListen)
+
+	cmd.Flags().String(
+		FlagAuditLog,
+		"",
+		fmt.Sprintf("Specify the file the signed audit log entries are appended to. If not set, no audit log is kept. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAuditLog)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAuditLog)
+
+	cmd.Flags().String(
+		FlagAuditPrivateKey,
+		"",
+		fmt.Sprintf("Specify the private key, in hex format, signing the audit log entries. Defaults to the EVM private key. Required when using a remote signer. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAuditPrivateKey)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAuditPrivateKey)
+
+	cmd.Flags().StringSlice(
+		FlagProofVerificationKeys,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of <function ID>=<path> Groth16 verifying keys, in JSON, used to verify the proofs off-chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProofVerificationKeys)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProofVerificationKeys)
+
+	cmd.Flags().String(
+		FlagProofVerificationArtifactsDir,
+		"",
+		fmt.Sprintf("Specify a directory containing a <function ID>.json Groth16 verifying key per function, used to verify the proofs off-chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProofVerificationArtifactsDir)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProofVerificationArtifactsDir)
+
+	cmd.Flags().String(
+		FlagSourceChainID,
+		"",
+		fmt.Sprintf("Specify the expected chain ID of the source EVM chain, checked before replaying. If not set, it's not checked. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagSourceChainID)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagSourceChainID)
+
+	cmd.Flags().String(
+		FlagTargetChainID,
+		"",
+		fmt.Sprintf("Specify the expected chain ID of the target EVM chain, checked before replaying. If not set, it's not checked. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTargetChainID)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTargetChainID)
+
+	defaultPreflight := replay.DefaultPreflightConfig()
+	cmd.Flags().Int64(
+		FlagPreflightProofs,
+		defaultPreflight.Proofs,
+		fmt.Sprintf("Specify the number of proofs the signer balance should be able to pay for before replaying. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPreflightProofs)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPreflightProofs)
+
+	cmd.Flags().Uint64(
+		FlagPreflightGasPerProof,
+		defaultPreflight.GasPerProof,
+		fmt.Sprintf("Specify the gas used by a proof submission, used to estimate the cost of the proofs during the preflight checks. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPreflightGasPerProof)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPreflightGasPerProof)
+
+	// if a guardian signer is set, the target contract is frozen when a verification mismatch is confirmed
+	// by the guardian trusted core endpoints
+	guardianSignerFlags.Add(cmd, "the target contract guardian")
+
+	cmd.Flags().StringSlice(
+		FlagGuardianCoreRPCs,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of trusted celestia app rpc addresses a mismatch is confirmed against before freezing the target contract. Requires a guardian signer and --%s. Corresponding environment variable %s", FlagVerify, cmdutil.ToEnvVariableFormat(FlagGuardianCoreRPCs)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGuardianCoreRPCs)
+
+	cmd.Flags().Int(
+		FlagGuardianQuorum,
+		0,
+		fmt.Sprintf("Specify the number of guardian trusted core endpoints that need to confirm a mismatch before freezing the target contract. Defaults to a majority of them. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGuardianQuorum)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGuardianQuorum)
+
+	cmd.Flags().Bool(
+		FlagGovernanceWatch,
+		false,
+		fmt.Sprintf("Set to watch the source and target contracts, and their gateways, for upgrades and governance changes, and pause the replay until they're acknowledged using the admin API. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGovernanceWatch)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGovernanceWatch)
+
+	cmd.Flags().Duration(
+		FlagGovernancePollInterval,
+		time.Minute,
+		fmt.Sprintf("Specify the interval between two governance changes checks. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagGovernancePollInterval)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagGovernancePollInterval)
+
 	return cmd
 }
 
+// addLogFlags adds the logging flags to the command.
+func addLogFlags(cmd *cobra.Command) {
+	cmd.Flags().String(
+		FlagLogLevel,
+		"info",
+		fmt.Sprintf("The logging level (trace|debug|info|warn|error|fatal|panic). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogLevel)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogLevel)
+
+	cmd.Flags().String(
+		FlagLogFormat,
+		"plain",
+		fmt.Sprintf("The logging format (json|plain). Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagLogFormat)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagLogFormat)
+}
+
+// evmSignerFlags the flags of the EVM key or external signer signing the replay transactions.
+var evmSignerFlags = cmdutil.SignerFlags{
+	PrivateKey:          FlagEVMPrivateKey,
+	Keystore:            FlagEVMKeystore,
+	KeystorePassphrase:  FlagEVMKeystorePassphrase,
+	Mnemonic:            FlagEVMMnemonic,
+	DerivationPath:      FlagEVMDerivationPath,
+	RemoteSigner:        FlagEVMRemoteSigner,
+	RemoteSignerAddress: FlagEVMRemoteSignerAddress,
+}
+
+// guardianSignerFlags the flags of the target contract guardian key or external signer, e.g.
+// guardian.private-key.
+var guardianSignerFlags = cmdutil.NewSignerFlags("guardian")
+
+// addSignerFlags adds the flags of the EVM key or external signer signing the replay transactions to the
+// command.
+func addSignerFlags(cmd *cobra.Command) {
+	evmSignerFlags.Add(cmd, "the account replaying the transactions in the target chain, which should be funded")
+}
+
 type Config struct {
 	SourceEVMRPC          string
 	TargetEVMRPC          string
 	SourceContractAddress string
 	TargetContractAddress string
 	TargetChainGateway    string
-	LogLevel              string
-	LogFormat             string
-	CoreRPC               string
-	Verify                bool
-	PrivateKey            *ecdsa.PrivateKey
+	SourceChainGateway    string
+	// Archive the proofs archive replayed instead of reading the proofs from the source chain. Nil if not set.
+	Archive   *replay.Archive
+	LogLevel  string
+	LogFormat string
+	CoreRPC   string
+	Verify    bool
+	// Signer the EVM key or external signer signing the replay transactions. Not set if the transactions are
+	// signed by the relayer.
+	Signer cmdutil.SignerConfig
+	// Sender how the proof submission transactions are sent: direct, relayer or private.
+	Sender         string
+	RelayerURL     string
+	RelayerToken   string
+	RelayerAddress ethcmn.Address
+	PrivateRPC     string
+	// HeaderRangeFunctionID and NextHeaderFunctionID the target function IDs. Zero if they need to be read from the target contract.
 	HeaderRangeFunctionID [32]byte
 	NextHeaderFunctionID  [32]byte
+	FunctionIDs           map[[32]byte][32]byte
+	// Circuits the encodings of the circuits input and output set with the function IDs mappings, keyed by
+	// the source function IDs.
+	Circuits              map[[32]byte]replay.CircuitEncoding
 	FilterRange           int64
+	RecordRPC             string
+	PlaybackRPC           string
+	MetricsListen         string
+	Tracing               tracing.Config
+	Webhooks              []notify.Webhook
+	NotifyCooldown        time.Duration
+	LagAlertThreshold     time.Duration
+	MinSignerBalance      *big.Int
+	MaxSubmissionFailures int
+	AdminListen           string
+	AdminToken            string
+	EventsOutput          string
+	EventsSocket          string
+	EventsSSEListen       string
+	AuditLog              string
+	AuditPrivateKey       *ecdsa.PrivateKey
+	ProofVerifier         *proofverify.Verifier
+	Preflight             replay.PreflightConfig
+	// GuardianSigner the target contract guardian key or external signer. Not set if the guardian is disabled.
+	GuardianSigner   cmdutil.SignerConfig
+	GuardianCoreRPCs []string
+	GuardianQuorum   int
+	// GovernancePollInterval the governance changes polling interval. Zero if the governance isn't watched.
+	GovernancePollInterval time.Duration
 }
 
 func (cfg Config) ValidateBasics() error {
@@ -163,9 +588,78 @@ func (cfg Config) ValidateBasics() error {
 	if err := ValidateEVMAddress(cfg.TargetChainGateway); err != nil {
 		return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagTargetChainGateway, cmdutil.ToEnvVariableFormat(FlagTargetChainGateway))
 	}
+	if cfg.SourceChainGateway != "" {
+		if err := ValidateEVMAddress(cfg.SourceChainGateway); err != nil {
+			return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagSourceChainGateway, cmdutil.ToEnvVariableFormat(FlagSourceChainGateway))
+		}
+	}
 	if cfg.Verify && cfg.CoreRPC == "" {
 		return fmt.Errorf("flag --%s is set but the core RPC flag --%s is not set. Please set --%s or environment variable %s", FlagVerify, FlagCoreRPC, FlagCoreRPC, cmdutil.ToEnvVariableFormat(FlagCoreRPC))
 	}
+	if cfg.FilterRange <= 0 {
+		return fmt.Errorf("the filter range should be positive: flag --%s", FlagEVMFilterRange)
+	}
+	if cfg.RecordRPC != "" && cfg.PlaybackRPC != "" {
+		return fmt.Errorf("flags --%s and --%s cannot be set at the same time", FlagRecordRPC, FlagPlaybackRPC)
+	}
+	if err := cfg.Tracing.ValidateBasics(); err != nil {
+		return fmt.Errorf("%s: flags --%s and --%s", err.Error(), FlagTracingExporter, FlagTracingFile)
+	}
+	for _, webhook := range cfg.Webhooks {
+		if err := webhook.ValidateBasics(); err != nil {
+			return err
+		}
+	}
+	if cfg.MinSignerBalance != nil && cfg.MinSignerBalance.Sign() < 0 {
+		return fmt.Errorf("the minimum signer balance cannot be negative: flag --%s", FlagNotifyMinBalance)
+	}
+	if cfg.Preflight.Proofs < 0 {
+		return fmt.Errorf("the number of preflight proofs cannot be negative: flag --%s", FlagPreflightProofs)
+	}
+	if cfg.MaxSubmissionFailures < 0 {
+		return fmt.Errorf("the maximum submission failures cannot be negative: flag --%s", FlagNotifyMaxSubmissionFailures)
+	}
+	switch cfg.Sender {
+	case SenderDirect:
+	case SenderRelayer:
+		if cfg.RelayerURL == "" {
+			return fmt.Errorf("the sender is %s but the relayer URL is not set. Please set --%s or environment variable %s", SenderRelayer, FlagSenderRelayerURL, cmdutil.ToEnvVariableFormat(FlagSenderRelayerURL))
+		}
+	case SenderPrivate:
+		if cfg.PrivateRPC == "" {
+			return fmt.Errorf("the sender is %s but the private transactions endpoint is not set. Please set --%s or environment variable %s", SenderPrivate, FlagSenderPrivateRPC, cmdutil.ToEnvVariableFormat(FlagSenderPrivateRPC))
+		}
+	default:
+		return fmt.Errorf("unknown sender %q, expected %s, %s or %s: flag --%s", cfg.Sender, SenderDirect, SenderRelayer, SenderPrivate, FlagSender)
+	}
+	if cfg.Signer.PrivateKey == nil && cfg.AuditLog != "" && cfg.AuditPrivateKey == nil {
+		return fmt.Errorf("the audit log entries can't be signed without a local EVM key. Please set --%s or environment variable %s", FlagAuditPrivateKey, cmdutil.ToEnvVariableFormat(FlagAuditPrivateKey))
+	}
+	if cfg.GuardianSigner.IsSet() {
+		if !cfg.Verify {
+			return fmt.Errorf("the guardian signer is set but the verification is disabled. Please set --%s", FlagVerify)
+		}
+		if cfg.GuardianSigner.Address() == cfg.Signer.Address() {
+			return errors.New("the guardian signer should be different from the replay signer")
+		}
+		if len(cfg.GuardianCoreRPCs) == 0 {
+			return fmt.Errorf("the guardian signer is set but no trusted core endpoint is set. Please set --%s or environment variable %s", FlagGuardianCoreRPCs, cmdutil.ToEnvVariableFormat(FlagGuardianCoreRPCs))
+		}
+		if cfg.GuardianQuorum < 0 || cfg.GuardianQuorum > len(cfg.GuardianCoreRPCs) {
+			return fmt.Errorf("the guardian quorum should be between 1 and the number of trusted core endpoints: flag --%s", FlagGuardianQuorum)
+		}
+	}
+	if cfg.Archive != nil {
+		if !strings.EqualFold(cfg.SourceContractAddress, cfg.Archive.SourceContract.Hex()) {
+			return fmt.Errorf("the source contract %s doesn't match the archived proofs source contract %s: flag --%s", cfg.SourceContractAddress, cfg.Archive.SourceContract.Hex(), FlagSourceEVMContractAddress)
+		}
+		if cfg.GovernancePollInterval != 0 {
+			return fmt.Errorf("the governance can't be watched when replaying from a proofs archive: flag --%s", FlagGovernanceWatch)
+		}
+	}
+	if cfg.GovernancePollInterval < 0 {
+		return fmt.Errorf("the governance poll interval should be positive: flag --%s", FlagGovernancePollInterval)
+	}
 	return nil
 }
 
@@ -179,12 +673,31 @@ func ValidateEVMAddress(addr string) error {
 	return nil
 }
 
-func parseFlags() (Config, error) {
+// parseFlags parses the replay flags. The signer is optional if the transactions are only prepared, or
+// signed by the relayer.
+func parseFlags(optionalSigner bool) (Config, error) {
+	var manifest *deploy.Manifest
+	if path := viper.GetString(FlagDeployment); path != "" {
+		loaded, err := deploy.LoadManifest(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagDeployment)
+		}
+		manifest = &loaded
+	}
+
 	sourceContractAddress := viper.GetString(FlagSourceEVMContractAddress)
 
 	targetContractAddress := viper.GetString(FlagTargetEVMContractAddress)
+	if targetContractAddress == "" && manifest != nil {
+		targetContractAddress = manifest.BlobstreamX.Hex()
+	}
 
 	targetChainGateway := viper.GetString(FlagTargetChainGateway)
+	if targetChainGateway == "" && manifest != nil {
+		targetChainGateway = manifest.Gateway.Hex()
+	}
+
+	sourceChainGateway := viper.GetString(FlagSourceChainGateway)
 
 	sourceEVMRPC := viper.GetString(FlagSourceEVMRPC)
 
@@ -196,59 +709,234 @@ func parseFlags() (Config, error) {
 
 	logFormat := viper.GetString(FlagLogFormat)
 
-	rawPrivateKey := viper.GetString(FlagEVMPrivateKey)
-	if rawPrivateKey == "" {
-		return Config{}, fmt.Errorf("please set the private key --%s or %s", FlagEVMPrivateKey, cmdutil.ToEnvVariableFormat(FlagEVMPrivateKey))
+	sender := viper.GetString(FlagSender)
+
+	// the relayer signs the transactions, the replay host doesn't need a signer
+	signerConfig, err := evmSignerFlags.Parse(optionalSigner || sender == SenderRelayer)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var relayerAddress ethcmn.Address
+	if rawAddress := viper.GetString(FlagSenderRelayerAddress); rawAddress != "" {
+		if err := ValidateEVMAddress(rawAddress); err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagSenderRelayerAddress, cmdutil.ToEnvVariableFormat(FlagSenderRelayerAddress))
+		}
+		relayerAddress = ethcmn.HexToAddress(rawAddress)
+	}
+
+	var bzHeaderRange [32]byte
+	if strHeaderRange := viper.GetString(FlagHeaderRangeFunctionID); strHeaderRange != "" {
+		bzHeaderRange, err = fulfillcall.ParseFunctionID(strHeaderRange)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagHeaderRangeFunctionID, cmdutil.ToEnvVariableFormat(FlagHeaderRangeFunctionID))
+		}
+	}
+
+	var bzNextHeader [32]byte
+	if strNextHeader := viper.GetString(FlagNextHeaderFunctionID); strNextHeader != "" {
+		bzNextHeader, err = fulfillcall.ParseFunctionID(strNextHeader)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagNextHeaderFunctionID, cmdutil.ToEnvVariableFormat(FlagNextHeaderFunctionID))
+		}
+	}
+
+	if manifest != nil {
+		if bzHeaderRange == [32]byte{} {
+			bzHeaderRange = manifest.HeaderRangeFunctionID
+		}
+		if bzNextHeader == [32]byte{} {
+			bzNextHeader = manifest.NextHeaderFunctionID
+		}
 	}
-	rawPrivateKey = strings.TrimPrefix(rawPrivateKey, "0x")
-	privateKey, err := crypto.HexToECDSA(rawPrivateKey)
+
+	functionIDs, circuits, err := parseFunctionIDs(viper.GetStringSlice(FlagFunctionIDs))
 	if err != nil {
-		return Config{}, fmt.Errorf("failed to hex-decode Ethereum ECDSA Private Key: %w", err)
+		return Config{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagFunctionIDs)
+	}
+
+	filterRange := viper.GetInt64(FlagEVMFilterRange)
+
+	verify := viper.GetBool(FlagVerify)
+
+	recordRPC := viper.GetString(FlagRecordRPC)
+
+	playbackRPC := viper.GetString(FlagPlaybackRPC)
+
+	metricsListen := viper.GetString(FlagMetricsListen)
+
+	tracingConfig := tracing.Config{
+		Exporter: viper.GetString(FlagTracingExporter),
+		Endpoint: viper.GetString(FlagTracingEndpoint),
+		File:     viper.GetString(FlagTracingFile),
+	}
+
+	adminListen := viper.GetString(FlagAdminListen)
+
+	adminToken := viper.GetString(FlagAdminToken)
+
+	webhooks := notify.NewWebhooks(
+		viper.GetStringSlice(FlagNotifyWebhookURL),
+		viper.GetStringSlice(FlagNotifySlackURL),
+		viper.GetString(FlagNotifyPagerDutyRoutingKey),
+		viper.GetString(FlagNotifyPagerDutyURL),
+	)
+
+	notifyCooldown := viper.GetDuration(FlagNotifyCooldown)
+
+	lagAlertThreshold := viper.GetDuration(FlagNotifyLagThreshold)
+
+	var minSignerBalance *big.Int
+	if rawMinBalance := viper.GetString(FlagNotifyMinBalance); rawMinBalance != "" {
+		var ok bool
+		minSignerBalance, ok = new(big.Int).SetString(rawMinBalance, 10)
+		if !ok {
+			return Config{}, fmt.Errorf("invalid minimum signer balance %q, expected an amount in wei: flag --%s", rawMinBalance, FlagNotifyMinBalance)
+		}
+	}
+
+	maxSubmissionFailures := viper.GetInt(FlagNotifyMaxSubmissionFailures)
+
+	eventsOutput := viper.GetString(FlagEventsOutput)
+
+	eventsSocket := viper.GetString(FlagEventsSocket)
+
+	eventsSSEListen := viper.GetString(FlagEventsSSEListen)
+
+	auditLog := viper.GetString(FlagAuditLog)
+
+	auditPrivateKey := signerConfig.PrivateKey
+	if rawAuditPrivateKey := viper.GetString(FlagAuditPrivateKey); rawAuditPrivateKey != "" {
+		auditPrivateKey, err = crypto.HexToECDSA(strings.TrimPrefix(rawAuditPrivateKey, "0x"))
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to hex-decode the audit log private key: %w", err)
+		}
 	}
 
-	strHeaderRange := viper.GetString(FlagHeaderRangeFunctionID)
-	if strHeaderRange == "" {
-		return Config{}, fmt.Errorf("please set the header range function ID --%s or %s", FlagHeaderRangeFunctionID, cmdutil.ToEnvVariableFormat(FlagHeaderRangeFunctionID))
+	var proofVerifier *proofverify.Verifier
+	if keys, artifactsDir := viper.GetStringSlice(FlagProofVerificationKeys), viper.GetString(FlagProofVerificationArtifactsDir); len(keys) != 0 || artifactsDir != "" {
+		keyFiles, err := proofverify.ParseKeyFiles(keys)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagProofVerificationKeys)
+		}
+		proofVerifier, err = proofverify.LoadVerifier(artifactsDir, keyFiles)
+		if err != nil {
+			return Config{}, fmt.Errorf("couldn't load the verifying keys: %w", err)
+		}
 	}
-	strHeaderRange = strings.TrimPrefix(strHeaderRange, "0x")
-	decodedHeaderRange, err := hex.DecodeString(strHeaderRange)
+
+	sourceChainID, err := parseChainID(FlagSourceChainID)
 	if err != nil {
 		return Config{}, err
 	}
-	var bzHeaderRange [32]byte
-	copy(bzHeaderRange[:], decodedHeaderRange)
 
-	strNextHeader := viper.GetString(FlagNextHeaderFunctionID)
-	if strNextHeader == "" {
-		return Config{}, fmt.Errorf("please set the next header function ID --%s or %s", FlagNextHeaderFunctionID, cmdutil.ToEnvVariableFormat(FlagNextHeaderFunctionID))
-	}
-	strNextHeader = strings.TrimPrefix(strNextHeader, "0x")
-	decodedNextHeader, err := hex.DecodeString(strNextHeader)
+	targetChainID, err := parseChainID(FlagTargetChainID)
 	if err != nil {
 		return Config{}, err
 	}
-	var bzNextHeader [32]byte
-	copy(bzNextHeader[:], decodedNextHeader)
 
-	filterRange := viper.GetInt64(FlagEVMFilterRange)
+	preflightConfig := replay.PreflightConfig{
+		SourceChainID: sourceChainID,
+		TargetChainID: targetChainID,
+		Proofs:        viper.GetInt64(FlagPreflightProofs),
+		GasPerProof:   viper.GetUint64(FlagPreflightGasPerProof),
+	}
 
-	verify := viper.GetBool(FlagVerify)
+	guardianSigner, err := guardianSignerFlags.Parse(true)
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid guardian signer: %w", err)
+	}
+
+	var governancePollInterval time.Duration
+	if viper.GetBool(FlagGovernanceWatch) {
+		governancePollInterval = viper.GetDuration(FlagGovernancePollInterval)
+		if governancePollInterval == 0 {
+			return Config{}, fmt.Errorf("the governance poll interval cannot be zero: flag --%s", FlagGovernancePollInterval)
+		}
+	}
 
 	// TODO add rate limiting flag
 	// TODO add gas price multiplier flag
-	return Config{
-		SourceEVMRPC:          sourceEVMRPC,
-		TargetEVMRPC:          targetEVMRPC,
-		SourceContractAddress: sourceContractAddress,
-		TargetContractAddress: targetContractAddress,
-		TargetChainGateway:    targetChainGateway,
-		CoreRPC:               coreRPC,
-		LogLevel:              logLevel,
-		LogFormat:             logFormat,
-		PrivateKey:            privateKey,
-		NextHeaderFunctionID:  bzNextHeader,
-		HeaderRangeFunctionID: bzHeaderRange,
-		FilterRange:           filterRange,
-		Verify:                verify,
-	}, nil
+	config := Config{
+		SourceEVMRPC:           sourceEVMRPC,
+		TargetEVMRPC:           targetEVMRPC,
+		SourceContractAddress:  sourceContractAddress,
+		TargetContractAddress:  targetContractAddress,
+		TargetChainGateway:     targetChainGateway,
+		SourceChainGateway:     sourceChainGateway,
+		CoreRPC:                coreRPC,
+		LogLevel:               logLevel,
+		LogFormat:              logFormat,
+		Signer:                 signerConfig,
+		Sender:                 sender,
+		RelayerURL:             viper.GetString(FlagSenderRelayerURL),
+		RelayerToken:           viper.GetString(FlagSenderRelayerToken),
+		RelayerAddress:         relayerAddress,
+		PrivateRPC:             viper.GetString(FlagSenderPrivateRPC),
+		NextHeaderFunctionID:   bzNextHeader,
+		HeaderRangeFunctionID:  bzHeaderRange,
+		FunctionIDs:            functionIDs,
+		Circuits:               circuits,
+		FilterRange:            filterRange,
+		Verify:                 verify,
+		RecordRPC:              recordRPC,
+		PlaybackRPC:            playbackRPC,
+		MetricsListen:          metricsListen,
+		Tracing:                tracingConfig,
+		Webhooks:               webhooks,
+		NotifyCooldown:         notifyCooldown,
+		LagAlertThreshold:      lagAlertThreshold,
+		MinSignerBalance:       minSignerBalance,
+		MaxSubmissionFailures:  maxSubmissionFailures,
+		EventsOutput:           eventsOutput,
+		EventsSocket:           eventsSocket,
+		EventsSSEListen:        eventsSSEListen,
+		AdminListen:            adminListen,
+		AdminToken:             adminToken,
+		AuditLog:               auditLog,
+		AuditPrivateKey:        auditPrivateKey,
+		ProofVerifier:          proofVerifier,
+		Preflight:              preflightConfig,
+		GuardianSigner:         guardianSigner,
+		GuardianCoreRPCs:       viper.GetStringSlice(FlagGuardianCoreRPCs),
+		GuardianQuorum:         viper.GetInt(FlagGuardianQuorum),
+		GovernancePollInterval: governancePollInterval,
+	}
+
+	switch source := viper.GetString(FlagSource); {
+	case source == "":
+	case strings.HasPrefix(source, ArchiveSourceScheme):
+		archive, err := replay.LoadArchive(strings.TrimPrefix(source, ArchiveSourceScheme))
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagSource)
+		}
+		config.useArchive(archive)
+	default:
+		return Config{}, fmt.Errorf("unknown source %q, expected %s<path>: flag --%s", source, ArchiveSourceScheme, FlagSource)
+	}
+	return config, nil
+}
+
+// useArchive sets the proofs archive to replay. The source contract and gateway default to the archived ones.
+func (cfg *Config) useArchive(archive *replay.Archive) {
+	cfg.Archive = archive
+	if cfg.SourceContractAddress == "" {
+		cfg.SourceContractAddress = archive.SourceContract.Hex()
+	}
+	if cfg.SourceChainGateway == "" {
+		cfg.SourceChainGateway = archive.SourceGateway.Hex()
+	}
+}
+
+// parseChainID parses the chain ID set using the provided flag. It returns nil if it's not set.
+func parseChainID(flag string) (*big.Int, error) {
+	rawChainID := viper.GetString(flag)
+	if rawChainID == "" {
+		return nil, nil
+	}
+	chainID, ok := new(big.Int).SetString(rawChainID, 10)
+	if !ok || chainID.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid chain ID %q: flag --%s", rawChainID, flag)
+	}
+	return chainID, nil
 }
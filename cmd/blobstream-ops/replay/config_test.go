@@ -0,0 +1,101 @@
+package replay
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/tracing"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlagsDeployment(t *testing.T) {
+	manifest := deploy.Manifest{
+		ChainID:               "1337",
+		Gateway:               ethcmn.HexToAddress("0x1000000000000000000000000000000000000001"),
+		BlobstreamX:           ethcmn.HexToAddress("0x2000000000000000000000000000000000000002"),
+		HeaderRangeFunctionID: ethcmn.HexToHash("0x01"),
+		NextHeaderFunctionID:  ethcmn.HexToHash("0x02"),
+		GenesisHeight:         1000,
+		GenesisHeader:         ethcmn.HexToHash("0x03"),
+	}
+	path := filepath.Join(t.TempDir(), "deployment.json")
+	require.NoError(t, manifest.Write(path))
+
+	tests := []struct {
+		name              string
+		flags             map[string]string
+		wantContract      string
+		wantGateway       string
+		wantHeaderRangeID [32]byte
+		wantNextHeaderID  [32]byte
+	}{
+		{
+			name:              "manifest values",
+			wantContract:      manifest.BlobstreamX.Hex(),
+			wantGateway:       manifest.Gateway.Hex(),
+			wantHeaderRangeID: manifest.HeaderRangeFunctionID,
+			wantNextHeaderID:  manifest.NextHeaderFunctionID,
+		},
+		{
+			name: "flags take precedence",
+			flags: map[string]string{
+				FlagTargetEVMContractAddress: "0x3000000000000000000000000000000000000003",
+				FlagHeaderRangeFunctionID:    "0x0000000000000000000000000000000000000000000000000000000000000004",
+			},
+			wantContract:      "0x3000000000000000000000000000000000000003",
+			wantGateway:       manifest.Gateway.Hex(),
+			wantHeaderRangeID: ethcmn.HexToHash("0x04"),
+			wantNextHeaderID:  manifest.NextHeaderFunctionID,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+			viper.Set(FlagDeployment, path)
+			for flag, value := range test.flags {
+				viper.Set(flag, value)
+			}
+
+			config, err := parseFlags(true)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantContract, config.TargetContractAddress)
+			assert.Equal(t, test.wantGateway, config.TargetChainGateway)
+			assert.Equal(t, test.wantHeaderRangeID, config.HeaderRangeFunctionID)
+			assert.Equal(t, test.wantNextHeaderID, config.NextHeaderFunctionID)
+		})
+	}
+}
+
+func TestValidateBasicsFilterRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		filterRange int64
+		wantErr     string
+	}{
+		{name: "positive", filterRange: 5000},
+		{name: "zero", filterRange: 0, wantErr: "the filter range should be positive"},
+		{name: "negative", filterRange: -1, wantErr: "the filter range should be positive"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{
+				SourceContractAddress: "0x1000000000000000000000000000000000000001",
+				TargetContractAddress: "0x2000000000000000000000000000000000000002",
+				TargetChainGateway:    "0x3000000000000000000000000000000000000003",
+				FilterRange:           test.filterRange,
+				Sender:                SenderDirect,
+				Tracing:               tracing.Config{Exporter: tracing.ExporterNone},
+			}
+			err := config.ValidateBasics()
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// contracts the connections to the source and target BlobstreamX contracts, and to the transactions signer
+// and sender, shared by the replay commands.
+type contracts struct {
+	// sourceEVMClient the source chain client. Nil when replaying from a proofs archive, along with the source
+	// contract reader.
+	sourceEVMClient        *ethclient.Client
+	targetEVMClient        *ethclient.Client
+	sourceBlobstreamReader *blobstreamxwrapper.BlobstreamXCaller
+	targetBlobstreamReader *blobstreamxwrapper.BlobstreamXCaller
+	// signer the replay signer. Nil if the transactions are signed by the relayer, or only prepared.
+	signer      signer.Signer
+	sender      replay.TxSender
+	closeSigner func()
+	closeSender func()
+}
+
+// dialContracts connects to the source and target BlobstreamX contracts, and to the transactions signer
+// and sender.
+func dialContracts(ctx context.Context, tape rpcrecord.Tape, config Config) (*contracts, error) {
+	var sourceEVMClient *ethclient.Client
+	var sourceBlobstreamReader *blobstreamxwrapper.BlobstreamXCaller
+	closeSource := func() {}
+	if config.Archive == nil {
+		// connecting to the source BlobstreamX contract
+		var err error
+		sourceEVMClient, err = cmdutil.DialEVMClient(ctx, tape, "source-evm", config.SourceEVMRPC)
+		if err != nil {
+			return nil, err
+		}
+		closeSource = sourceEVMClient.Close
+
+		sourceBlobstreamReader, err = blobstreamxwrapper.NewBlobstreamXCaller(
+			ethcmn.HexToAddress(config.SourceContractAddress),
+			sourceEVMClient,
+		)
+		if err != nil {
+			closeSource()
+			return nil, err
+		}
+	}
+
+	// connecting to the target BlobstreamX contract
+	targetEVMClient, err := cmdutil.DialEVMClient(ctx, tape, "target-evm", config.TargetEVMRPC)
+	if err != nil {
+		closeSource()
+		return nil, err
+	}
+
+	targetBlobstreamReader, err := blobstreamxwrapper.NewBlobstreamXCaller(
+		ethcmn.HexToAddress(config.TargetContractAddress),
+		targetEVMClient,
+	)
+	if err != nil {
+		closeSource()
+		targetEVMClient.Close()
+		return nil, err
+	}
+
+	txSigner, closeSigner, err := config.Signer.NewSigner(ctx)
+	if err != nil {
+		closeSource()
+		targetEVMClient.Close()
+		return nil, err
+	}
+
+	sender, closeSender, err := newSender(ctx, tape, config, txSigner, targetEVMClient)
+	if err != nil {
+		closeSource()
+		targetEVMClient.Close()
+		closeSigner()
+		return nil, err
+	}
+
+	return &contracts{
+		sourceEVMClient:        sourceEVMClient,
+		targetEVMClient:        targetEVMClient,
+		sourceBlobstreamReader: sourceBlobstreamReader,
+		targetBlobstreamReader: targetBlobstreamReader,
+		signer:                 txSigner,
+		sender:                 sender,
+		closeSigner:            closeSigner,
+		closeSender:            closeSender,
+	}, nil
+}
+
+// Close closes the EVM clients, and the signer and sender connections.
+func (c *contracts) Close() {
+	if c.sourceEVMClient != nil {
+		c.sourceEVMClient.Close()
+	}
+	c.targetEVMClient.Close()
+	c.closeSigner()
+	c.closeSender()
+}
+
+// replayConfig creates the replayer configuration. The source chain gateway is read from the source
+// contract if not set, and the function IDs mapping is resolved using both contracts.
+func (c *contracts) replayConfig(ctx context.Context, logger tmlog.Logger, config Config) (replay.Config, error) {
+	sourceChainGateway := config.SourceChainGateway
+	if sourceChainGateway == "" {
+		gateway, err := c.sourceBlobstreamReader.Gateway(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return replay.Config{}, err
+		}
+		sourceChainGateway = gateway.Hex()
+		logger.Info("found source chain gateway", "address", sourceChainGateway)
+	}
+
+	sourceHeaderRange, sourceNextHeader, err := c.sourceFunctionIDs(ctx, config)
+	if err != nil {
+		return replay.Config{}, err
+	}
+	functionIDs, err := resolveFunctionIDs(ctx, logger, config, sourceHeaderRange, sourceNextHeader, c.targetBlobstreamReader)
+	if err != nil {
+		return replay.Config{}, err
+	}
+
+	return replay.Config{
+		Verify:                          config.Verify,
+		CoreRPC:                         config.CoreRPC,
+		SourceBlobstreamContractAddress: config.SourceContractAddress,
+		TargetBlobstreamContractAddress: config.TargetContractAddress,
+		TargetChainGatewayAddress:       config.TargetChainGateway,
+		SourceChainGatewayAddress:       sourceChainGateway,
+		Signer:                          c.signer,
+		Sender:                          c.sender,
+		FunctionIDs:                     functionIDs,
+		Circuits:                        resolveCircuits(config, sourceHeaderRange, sourceNextHeader),
+		FilterRange:                     config.FilterRange,
+		Archive:                         config.Archive,
+		ProofVerifier:                   config.ProofVerifier,
+		LagAlertThreshold:               config.LagAlertThreshold,
+		MinSignerBalance:                config.MinSignerBalance,
+		MaxSubmissionFailures:           config.MaxSubmissionFailures,
+	}, nil
+}
+
+// sourceFunctionIDs returns the source header range and next header function IDs, read from the source
+// contract, or from the proofs archive.
+func (c *contracts) sourceFunctionIDs(ctx context.Context, config Config) ([32]byte, [32]byte, error) {
+	if config.Archive != nil {
+		return config.Archive.HeaderRangeFunctionID, config.Archive.NextHeaderFunctionID, nil
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	headerRange, err := c.sourceBlobstreamReader.HeaderRangeFunctionId(opts)
+	if err != nil {
+		return [32]byte{}, [32]byte{}, err
+	}
+	nextHeader, err := c.sourceBlobstreamReader.NextHeaderFunctionId(opts)
+	if err != nil {
+		return [32]byte{}, [32]byte{}, err
+	}
+	return headerRange, nextHeader, nil
+}
@@ -0,0 +1,118 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// resolveFunctionIDs builds the table mapping the source function IDs to the target ones. The source
+// header range and next header function IDs are mapped to the target ones, which are read from the target
+// contract unless set using the flags. The explicitly configured mappings take precedence.
+func resolveFunctionIDs(
+	ctx context.Context,
+	logger tmlog.Logger,
+	config Config,
+	sourceHeaderRange [32]byte,
+	sourceNextHeader [32]byte,
+	targetBlobstreamReader *blobstreamxwrapper.BlobstreamXCaller,
+) (map[[32]byte][32]byte, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	var err error
+	targetHeaderRange := config.HeaderRangeFunctionID
+	if targetHeaderRange == [32]byte{} {
+		targetHeaderRange, err = targetBlobstreamReader.HeaderRangeFunctionId(opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	targetNextHeader := config.NextHeaderFunctionID
+	if targetNextHeader == [32]byte{} {
+		targetNextHeader, err = targetBlobstreamReader.NextHeaderFunctionId(opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	functionIDs := make(map[[32]byte][32]byte, len(config.FunctionIDs)+2)
+	for source, target := range map[[32]byte][32]byte{
+		sourceHeaderRange: targetHeaderRange,
+		sourceNextHeader:  targetNextHeader,
+	} {
+		if source == [32]byte{} {
+			continue
+		}
+		if target == [32]byte{} {
+			return nil, fmt.Errorf("the target function ID for the source function ID %s is not set", ethcmn.Hash(source).Hex())
+		}
+		functionIDs[source] = target
+	}
+	for source, target := range config.FunctionIDs {
+		functionIDs[source] = target
+	}
+	if len(functionIDs) == 0 {
+		return nil, fmt.Errorf("no function ID mapping found, please set them using --%s", FlagFunctionIDs)
+	}
+
+	for source, target := range functionIDs {
+		logger.Info("function ID mapping", "source", ethcmn.Hash(source).Hex(), "target", ethcmn.Hash(target).Hex())
+	}
+	return functionIDs, nil
+}
+
+// parseFunctionIDs parses a list of <source function ID>=<target function ID>[:<encoding>] entries, into the
+// function IDs mapping and the encodings of the circuits input and output, keyed by the source function IDs.
+// The encoding of the entries without one is detected from their input length.
+func parseFunctionIDs(entries []string) (map[[32]byte][32]byte, map[[32]byte]replay.CircuitEncoding, error) {
+	mappings := make([]string, 0, len(entries))
+	rawEncodings := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		mapping, rawEncoding, ok := strings.Cut(entry, ":")
+		mappings = append(mappings, mapping)
+		if ok {
+			rawEncodings[mapping] = rawEncoding
+		}
+	}
+	functionIDs, err := fulfillcall.ParseFunctionIDMapping(mappings)
+	if err != nil {
+		return nil, nil, err
+	}
+	encodings := make(map[[32]byte]replay.CircuitEncoding, len(rawEncodings))
+	for mapping, rawEncoding := range rawEncodings {
+		encoding, err := replay.ParseCircuitEncoding(rawEncoding)
+		if err != nil {
+			return nil, nil, err
+		}
+		rawSource, _, _ := strings.Cut(mapping, "=")
+		source, err := fulfillcall.ParseFunctionID(rawSource)
+		if err != nil {
+			return nil, nil, err
+		}
+		encodings[source] = encoding
+	}
+	return functionIDs, encodings, nil
+}
+
+// resolveCircuits builds the table of the circuits input and output encodings, keyed by the source function
+// IDs. The source header range and next header circuits are set by default, the explicitly configured
+// encodings take precedence.
+func resolveCircuits(config Config, sourceHeaderRange [32]byte, sourceNextHeader [32]byte) map[[32]byte]replay.CircuitEncoding {
+	circuits := make(map[[32]byte]replay.CircuitEncoding, len(config.Circuits)+2)
+	if sourceHeaderRange != [32]byte{} {
+		circuits[sourceHeaderRange] = replay.CircuitHeaderRange
+	}
+	if sourceNextHeader != [32]byte{} {
+		circuits[sourceNextHeader] = replay.CircuitNextHeader
+	}
+	for source, encoding := range config.Circuits {
+		circuits[source] = encoding
+	}
+	return circuits
+}
@@ -0,0 +1,48 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// newGuardian creates the guardian, connects to its signer and starts its trusted core endpoints clients.
+// The returned function stops them and closes the signer connection.
+func newGuardian(ctx context.Context, logger tmlog.Logger, tape rpcrecord.Tape, config Config) (*replay.Guardian, func(), error) {
+	guardianSigner, closeSigner, err := config.GuardianSigner.NewSigner(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var endpoints []*tmhttp.HTTP
+	stop := func() {
+		defer closeSigner()
+		for _, trpc := range endpoints {
+			if !trpc.IsRunning() {
+				continue
+			}
+			err := trpc.Stop()
+			if err != nil {
+				logger.Error("error stopping guardian tendermint RPC", "err", err.Error())
+			}
+		}
+	}
+	for i, coreRPC := range config.GuardianCoreRPCs {
+		trpc, err := cmdutil.StartTendermintRPC(tape, fmt.Sprintf("guardian-core-%d", i), coreRPC)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		endpoints = append(endpoints, trpc)
+	}
+	guardian, err := replay.NewGuardian(guardianSigner, endpoints, config.GuardianQuorum)
+	if err != nil {
+		stop()
+		return nil, nil, err
+	}
+	return guardian, stop, nil
+}
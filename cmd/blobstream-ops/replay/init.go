@@ -0,0 +1,145 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+const (
+	FlagInitHeight   = "height"
+	FlagInitGuardian = "guardian"
+)
+
+// InitCommand the replay init command. It bootstraps the target contract from a source proof start block.
+func InitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "init",
+		Short:        "Bootstraps a target BlobstreamX contract from a source proof start block",
+		Long:         "initializes the target BlobstreamX contract, or updates its genesis state, using the header hash stored in the source BlobstreamX contract at the start block of a source proof, so that the replay can start from it",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseFlags(false)
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+			if config.Archive != nil {
+				return fmt.Errorf("the target contract can't be initialized from a proofs archive: flag --%s", FlagSource)
+			}
+
+			height := viper.GetUint64(FlagInitHeight)
+
+			guardian := config.Signer.Address()
+			if rawGuardian := viper.GetString(FlagInitGuardian); rawGuardian != "" {
+				if err := ValidateEVMAddress(rawGuardian); err != nil {
+					return fmt.Errorf("%s: flag --%s or environment variable %s", err.Error(), FlagInitGuardian, cmdutil.ToEnvVariableFormat(FlagInitGuardian))
+				}
+				guardian = ethcmn.HexToAddress(rawGuardian)
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			tape, err := rpcrecord.New(config.RecordRPC, config.PlaybackRPC)
+			if err != nil {
+				return err
+			}
+			defer func(tape rpcrecord.Tape) {
+				err := tape.Close()
+				if err != nil {
+					logger.Error("error closing the RPC tape", "err", err.Error())
+				}
+			}(tape)
+
+			contracts, err := dialContracts(ctx, tape, config)
+			if err != nil {
+				return err
+			}
+			defer contracts.Close()
+			if contracts.signer == nil {
+				return fmt.Errorf("the target contract can't be initialized through the relayer. Please set a signer using --%s, --%s, --%s or --%s", FlagEVMPrivateKey, FlagEVMKeystore, FlagEVMMnemonic, FlagEVMRemoteSigner)
+			}
+
+			var trpc *tmhttp.HTTP
+			if config.Verify {
+				trpc, err = cmdutil.StartTendermintRPC(tape, "core", config.CoreRPC)
+				if err != nil {
+					return err
+				}
+				defer func(trpc *tmhttp.HTTP) {
+					if !trpc.IsRunning() {
+						return
+					}
+					err := trpc.Stop()
+					if err != nil {
+						logger.Error("error stopping tendermint RPC", "err", err.Error())
+					}
+				}(trpc)
+			}
+
+			// the function IDs can't be resolved before the target contract is initialized
+			initConfig := replay.Config{
+				SourceBlobstreamContractAddress: config.SourceContractAddress,
+				TargetBlobstreamContractAddress: config.TargetContractAddress,
+				TargetChainGatewayAddress:       config.TargetChainGateway,
+				Signer:                          contracts.signer,
+				FilterRange:                     config.FilterRange,
+			}
+
+			genesis, err := replay.FindGenesis(ctx, logger, initConfig, contracts.sourceEVMClient, trpc, height)
+			if err != nil {
+				return err
+			}
+
+			receipt, err := replay.InitializeTarget(ctx, logger, initConfig, contracts.targetEVMClient, genesis, replay.InitParams{
+				Guardian:              guardian,
+				HeaderRangeFunctionID: config.HeaderRangeFunctionID,
+				NextHeaderFunctionID:  config.NextHeaderFunctionID,
+			})
+			if err != nil {
+				return err
+			}
+			logger.Info("target contract bootstrapped", "height", genesis.Height, "tx_hash", receipt.TxHash.Hex())
+
+			replayConfig, err := contracts.replayConfig(ctx, logger, config)
+			if err != nil {
+				return err
+			}
+			return runPreflight(ctx, logger, replayConfig, config.Preflight, contracts)
+		},
+	}
+
+	cmd.Flags().Uint64(
+		FlagInitHeight,
+		0,
+		fmt.Sprintf("Specify the start block of the source proof to bootstrap the target contract from. If not set, the latest source proof is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagInitHeight)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagInitHeight)
+
+	cmd.Flags().String(
+		FlagInitGuardian,
+		"",
+		fmt.Sprintf("Specify the guardian of the target contract, if it's initialized by this command. Defaults to the EVM signer address. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagInitGuardian)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagInitGuardian)
+
+	return addFlags(cmd)
+}
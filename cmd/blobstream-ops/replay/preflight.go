@@ -0,0 +1,99 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/spf13/cobra"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// PreflightCommand the replay preflight command. It checks that the proofs can be replayed to the
+// target contract without replaying them.
+func PreflightCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "preflight",
+		Short:        "Checks that a target BlobstreamX deployment is compatible with the source one",
+		Long:         "checks that the proofs of the source BlobstreamX contract can be replayed to the target BlobstreamX contract, without replaying them",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := parseFlags(false)
+			if err != nil {
+				return err
+			}
+			if err := config.ValidateBasics(); err != nil {
+				return err
+			}
+			if config.Archive != nil {
+				return fmt.Errorf("the preflight checks can't be run against a proofs archive, as they read the source chain: flag --%s", FlagSource)
+			}
+
+			logger, err := cmdutil.GetLogger(config.LogLevel, config.LogFormat)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Listen for and trap any OS signal to graceful shutdown and exit
+			go cmdutil.TrapSignal(logger, cancel)
+
+			tape, err := rpcrecord.New(config.RecordRPC, config.PlaybackRPC)
+			if err != nil {
+				return err
+			}
+			defer func(tape rpcrecord.Tape) {
+				err := tape.Close()
+				if err != nil {
+					logger.Error("error closing the RPC tape", "err", err.Error())
+				}
+			}(tape)
+
+			contracts, err := dialContracts(ctx, tape, config)
+			if err != nil {
+				return err
+			}
+			defer contracts.Close()
+
+			replayConfig, err := contracts.replayConfig(ctx, logger, config)
+			if err != nil {
+				return err
+			}
+
+			return runPreflight(ctx, logger, replayConfig, config.Preflight, contracts)
+		},
+	}
+
+	return addFlags(cmd)
+}
+
+// runPreflight runs the preflight checks and logs their results. It returns an error if any of them failed.
+func runPreflight(
+	ctx context.Context,
+	logger tmlog.Logger,
+	replayConfig replay.Config,
+	preflightConfig replay.PreflightConfig,
+	contracts *contracts,
+) error {
+	logger.Info("running the preflight checks")
+	report, err := replay.Preflight(ctx, logger, replayConfig, preflightConfig, contracts.sourceEVMClient, contracts.targetEVMClient)
+	if err != nil {
+		return fmt.Errorf("couldn't run the preflight checks: %w", err)
+	}
+	for _, check := range report.Checks {
+		if check.Passed {
+			logger.Info("preflight check passed", "check", check.Name, "details", check.Details)
+		} else {
+			logger.Error("preflight check failed", "check", check.Name, "details", check.Details)
+		}
+	}
+	if failed := report.Failed(); len(failed) != 0 {
+		return fmt.Errorf("%d preflight checks failed, the first one: %s: %s", len(failed), failed[0].Name, failed[0].Details)
+	}
+	logger.Info("all preflight checks passed")
+	return nil
+}
@@ -0,0 +1,38 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// newSender creates the sender of the proof submission transactions. It returns a nil sender if there's no
+// signer and the transactions aren't sent by the relayer, i.e. they're only prepared. The returned function
+// closes the connection to the private transactions endpoint.
+func newSender(
+	ctx context.Context,
+	tape rpcrecord.Tape,
+	config Config,
+	txSigner signer.Signer,
+	targetEVMClient *ethclient.Client,
+) (replay.TxSender, func(), error) {
+	if txSigner == nil && config.Sender != SenderRelayer {
+		return nil, func() {}, nil
+	}
+	switch config.Sender {
+	case SenderRelayer:
+		return replay.NewRelayerSender(config.RelayerURL, config.RelayerToken, config.RelayerAddress), func() {}, nil
+	case SenderPrivate:
+		privateClient, err := cmdutil.DialEVMClient(ctx, tape, "private-evm", config.PrivateRPC)
+		if err != nil {
+			return nil, nil, err
+		}
+		return replay.NewPrivateSender(txSigner, targetEVMClient, privateClient), privateClient.Close, nil
+	default:
+		return replay.NewDirectSender(txSigner, targetEVMClient), func() {}, nil
+	}
+}
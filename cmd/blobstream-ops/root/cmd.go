@@ -1,7 +1,12 @@
 package root
 
 import (
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/admin"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/audit"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/buildmeta"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/devnet"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/replay"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/verify"
 	"github.com/spf13/cobra"
@@ -11,15 +16,20 @@ import (
 // main function.
 func Cmd() *cobra.Command {
 	rootCmd := &cobra.Command{
-		Use:          "blobstream-ops",
-		Short:        "The Blobstream OPS CLI",
-		SilenceUsage: true,
+		Use:               "blobstream-ops",
+		Short:             "The Blobstream OPS CLI",
+		SilenceUsage:      true,
+		PersistentPreRunE: cmdutil.BindExecutedCommandFlags,
 	}
 
 	rootCmd.AddCommand(
 		buildmeta.Cmd,
 		verify.Command(),
 		replay.Command(),
+		devnet.Command(),
+		audit.Command(),
+		deploy.Command(),
+		admin.Command(),
 	)
 
 	rootCmd.SetHelpCommand(&cobra.Command{})
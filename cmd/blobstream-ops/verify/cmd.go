@@ -3,16 +3,24 @@ package verify
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
+	"time"
 
+	"github.com/celestiaorg/blobstream-ops/auditlog"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/buildmeta"
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/tracing"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcmn "github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/spf13/cobra"
 	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
 	"github.com/tendermint/tendermint/rpc/client/http"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Command the verify command
@@ -61,8 +69,70 @@ func VerifyContractCommand() *cobra.Command {
 			ctx, cancel := context.WithCancel(cmd.Context())
 			defer cancel()
 
+			shutdownTracing, err := tracing.Setup(ctx, "blobstream-ops-verify", buildInfo.SemanticVersion, config.Tracing)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				err := shutdownTracing(shutdownCtx)
+				if err != nil {
+					logger.Error("error shutting down tracing", "err", err.Error())
+				}
+			}()
+			ctx, span := tracing.Start(ctx, "verify.contract", attribute.String("evm.contract_address", config.ContractAddress))
+			defer span.End()
+
+			tape, err := rpcrecord.New(config.RecordRPC, config.PlaybackRPC)
+			if err != nil {
+				return err
+			}
+			defer func(tape rpcrecord.Tape) {
+				err := tape.Close()
+				if err != nil {
+					logger.Error("error closing the RPC tape", "err", err.Error())
+				}
+			}(tape)
+
+			// the alerts are only sent once per run
+			notifier, err := notify.New(logger, config.Webhooks, 0)
+			if err != nil {
+				return err
+			}
+
+			var auditLog *auditlog.Log
+			if config.AuditLog != "" {
+				auditLog, err = auditlog.Open(config.AuditLog, config.AuditPrivateKey)
+				if err != nil {
+					return err
+				}
+				defer func(auditLog *auditlog.Log) {
+					err := auditLog.Close()
+					if err != nil {
+						logger.Error("error closing the audit log", "err", err.Error())
+					}
+				}(auditLog)
+			}
+
+			registry := metrics.NewRegistry()
+			verifyMetrics := metrics.NewVerify(registry)
+			if config.MetricsListen != "" {
+				go func() {
+					err := cmdutil.ServeHTTP(ctx, logger, config.MetricsListen, metrics.Handler(registry))
+					if err != nil {
+						logger.Error("metrics server stopped", "err", err.Error())
+						cancel()
+					}
+				}()
+			}
+			scanStart := time.Now()
+			defer func() {
+				verifyMetrics.ScanDuration.Set(time.Since(scanStart).Seconds())
+			}()
+
 			// connecting to a BlobstreamX contract
-			evmClient, err := ethclient.Dial(config.EVMRPC)
+			evmClient, err := cmdutil.DialEVMClient(ctx, tape, "evm", config.EVMRPC)
 			if err != nil {
 				return err
 			}
@@ -91,27 +161,78 @@ func VerifyContractCommand() *cobra.Command {
 
 			latestNonce, err := blobstreamReader.StateProofNonce(&bind.CallOpts{})
 			if err != nil {
+				verifyMetrics.RPCErrors.Inc()
 				return err
 			}
 
 			logger.Info("found latest blobstreamX contract nonce", "nonce", latestNonce.Int64())
 
+			var extractor *fulfillcall.Extractor
+			if config.ProofVerifier != nil {
+				gateway := ethcmn.HexToAddress(config.Gateway)
+				if config.Gateway == "" {
+					gateway, err = blobstreamReader.Gateway(&bind.CallOpts{Context: ctx})
+					if err != nil {
+						verifyMetrics.RPCErrors.Inc()
+						return err
+					}
+					logger.Info("found gateway", "address", gateway.Hex())
+				}
+				extractor, err = fulfillcall.NewExtractor(gateway, evmClient.Client())
+				if err != nil {
+					return err
+				}
+			}
+
 			evmChainTip, err := evmClient.BlockNumber(ctx)
 			if err != nil {
+				verifyMetrics.RPCErrors.Inc()
 				return err
 			}
 
 			logger.Debug("evm chain latest block number", "number", evmChainTip)
 
+			var chainID string
+			if auditLog != nil {
+				evmChainID, err := evmClient.ChainID(ctx)
+				if err != nil {
+					verifyMetrics.RPCErrors.Inc()
+					return err
+				}
+				chainID = evmChainID.String()
+			}
+			// appendAuditEntry appends the verification result of the event to the audit log, if enabled
+			appendAuditEntry := func(kind string, event blobstreamxwrapper.BlobstreamXDataCommitmentStored, trustedDataCommitment []byte) {
+				if auditLog == nil {
+					return
+				}
+				err := auditLog.Append(auditlog.Entry{
+					Kind:                  kind,
+					ChainID:               chainID,
+					Contract:              config.ContractAddress,
+					ProofNonce:            event.ProofNonce.Int64(),
+					StartBlock:            event.StartBlock,
+					EndBlock:              event.EndBlock,
+					DataCommitment:        hex.EncodeToString(event.DataCommitment[:]),
+					TrustedDataCommitment: hex.EncodeToString(trustedDataCommitment),
+					TrustedEndpoint:       config.CoreRPC,
+					SourceTxHash:          event.Raw.TxHash.Hex(),
+				})
+				if err != nil {
+					logger.Error("couldn't append to the audit log", "kind", kind, "nonce", event.ProofNonce, "err", err.Error())
+				}
+			}
+
+			scanCtx, scanSpan := tracing.Start(ctx, "verify.scan_events")
 			maxFilterRange := int64(5000)
 			dataCommitmentEvents := make(map[int]blobstreamxwrapper.BlobstreamXDataCommitmentStored)
 			for eventLookupEnd := int64(evmChainTip); eventLookupEnd > 0; eventLookupEnd -= maxFilterRange {
 				logger.Debug("querying all the data commitment stored events", "evm_block_start", eventLookupEnd, "evm_block_end", eventLookupEnd-maxFilterRange)
-				rangeStart := eventLookupEnd - maxFilterRange
+				rangeStart := max(eventLookupEnd-maxFilterRange, 0)
 				rangeEnd := uint64(eventLookupEnd)
 				events, err := blobstreamLogFilterer.FilterDataCommitmentStored(
 					&bind.FilterOpts{
-						Context: ctx,
+						Context: scanCtx,
 						Start:   uint64(rangeStart),
 						End:     &rangeEnd,
 					},
@@ -120,16 +241,17 @@ func VerifyContractCommand() *cobra.Command {
 					nil,
 				)
 				if err != nil {
+					verifyMetrics.RPCErrors.Inc()
+					tracing.End(scanSpan, err)
 					return err
 				}
 
 				for {
+					// the events of the blocks shared by two consecutive ranges are returned twice
 					if events.Event != nil {
-						_, exists := dataCommitmentEvents[int(events.Event.ProofNonce.Int64())]
-						if exists {
-							continue
+						if _, exists := dataCommitmentEvents[int(events.Event.ProofNonce.Int64())]; !exists {
+							dataCommitmentEvents[int(events.Event.ProofNonce.Int64())] = *events.Event
 						}
-						dataCommitmentEvents[int(events.Event.ProofNonce.Int64())] = *events.Event
 					}
 					if !events.Next() {
 						break
@@ -142,16 +264,16 @@ func VerifyContractCommand() *cobra.Command {
 				}
 				logger.Info("found events", "count", len(dataCommitmentEvents))
 			}
+			tracing.End(scanSpan, nil)
 
-			trpc, err := http.New(config.CoreRPC, "/websocket")
-			if err != nil {
-				return err
-			}
-			err = trpc.Start()
+			trpc, err := cmdutil.StartTendermintRPC(tape, "core", config.CoreRPC)
 			if err != nil {
 				return err
 			}
 			defer func(trpc *http.HTTP) {
+				if !trpc.IsRunning() {
+					return
+				}
 				err := trpc.Stop()
 				if err != nil {
 					logger.Error("error stopping tendermint RPC", "err", err.Error())
@@ -164,15 +286,60 @@ func VerifyContractCommand() *cobra.Command {
 					return fmt.Errorf("couldn't find nonce %d in events", nonce)
 				}
 				logger.Info("verifying data root tuple root", "nonce", event.ProofNonce, "start_block", event.StartBlock, "end_block", event.EndBlock)
-				coreDataCommitment, err := trpc.DataCommitment(ctx, event.StartBlock, event.EndBlock)
+				verifyCtx, verifySpan := tracing.Start(
+					ctx,
+					"verify.data_commitment",
+					tracing.AttributeNonce.Int64(event.ProofNonce.Int64()),
+					tracing.AttributeStartBlock.Int64(int64(event.StartBlock)),
+					tracing.AttributeEndBlock.Int64(int64(event.EndBlock)),
+					tracing.AttributeTxHash.String(event.Raw.TxHash.Hex()),
+				)
+				coreDataCommitment, err := trpc.DataCommitment(verifyCtx, event.StartBlock, event.EndBlock)
 				if err != nil {
+					verifyMetrics.RPCErrors.Inc()
+					tracing.End(verifySpan, err)
 					return err
 				}
 				if bytes.Equal(coreDataCommitment.DataCommitment.Bytes(), event.DataCommitment[:]) {
+					verifyMetrics.CommitmentsVerified.Inc()
+					appendAuditEntry(auditlog.KindVerified, event, coreDataCommitment.DataCommitment.Bytes())
 					logger.Info("data commitment matches")
+					if config.ProofVerifier != nil {
+						err := verifyProof(verifyCtx, evmClient, extractor, config.ProofVerifier, event)
+						if err != nil {
+							verifyMetrics.InvalidProofs.Inc()
+							logger.Error("proof verification failed!! quitting", "nonce", event.ProofNonce, "tx_hash", event.Raw.TxHash.Hex(), "err", err.Error())
+							tracing.End(verifySpan, err)
+							return err
+						}
+						verifyMetrics.ProofsVerified.Inc()
+						logger.Info("proof verified off-chain")
+					}
+					tracing.End(verifySpan, nil)
 				} else {
+					verifyMetrics.Mismatches.Inc()
+					appendAuditEntry(auditlog.KindMismatch, event, coreDataCommitment.DataCommitment.Bytes())
 					logger.Error("data commitment mismatch!! quitting", "nonce", event.ProofNonce)
-					return fmt.Errorf("data commitment mistmatch. nonce %d", event.ProofNonce)
+					alertErr := notifier.Notify(ctx, notify.Alert{
+						Kind:     notify.KindMismatch,
+						Severity: notify.SeverityCritical,
+						Summary:  fmt.Sprintf("data commitment mismatch for the contract proof nonce %d", event.ProofNonce.Int64()),
+						Details: map[string]string{
+							"contract":                 config.ContractAddress,
+							"proof_nonce":              event.ProofNonce.String(),
+							"start_block":              fmt.Sprint(event.StartBlock),
+							"end_block":                fmt.Sprint(event.EndBlock),
+							"expected_data_commitment": hex.EncodeToString(coreDataCommitment.DataCommitment.Bytes()),
+							"actual_data_commitment":   hex.EncodeToString(event.DataCommitment[:]),
+						},
+						DedupKey: fmt.Sprintf("%s-%s-%d", notify.KindMismatch, config.ContractAddress, event.ProofNonce.Int64()),
+					})
+					if alertErr != nil {
+						logger.Error("couldn't send alert", "err", alertErr.Error())
+					}
+					err := fmt.Errorf("data commitment mistmatch. nonce %d", event.ProofNonce)
+					tracing.End(verifySpan, err)
+					return err
 				}
 			}
 			logger.Info("blobstreamX contract verified")
@@ -0,0 +1,110 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+var record = flag.Bool("record", false, "record the fixture tape against a simulated chain before playing it back")
+
+// TestVerifyContractPlayback verifies a contract from a recorded tape, and fails on a tampered core data commitment.
+// Run with -record to record the tape again against a simulated chain.
+func TestVerifyContractPlayback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	dir := filepath.Join("testdata", "contract")
+	if *record {
+		recordVerifyContract(ctx, t, dir)
+	}
+	manifest, err := deploy.LoadManifest(filepath.Join(dir, "deployment.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		// tamper modifies the played back tape
+		tamper  func(t *testing.T, dir string)
+		wantErr string
+	}{
+		{
+			name: "valid contract",
+		},
+		{
+			name: "tampered core data commitment",
+			tamper: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "core.jsonl")
+				cassette, err := os.ReadFile(path)
+				require.NoError(t, err)
+				commitment, tampered := simchain.DataCommitment(simchain.GenesisHeight+10, simchain.GenesisHeight+20), [32]byte{1}
+				// the data commitments are encoded in upper case hex
+				original := []byte(strings.ToUpper(hex.EncodeToString(commitment[:])))
+				require.True(t, bytes.Contains(cassette, original))
+				cassette = bytes.ReplaceAll(cassette, original, []byte(strings.ToUpper(hex.EncodeToString(tampered[:]))))
+				require.NoError(t, os.WriteFile(path, cassette, 0o644))
+			},
+			wantErr: "data commitment mistmatch. nonce 2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			playbackDir := t.TempDir()
+			for _, name := range []string{"evm.jsonl", "core.jsonl"} {
+				cassette, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(filepath.Join(playbackDir, name), cassette, 0o644))
+			}
+			if test.tamper != nil {
+				test.tamper(t, playbackDir)
+			}
+
+			err := runVerifyContract(ctx, manifest, "--"+FlagPlaybackRPC, playbackDir)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// recordVerifyContract records the verify tape against a simulated chain and a fake core endpoint.
+func recordVerifyContract(ctx context.Context, t *testing.T, dir string) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chain := simchain.New(ctx, t, key)
+	core := simchain.NewCore(t)
+	chain.CommitHeaderRange(ctx, t, key, simchain.GenesisHeight, simchain.GenesisHeight+10)
+	chain.CommitHeaderRange(ctx, t, key, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, runVerifyContract(
+		ctx,
+		chain.Manifest,
+		"--"+FlagEVMRPC, "http://"+chain.Endpoint,
+		"--"+FlagCoreRPC, core.URL,
+		"--"+FlagRecordRPC, dir,
+	))
+	require.NoError(t, chain.Manifest.Write(filepath.Join(dir, "deployment.json")))
+}
+
+// runVerifyContract runs the contract verifier of the deployment with the provided flags.
+func runVerifyContract(ctx context.Context, manifest deploy.Manifest, args ...string) error {
+	cmd := VerifyContractCommand()
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	cmd.SetArgs(append(args,
+		"--"+FlagEVMContractAddress, manifest.BlobstreamX.Hex(),
+		"--"+FlagLogLevel, "error",
+	))
+	return cmd.ExecuteContext(ctx)
+}
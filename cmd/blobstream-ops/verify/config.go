@@ -1,11 +1,17 @@
 package verify
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/celestiaorg/blobstream-ops/cmd/blobstream-ops/cmdutil"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/proofverify"
+	"github.com/celestiaorg/blobstream-ops/tracing"
 	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -13,11 +19,32 @@ import (
 const (
 	FlagEVMRPC             = "evm.rpc"
 	FlagEVMContractAddress = "evm.contract-address"
+	FlagEVMGateway         = "evm.gateway"
 
 	FlagLogLevel  = "log.level"
 	FlagLogFormat = "log.format"
 
 	FlagCoreRPC = "core.rpc"
+
+	FlagRecordRPC   = "record-rpc"
+	FlagPlaybackRPC = "playback-rpc"
+
+	FlagMetricsListen = "metrics.listen"
+
+	FlagTracingExporter = "tracing.exporter"
+	FlagTracingEndpoint = "tracing.endpoint"
+	FlagTracingFile     = "tracing.file"
+
+	FlagNotifyWebhookURL          = "notify.webhook-url"
+	FlagNotifySlackURL            = "notify.slack-url"
+	FlagNotifyPagerDutyRoutingKey = "notify.pagerduty-routing-key"
+	FlagNotifyPagerDutyURL        = "notify.pagerduty-url"
+
+	FlagAuditLog        = "audit.log"
+	FlagAuditPrivateKey = "audit.private-key"
+
+	FlagProofVerificationKeys         = "proof-verification.keys"
+	FlagProofVerificationArtifactsDir = "proof-verification.artifacts-dir"
 )
 
 func addStartFlags(cmd *cobra.Command) *cobra.Command {
@@ -37,6 +64,13 @@ func addStartFlags(cmd *cobra.Command) *cobra.Command {
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMContractAddress)
 
+	cmd.Flags().String(
+		FlagEVMGateway,
+		"",
+		fmt.Sprintf("Specify the succinct gateway contract address the proofs are submitted to, used when verifying the proofs off-chain. If not set, it's read from the BlobstreamX contract. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagEVMGateway)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagEVMGateway)
+
 	cmd.Flags().String(
 		FlagLogLevel,
 		"info",
@@ -58,21 +92,147 @@ func addStartFlags(cmd *cobra.Command) *cobra.Command {
 	)
 	cmdutil.BindFlagAndEnvVar(cmd, FlagCoreRPC)
 
+	cmd.Flags().String(
+		FlagRecordRPC,
+		"",
+		fmt.Sprintf("Specify a directory to record all the JSON-RPC requests and responses into. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagRecordRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagRecordRPC)
+
+	cmd.Flags().String(
+		FlagPlaybackRPC,
+		"",
+		fmt.Sprintf("Specify a directory containing recorded JSON-RPC requests and responses to serve instead of connecting to the endpoints. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagPlaybackRPC)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagPlaybackRPC)
+
+	cmd.Flags().String(
+		FlagMetricsListen,
+		"",
+		fmt.Sprintf("Specify the address to expose the Prometheus metrics on, e.g. localhost:9090. If not set, the metrics are not exposed. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagMetricsListen)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagMetricsListen)
+
+	cmd.Flags().String(
+		FlagTracingExporter,
+		tracing.ExporterNone,
+		fmt.Sprintf("Specify the OpenTelemetry traces exporter: %s, %s, %s or %s. Corresponding environment variable %s", tracing.ExporterNone, tracing.ExporterOTLPGRPC, tracing.ExporterOTLPHTTP, tracing.ExporterFile, cmdutil.ToEnvVariableFormat(FlagTracingExporter)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingExporter)
+
+	cmd.Flags().String(
+		FlagTracingEndpoint,
+		"",
+		fmt.Sprintf("Specify the OTLP collector URL the traces are exported to, e.g. http://localhost:4317. If not set, the OTLP exporters default is used. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTracingEndpoint)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingEndpoint)
+
+	cmd.Flags().String(
+		FlagTracingFile,
+		"",
+		fmt.Sprintf("Specify the file the traces are written to when using the file exporter. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagTracingFile)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagTracingFile)
+
+	cmd.Flags().StringSlice(
+		FlagNotifyWebhookURL,
+		nil,
+		fmt.Sprintf("Specify the URLs the alerts are posted to as generic JSON payloads. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyWebhookURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyWebhookURL)
+
+	cmd.Flags().StringSlice(
+		FlagNotifySlackURL,
+		nil,
+		fmt.Sprintf("Specify the Slack incoming webhook URLs the alerts are posted to. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifySlackURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifySlackURL)
+
+	cmd.Flags().String(
+		FlagNotifyPagerDutyRoutingKey,
+		"",
+		fmt.Sprintf("Specify the PagerDuty Events API v2 routing key used to trigger incidents. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyPagerDutyRoutingKey)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyPagerDutyRoutingKey)
+
+	cmd.Flags().String(
+		FlagNotifyPagerDutyURL,
+		notify.DefaultPagerDutyURL,
+		fmt.Sprintf("Specify the PagerDuty Events API v2 compatible endpoint. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagNotifyPagerDutyURL)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagNotifyPagerDutyURL)
+
+	cmd.Flags().String(
+		FlagAuditLog,
+		"",
+		fmt.Sprintf("Specify the file the signed audit log entries are appended to. If not set, no audit log is kept. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAuditLog)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAuditLog)
+
+	cmd.Flags().String(
+		FlagAuditPrivateKey,
+		"",
+		fmt.Sprintf("Specify the private key, in hex format, signing the audit log entries. Required if the audit log is set. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagAuditPrivateKey)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagAuditPrivateKey)
+
+	cmd.Flags().StringSlice(
+		FlagProofVerificationKeys,
+		nil,
+		fmt.Sprintf("Specify a comma separated list of <function ID>=<path> Groth16 verifying keys, in JSON, used to verify the proofs off-chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProofVerificationKeys)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProofVerificationKeys)
+
+	cmd.Flags().String(
+		FlagProofVerificationArtifactsDir,
+		"",
+		fmt.Sprintf("Specify a directory containing a <function ID>.json Groth16 verifying key per function, used to verify the proofs off-chain. Corresponding environment variable %s", cmdutil.ToEnvVariableFormat(FlagProofVerificationArtifactsDir)),
+	)
+	cmdutil.BindFlagAndEnvVar(cmd, FlagProofVerificationArtifactsDir)
+
 	return cmd
 }
 
 type StartConfig struct {
 	EVMRPC          string
 	ContractAddress string
+	Gateway         string
 	LogLevel        string
 	LogFormat       string
 	CoreRPC         string
+	RecordRPC       string
+	PlaybackRPC     string
+	MetricsListen   string
+	Tracing         tracing.Config
+	Webhooks        []notify.Webhook
+	AuditLog        string
+	AuditPrivateKey *ecdsa.PrivateKey
+	ProofVerifier   *proofverify.Verifier
 }
 
 func (cfg StartConfig) ValidateBasics() error {
 	if err := ValidateEVMAddress(cfg.ContractAddress); err != nil {
 		return fmt.Errorf("%s: flag --%s", err.Error(), FlagEVMContractAddress)
 	}
+	if cfg.Gateway != "" {
+		if err := ValidateEVMAddress(cfg.Gateway); err != nil {
+			return fmt.Errorf("%s: flag --%s", err.Error(), FlagEVMGateway)
+		}
+	}
+	if cfg.RecordRPC != "" && cfg.PlaybackRPC != "" {
+		return fmt.Errorf("flags --%s and --%s cannot be set at the same time", FlagRecordRPC, FlagPlaybackRPC)
+	}
+	if err := cfg.Tracing.ValidateBasics(); err != nil {
+		return fmt.Errorf("%s: flags --%s and --%s", err.Error(), FlagTracingExporter, FlagTracingFile)
+	}
+	for _, webhook := range cfg.Webhooks {
+		if err := webhook.ValidateBasics(); err != nil {
+			return err
+		}
+	}
+	if cfg.AuditLog != "" && cfg.AuditPrivateKey == nil {
+		return fmt.Errorf("flag --%s is set but the audit log private key flag --%s is not set", FlagAuditLog, FlagAuditPrivateKey)
+	}
 	return nil
 }
 
@@ -89,15 +249,59 @@ func ValidateEVMAddress(addr string) error {
 func parseStartFlags() (StartConfig, error) {
 	contractAddress := viper.GetString(FlagEVMContractAddress)
 	evmRPC := viper.GetString(FlagEVMRPC)
+	gateway := viper.GetString(FlagEVMGateway)
 	coreRPC := viper.GetString(FlagCoreRPC)
 	logLevel := viper.GetString(FlagLogLevel)
 	logFormat := viper.GetString(FlagLogFormat)
+	recordRPC := viper.GetString(FlagRecordRPC)
+	playbackRPC := viper.GetString(FlagPlaybackRPC)
+	metricsListen := viper.GetString(FlagMetricsListen)
+	tracingConfig := tracing.Config{
+		Exporter: viper.GetString(FlagTracingExporter),
+		Endpoint: viper.GetString(FlagTracingEndpoint),
+		File:     viper.GetString(FlagTracingFile),
+	}
+	webhooks := notify.NewWebhooks(
+		viper.GetStringSlice(FlagNotifyWebhookURL),
+		viper.GetStringSlice(FlagNotifySlackURL),
+		viper.GetString(FlagNotifyPagerDutyRoutingKey),
+		viper.GetString(FlagNotifyPagerDutyURL),
+	)
+	auditLog := viper.GetString(FlagAuditLog)
+	var auditPrivateKey *ecdsa.PrivateKey
+	if rawAuditPrivateKey := viper.GetString(FlagAuditPrivateKey); rawAuditPrivateKey != "" {
+		var err error
+		auditPrivateKey, err = crypto.HexToECDSA(strings.TrimPrefix(rawAuditPrivateKey, "0x"))
+		if err != nil {
+			return StartConfig{}, fmt.Errorf("failed to hex-decode the audit log private key: %w", err)
+		}
+	}
+	var proofVerifier *proofverify.Verifier
+	if keys, artifactsDir := viper.GetStringSlice(FlagProofVerificationKeys), viper.GetString(FlagProofVerificationArtifactsDir); len(keys) != 0 || artifactsDir != "" {
+		keyFiles, err := proofverify.ParseKeyFiles(keys)
+		if err != nil {
+			return StartConfig{}, fmt.Errorf("%s: flag --%s", err.Error(), FlagProofVerificationKeys)
+		}
+		proofVerifier, err = proofverify.LoadVerifier(artifactsDir, keyFiles)
+		if err != nil {
+			return StartConfig{}, fmt.Errorf("couldn't load the verifying keys: %w", err)
+		}
+	}
 
 	return StartConfig{
 		EVMRPC:          evmRPC,
 		ContractAddress: contractAddress,
+		Gateway:         gateway,
 		CoreRPC:         coreRPC,
 		LogLevel:        logLevel,
 		LogFormat:       logFormat,
+		RecordRPC:       recordRPC,
+		PlaybackRPC:     playbackRPC,
+		MetricsListen:   metricsListen,
+		Tracing:         tracingConfig,
+		Webhooks:        webhooks,
+		AuditLog:        auditLog,
+		AuditPrivateKey: auditPrivateKey,
+		ProofVerifier:   proofVerifier,
 	}, nil
 }
@@ -0,0 +1,38 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/proofverify"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+)
+
+// verifyProof re-verifies, off-chain, the proof submitted by the transaction that emitted the event,
+// using the verifying key of the function ID it was submitted with. If the transaction contains multiple
+// calls to the gateway, e.g. when batched, all of them need to be valid.
+func verifyProof(
+	ctx context.Context,
+	evmClient *ethclient.Client,
+	extractor *fulfillcall.Extractor,
+	verifier *proofverify.Verifier,
+	event blobstreamxwrapper.BlobstreamXDataCommitmentStored,
+) error {
+	tx, _, err := evmClient.TransactionByHash(ctx, event.Raw.TxHash)
+	if err != nil {
+		return err
+	}
+	calls, err := extractor.Extract(ctx, tx)
+	if err != nil {
+		return err
+	}
+	for _, call := range calls {
+		if err := verifier.Verify(call.FunctionID, call.Input, call.Output, call.Proof); err != nil {
+			return fmt.Errorf("function ID %s: %w", ethcmn.Hash(call.FunctionID).Hex(), err)
+		}
+	}
+	return nil
+}
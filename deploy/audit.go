@@ -0,0 +1,372 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/celestiaorg/blobstream-ops/blobstreamxext"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// AuditBackend the EVM chain an audited deployment lives on.
+type AuditBackend interface {
+	bind.ContractBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+	StorageAt(ctx context.Context, account ethcmn.Address, key ethcmn.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
+// SecurityConfig the security relevant configuration of a BlobstreamX deployment. When used as the expected
+// configuration, the nil fields are not checked.
+type SecurityConfig struct {
+	ChainID *string `json:"chain_id,omitempty"`
+	// Implementation the BlobstreamX implementation the proxy delegates to.
+	Implementation         *ethcmn.Address `json:"implementation,omitempty"`
+	ImplementationCodeHash *ethcmn.Hash    `json:"implementation_code_hash,omitempty"`
+	// Admins the default admin role holders, i.e. the owners.
+	Admins                []ethcmn.Address    `json:"admins"`
+	Guardians             []ethcmn.Address    `json:"guardians"`
+	Timelocks             []ethcmn.Address    `json:"timelocks"`
+	Frozen                *bool               `json:"frozen,omitempty"`
+	Gateway               *ethcmn.Address     `json:"gateway,omitempty"`
+	HeaderRangeFunctionID *ethcmn.Hash        `json:"header_range_function_id,omitempty"`
+	NextHeaderFunctionID  *ethcmn.Hash        `json:"next_header_function_id,omitempty"`
+	HeaderRangeVerifier   *VerifierDeployment `json:"header_range_verifier,omitempty"`
+	NextHeaderVerifier    *VerifierDeployment `json:"next_header_verifier,omitempty"`
+}
+
+// VerifierDeployment the verifier a function ID resolves to through the gateway.
+type VerifierDeployment struct {
+	Address  ethcmn.Address `json:"address"`
+	CodeHash ethcmn.Hash    `json:"code_hash"`
+}
+
+// AuditTarget a BlobstreamX deployment to audit.
+type AuditTarget struct {
+	Name string `json:"name"`
+	// RPC the EVM endpoint of the deployment chain. Environment variables are expanded, so that the
+	// endpoints API keys don't need to be written in the file.
+	RPC     string         `json:"rpc"`
+	Address ethcmn.Address `json:"address"`
+	// FromBlock the block the role grants and revocations are queried from, e.g. the deployment block.
+	FromBlock uint64 `json:"from_block"`
+	// Expected the expected configuration. If nil, the configuration is only reported.
+	Expected *SecurityConfig `json:"expected,omitempty"`
+}
+
+// AuditTargets the deployments to audit, as read from the expected values file.
+type AuditTargets struct {
+	Deployments []AuditTarget `json:"deployments"`
+}
+
+// LoadAuditTargets reads the deployments to audit from the provided expected values file.
+func LoadAuditTargets(path string) (AuditTargets, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return AuditTargets{}, err
+	}
+	var targets AuditTargets
+	if err := json.Unmarshal(bz, &targets); err != nil {
+		return AuditTargets{}, fmt.Errorf("invalid expected values file %s: %w", path, err)
+	}
+	if len(targets.Deployments) == 0 {
+		return AuditTargets{}, fmt.Errorf("the expected values file %s has no deployment", path)
+	}
+	for i, target := range targets.Deployments {
+		if target.Address == (ethcmn.Address{}) {
+			return AuditTargets{}, fmt.Errorf("the deployment %d of %s has no address", i, path)
+		}
+		if target.RPC == "" {
+			return AuditTargets{}, fmt.Errorf("the deployment %d of %s has no rpc", i, path)
+		}
+		targets.Deployments[i].RPC = os.ExpandEnv(target.RPC)
+	}
+	return targets, nil
+}
+
+// Drift a difference between the expected and actual configuration of a deployment.
+type Drift struct {
+	Field    string `json:"field"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// AuditReport the audit of a deployment.
+type AuditReport struct {
+	Name    string         `json:"name"`
+	Address ethcmn.Address `json:"address"`
+	Actual  SecurityConfig `json:"actual"`
+	Drifts  []Drift        `json:"drifts,omitempty"`
+	// Error the error that prevented the audit, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Passed returns true if the deployment could be audited and matches the expected configuration.
+func (r AuditReport) Passed() bool {
+	return r.Error == "" && len(r.Drifts) == 0
+}
+
+// Audit reads the security relevant configuration of the BlobstreamX deployment at the provided address. The
+// role holders are found using the role grants since the provided block, queried by ranges of filterRange
+// blocks, then confirmed against the contract.
+func Audit(
+	ctx context.Context,
+	logger tmlog.Logger,
+	backend AuditBackend,
+	address ethcmn.Address,
+	fromBlock uint64,
+	filterRange int64,
+) (SecurityConfig, error) {
+	if filterRange <= 0 {
+		return SecurityConfig{}, errors.New("the filter range should be positive")
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	blobstreamX, err := blobstreamxwrapper.NewBlobstreamX(address, backend)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	ext, err := blobstreamxext.New(address, backend)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config := SecurityConfig{ChainID: ptr(chainID.String())}
+
+	slot, err := backend.StorageAt(ctx, address, ImplementationSlot, nil)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	implementation := ethcmn.BytesToAddress(slot)
+	implementationCodeHash, err := codeHash(ctx, backend, implementation)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.Implementation = &implementation
+	config.ImplementationCodeHash = &implementationCodeHash
+
+	frozen, err := ext.Frozen(opts)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.Frozen = &frozen
+
+	gateway, err := blobstreamX.Gateway(opts)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.Gateway = &gateway
+
+	headerRangeFunctionID, err := blobstreamX.HeaderRangeFunctionId(opts)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	nextHeaderFunctionID, err := blobstreamX.NextHeaderFunctionId(opts)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.HeaderRangeFunctionID = ptr(ethcmn.Hash(headerRangeFunctionID))
+	config.NextHeaderFunctionID = ptr(ethcmn.Hash(nextHeaderFunctionID))
+
+	succinctGateway, err := bindings.NewSuccinctGateway(gateway, backend)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.HeaderRangeVerifier, err = resolveVerifier(ctx, backend, succinctGateway, headerRangeFunctionID)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	config.NextHeaderVerifier, err = resolveVerifier(ctx, backend, succinctGateway, nextHeaderFunctionID)
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+
+	holders, err := roleHolders(ctx, logger, backend, blobstreamX, fromBlock, uint64(filterRange))
+	if err != nil {
+		return SecurityConfig{}, err
+	}
+	roles := []struct {
+		role    func(opts *bind.CallOpts) ([32]byte, error)
+		holders *[]ethcmn.Address
+	}{
+		{role: blobstreamX.DEFAULTADMINROLE, holders: &config.Admins},
+		{role: blobstreamX.GUARDIANROLE, holders: &config.Guardians},
+		{role: blobstreamX.TIMELOCKROLE, holders: &config.Timelocks},
+	}
+	for _, r := range roles {
+		role, err := r.role(opts)
+		if err != nil {
+			return SecurityConfig{}, err
+		}
+		// the events only tell who could hold the role, the contract tells who still does
+		*r.holders = []ethcmn.Address{}
+		for _, account := range holders[role] {
+			hasRole, err := blobstreamX.HasRole(opts, role, account)
+			if err != nil {
+				return SecurityConfig{}, err
+			}
+			if hasRole {
+				*r.holders = append(*r.holders, account)
+			}
+		}
+	}
+	return config, nil
+}
+
+// roleHolders returns, for each role, the accounts it was granted to since the provided block, sorted.
+func roleHolders(
+	ctx context.Context,
+	logger tmlog.Logger,
+	backend AuditBackend,
+	blobstreamX *blobstreamxwrapper.BlobstreamX,
+	fromBlock uint64,
+	filterRange uint64,
+) (map[[32]byte][]ethcmn.Address, error) {
+	latest, err := backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	latestBlock := latest.Number.Uint64()
+
+	granted := make(map[[32]byte]map[ethcmn.Address]struct{})
+	for start := fromBlock; start <= latestBlock; start += filterRange {
+		end := start + filterRange - 1
+		if end > latestBlock {
+			end = latestBlock
+		}
+		logger.Debug("querying the role grants", "evm_block_start", start, "evm_block_end", end)
+		it, err := blobstreamX.FilterRoleGranted(&bind.FilterOpts{Context: ctx, Start: start, End: &end}, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		for it.Next() {
+			if granted[it.Event.Role] == nil {
+				granted[it.Event.Role] = make(map[ethcmn.Address]struct{})
+			}
+			granted[it.Event.Role][it.Event.Account] = struct{}{}
+		}
+		if err := it.Error(); err != nil {
+			return nil, err
+		}
+		if err := it.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	holders := make(map[[32]byte][]ethcmn.Address, len(granted))
+	for role, accounts := range granted {
+		for account := range accounts {
+			holders[role] = append(holders[role], account)
+		}
+		sort.Slice(holders[role], func(i, j int) bool {
+			return holders[role][i].Cmp(holders[role][j]) < 0
+		})
+	}
+	return holders, nil
+}
+
+// resolveVerifier returns the verifier registered in the gateway for the provided function ID.
+func resolveVerifier(ctx context.Context, backend AuditBackend, gateway *bindings.SuccinctGateway, functionID [32]byte) (*VerifierDeployment, error) {
+	verifier, err := gateway.Verifiers(&bind.CallOpts{Context: ctx}, functionID)
+	if err != nil {
+		return nil, err
+	}
+	verifierCodeHash, err := codeHash(ctx, backend, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierDeployment{Address: verifier, CodeHash: verifierCodeHash}, nil
+}
+
+// codeHash returns the hash of the code deployed at the provided address. It's zero if there is no code.
+func codeHash(ctx context.Context, backend AuditBackend, address ethcmn.Address) (ethcmn.Hash, error) {
+	code, err := backend.CodeAt(ctx, address, nil)
+	if err != nil {
+		return ethcmn.Hash{}, err
+	}
+	if len(code) == 0 {
+		return ethcmn.Hash{}, nil
+	}
+	return crypto.Keccak256Hash(code), nil
+}
+
+// Compare returns the differences between the expected and actual configurations. The fields that are
+// nil in the expected configuration are not compared.
+func Compare(expected, actual SecurityConfig) []Drift {
+	var drifts []Drift
+	check := func(field string, expected, actual any, isSet bool) {
+		if !isSet {
+			return
+		}
+		expectedStr, actualStr := format(expected), format(actual)
+		if expectedStr != actualStr {
+			drifts = append(drifts, Drift{Field: field, Expected: expectedStr, Actual: actualStr})
+		}
+	}
+	check("chain_id", expected.ChainID, actual.ChainID, expected.ChainID != nil)
+	check("implementation", expected.Implementation, actual.Implementation, expected.Implementation != nil)
+	check("implementation_code_hash", expected.ImplementationCodeHash, actual.ImplementationCodeHash, expected.ImplementationCodeHash != nil)
+	check("admins", sortedAddresses(expected.Admins), sortedAddresses(actual.Admins), expected.Admins != nil)
+	check("guardians", sortedAddresses(expected.Guardians), sortedAddresses(actual.Guardians), expected.Guardians != nil)
+	check("timelocks", sortedAddresses(expected.Timelocks), sortedAddresses(actual.Timelocks), expected.Timelocks != nil)
+	check("frozen", expected.Frozen, actual.Frozen, expected.Frozen != nil)
+	check("gateway", expected.Gateway, actual.Gateway, expected.Gateway != nil)
+	check("header_range_function_id", expected.HeaderRangeFunctionID, actual.HeaderRangeFunctionID, expected.HeaderRangeFunctionID != nil)
+	check("next_header_function_id", expected.NextHeaderFunctionID, actual.NextHeaderFunctionID, expected.NextHeaderFunctionID != nil)
+	check("header_range_verifier", expected.HeaderRangeVerifier, actual.HeaderRangeVerifier, expected.HeaderRangeVerifier != nil)
+	check("next_header_verifier", expected.NextHeaderVerifier, actual.NextHeaderVerifier, expected.NextHeaderVerifier != nil)
+	return drifts
+}
+
+// format formats a configuration value for comparison and reporting.
+func format(value any) string {
+	switch v := value.(type) {
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *ethcmn.Address:
+		if v != nil {
+			return v.Hex()
+		}
+	case *ethcmn.Hash:
+		if v != nil {
+			return v.Hex()
+		}
+	case *bool:
+		if v != nil {
+			return fmt.Sprint(*v)
+		}
+	case *VerifierDeployment:
+		if v != nil {
+			return fmt.Sprintf("%s (code hash %s)", v.Address.Hex(), v.CodeHash.Hex())
+		}
+	case []ethcmn.Address:
+		return fmt.Sprint(v)
+	}
+	return "<nil>"
+}
+
+func sortedAddresses(addresses []ethcmn.Address) []ethcmn.Address {
+	sorted := append([]ethcmn.Address{}, addresses...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	return sorted
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
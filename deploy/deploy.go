@@ -0,0 +1,295 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// proxyABI the constructor of the ERC1967 proxy the contracts are deployed behind, if its bytecode is provided.
+const proxyABI = `[{"type":"constructor","stateMutability":"payable","inputs":[{"name":"_logic","type":"address"},{"name":"_data","type":"bytes"}]}]`
+
+// The default salts used to register the circuits verifiers in the gateway.
+var (
+	DefaultHeaderRangeSalt = crypto.Keccak256Hash([]byte("header_range"))
+	DefaultNextHeaderSalt  = crypto.Keccak256Hash([]byte("next_header"))
+)
+
+// Backend the EVM chain the stack is deployed to.
+type Backend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// Verifier a circuit verifier to register in the gateway: either an already deployed verifier, or its
+// creation bytecode, deployed by the gateway when registering it.
+type Verifier struct {
+	Address  ethcmn.Address
+	Bytecode []byte
+	// Salt the salt the function is registered with. The function ID is derived from it and the owner.
+	Salt [32]byte
+}
+
+// Config the configuration of a BlobstreamX stack deployment.
+type Config struct {
+	// Signer the deployer signer. The deployer owns the gateway and the registered functions.
+	Signer              signer.Signer
+	HeaderRangeVerifier Verifier
+	NextHeaderVerifier  Verifier
+	// Guardian the BlobstreamX guardian and timelock.
+	Guardian ethcmn.Address
+	// Prover the gateway default prover, i.e. the account allowed to fulfill the calls, e.g. the replay signer.
+	Prover ethcmn.Address
+	// FeeVault the gateway fee vault. Can be the zero address.
+	FeeVault ethcmn.Address
+	// ProxyBytecode the creation bytecode of the ERC1967 proxy the contracts are deployed behind, taking the
+	// implementation and init data as constructor arguments, e.g. the OpenZeppelin ERC1967Proxy. If nil,
+	// a minimal ERC1967 proxy is used.
+	ProxyBytecode []byte
+	GenesisHeight uint64
+	GenesisHeader [32]byte
+}
+
+// ValidateBasics validates the deployment configuration.
+func (cfg Config) ValidateBasics() error {
+	if cfg.Signer == nil {
+		return errors.New("the deployer signer is not set")
+	}
+	for name, verifier := range map[string]Verifier{"header range": cfg.HeaderRangeVerifier, "next header": cfg.NextHeaderVerifier} {
+		if (verifier.Address == ethcmn.Address{}) == (len(verifier.Bytecode) == 0) {
+			return fmt.Errorf("either the %s verifier address or bytecode should be set", name)
+		}
+	}
+	if cfg.GenesisHeight == 0 || cfg.GenesisHeader == [32]byte{} {
+		return errors.New("the genesis height and header should be set")
+	}
+	return nil
+}
+
+// deployer deploys the contracts and waits for their transactions to be included.
+type deployer struct {
+	logger  tmlog.Logger
+	backend Backend
+	signer  signer.Signer
+	chainID *big.Int
+	address ethcmn.Address
+}
+
+// Deploy deploys the succinct gateway, registers the header range and next header verifiers, then deploys
+// and initializes a BlobstreamX contract using them. It returns the manifest of the deployment.
+func Deploy(ctx context.Context, logger tmlog.Logger, backend Backend, config Config) (Manifest, error) {
+	if err := config.ValidateBasics(); err != nil {
+		return Manifest{}, err
+	}
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return Manifest{}, err
+	}
+	d := &deployer{
+		logger:  logger,
+		backend: backend,
+		signer:  config.Signer,
+		chainID: chainID,
+		address: config.Signer.Address(),
+	}
+	manifest := Manifest{
+		ChainID:       chainID.String(),
+		Guardian:      config.Guardian,
+		Prover:        config.Prover,
+		GenesisHeight: config.GenesisHeight,
+		GenesisHeader: config.GenesisHeader,
+	}
+
+	// the succinct gateway
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest.GatewayImplementation, err = d.deploy(ctx, "succinct gateway", func(opts *bind.TransactOpts) (ethcmn.Address, *coregethtypes.Transaction, error) {
+		address, tx, _, err := bindings.DeploySuccinctGateway(opts, backend)
+		return address, tx, err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest.Gateway, err = d.initialize(ctx, config.ProxyBytecode, "succinct gateway", manifest.GatewayImplementation, gatewayABI, d.address, config.FeeVault, config.Prover)
+	if err != nil {
+		return Manifest{}, err
+	}
+	gateway, err := bindings.NewSuccinctGateway(manifest.Gateway, backend)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	// the circuits verifiers
+	headerRangeSalt := saltOrDefault(config.HeaderRangeVerifier.Salt, DefaultHeaderRangeSalt)
+	manifest.HeaderRangeVerifier, manifest.HeaderRangeFunctionID, err = d.registerFunction(ctx, gateway, "header range", config.HeaderRangeVerifier, headerRangeSalt)
+	if err != nil {
+		return Manifest{}, err
+	}
+	nextHeaderSalt := saltOrDefault(config.NextHeaderVerifier.Salt, DefaultNextHeaderSalt)
+	manifest.NextHeaderVerifier, manifest.NextHeaderFunctionID, err = d.registerFunction(ctx, gateway, "next header", config.NextHeaderVerifier, nextHeaderSalt)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	// the BlobstreamX contract
+	blobstreamXABI, err := blobstreamxwrapper.BlobstreamXMetaData.GetAbi()
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest.BlobstreamXImplementation, err = d.deploy(ctx, "BlobstreamX", func(opts *bind.TransactOpts) (ethcmn.Address, *coregethtypes.Transaction, error) {
+		address, tx, _, err := blobstreamxwrapper.DeployBlobstreamX(opts, backend)
+		return address, tx, err
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	manifest.BlobstreamX, err = d.initialize(ctx, config.ProxyBytecode, "BlobstreamX", manifest.BlobstreamXImplementation, blobstreamXABI, blobstreamxwrapper.BlobstreamXInitParameters{
+		Guardian:              config.Guardian,
+		Gateway:               manifest.Gateway,
+		Height:                config.GenesisHeight,
+		Header:                config.GenesisHeader,
+		NextHeaderFunctionId:  manifest.NextHeaderFunctionID,
+		HeaderRangeFunctionId: manifest.HeaderRangeFunctionID,
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	logger.Info("BlobstreamX stack deployed", "blobstreamx", manifest.BlobstreamX.Hex(), "gateway", manifest.Gateway.Hex())
+	return manifest, nil
+}
+
+func saltOrDefault(salt [32]byte, defaultSalt ethcmn.Hash) [32]byte {
+	if salt == [32]byte{} {
+		return defaultSalt
+	}
+	return salt
+}
+
+// transactOpts creates the options of a deployment transaction. The nonce, gas price and gas limit are
+// set by the backend.
+func (d *deployer) transactOpts(ctx context.Context) (*bind.TransactOpts, error) {
+	return signer.NewTransactOpts(ctx, d.signer, d.chainID), nil
+}
+
+// wait waits for the transaction to be included and checks that it succeeded.
+func (d *deployer) wait(ctx context.Context, what string, tx *coregethtypes.Transaction) (*coregethtypes.Receipt, error) {
+	d.logger.Info("transaction submitted", "what", what, "hash", tx.Hash().Hex())
+	receipt, err := bind.WaitMined(ctx, d.backend, tx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for the %s transaction %s: %w", what, tx.Hash().Hex(), err)
+	}
+	if receipt.Status != coregethtypes.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("the %s transaction %s reverted", what, tx.Hash().Hex())
+	}
+	return receipt, nil
+}
+
+// deploy deploys a contract using the provided deploy function and returns its address.
+func (d *deployer) deploy(
+	ctx context.Context,
+	what string,
+	deployFn func(opts *bind.TransactOpts) (ethcmn.Address, *coregethtypes.Transaction, error),
+) (ethcmn.Address, error) {
+	opts, err := d.transactOpts(ctx)
+	if err != nil {
+		return ethcmn.Address{}, err
+	}
+	address, tx, err := deployFn(opts)
+	if err != nil {
+		return ethcmn.Address{}, fmt.Errorf("deploying the %s: %w", what, err)
+	}
+	if _, err := d.wait(ctx, "deploy "+what, tx); err != nil {
+		return ethcmn.Address{}, err
+	}
+	d.logger.Info("contract deployed", "contract", what, "address", address.Hex())
+	return address, nil
+}
+
+// initialize deploys a proxy pointing to the implementation, and initializes it using the provided initialize
+// arguments. It returns the proxy address.
+func (d *deployer) initialize(
+	ctx context.Context,
+	proxyBytecode []byte,
+	what string,
+	implementation ethcmn.Address,
+	implementationABI *abi.ABI,
+	args ...interface{},
+) (ethcmn.Address, error) {
+	initData, err := implementationABI.Pack("initialize", args...)
+	if err != nil {
+		return ethcmn.Address{}, err
+	}
+	if len(proxyBytecode) == 0 {
+		return d.deploy(ctx, what+" proxy", func(opts *bind.TransactOpts) (ethcmn.Address, *coregethtypes.Transaction, error) {
+			address, tx, _, err := bind.DeployContract(opts, abi.ABI{}, proxyCreationCode(implementation, initData), d.backend)
+			return address, tx, err
+		})
+	}
+	parsedProxyABI, err := abi.JSON(strings.NewReader(proxyABI))
+	if err != nil {
+		return ethcmn.Address{}, err
+	}
+	return d.deploy(ctx, what+" proxy", func(opts *bind.TransactOpts) (ethcmn.Address, *coregethtypes.Transaction, error) {
+		address, tx, _, err := bind.DeployContract(opts, parsedProxyABI, proxyBytecode, d.backend, implementation, initData)
+		return address, tx, err
+	})
+}
+
+// registerFunction registers the verifier in the gateway, owned by the deployer, and returns the verifier
+// address and the function ID.
+func (d *deployer) registerFunction(
+	ctx context.Context,
+	gateway *bindings.SuccinctGateway,
+	what string,
+	verifier Verifier,
+	salt [32]byte,
+) (ethcmn.Address, ethcmn.Hash, error) {
+	opts, err := d.transactOpts(ctx)
+	if err != nil {
+		return ethcmn.Address{}, ethcmn.Hash{}, err
+	}
+	var tx *coregethtypes.Transaction
+	if len(verifier.Bytecode) != 0 {
+		tx, err = gateway.DeployAndRegisterFunction(opts, d.address, verifier.Bytecode, salt)
+	} else {
+		tx, err = gateway.RegisterFunction(opts, d.address, verifier.Address, salt)
+	}
+	if err != nil {
+		return ethcmn.Address{}, ethcmn.Hash{}, fmt.Errorf("registering the %s verifier: %w", what, err)
+	}
+	receipt, err := d.wait(ctx, "register "+what+" verifier", tx)
+	if err != nil {
+		return ethcmn.Address{}, ethcmn.Hash{}, err
+	}
+
+	functionID, err := gateway.GetFunctionId(&bind.CallOpts{Context: ctx}, d.address, salt)
+	if err != nil {
+		return ethcmn.Address{}, ethcmn.Hash{}, err
+	}
+	verifierAddress := verifier.Address
+	for _, log := range receipt.Logs {
+		registered, err := gateway.ParseFunctionRegistered(*log)
+		if err == nil && registered.FunctionId == functionID {
+			verifierAddress = registered.Verifier
+			break
+		}
+	}
+	d.logger.Info("verifier registered", "circuit", what, "verifier", verifierAddress.Hex(), "function_id", ethcmn.Hash(functionID).Hex())
+	return verifierAddress, functionID, nil
+}
@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// returnsCreationCode the creation code of a contract whose runtime code returns 42, used as a verifier
+// deployed by the gateway.
+var returnsCreationCode = ethcmn.FromHex("0x600a600c600039600a6000f3602a60005260206000f3")
+
+// committingBackend a simulated backend mining a block on each sent transaction, so that the deployment
+// doesn't wait for the blocks to be committed.
+type committingBackend struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (b committingBackend) SendTransaction(ctx context.Context, tx *coregethtypes.Transaction) error {
+	if err := b.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.backend.Commit()
+	return nil
+}
+
+func TestDeploy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	deployer := crypto.PubkeyToAddress(key.PublicKey)
+	sim := simulated.NewBackend(coregethtypes.GenesisAlloc{
+		deployer: {Balance: new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))},
+	}, simulated.WithBlockGasLimit(60_000_000))
+	defer sim.Close()
+	backend := committingBackend{Client: sim.Client(), backend: sim}
+
+	guardian := ethcmn.HexToAddress("0x1000000000000000000000000000000000000001")
+	prover := ethcmn.HexToAddress("0x2000000000000000000000000000000000000002")
+	headerRangeVerifier := ethcmn.HexToAddress("0x3000000000000000000000000000000000000003")
+	genesisHeader := crypto.Keccak256Hash([]byte("genesis"))
+
+	manifest, err := Deploy(ctx, tmlog.NewNopLogger(), backend, Config{
+		Signer:              signer.NewKey(key),
+		HeaderRangeVerifier: Verifier{Address: headerRangeVerifier},
+		NextHeaderVerifier:  Verifier{Bytecode: returnsCreationCode},
+		Guardian:            guardian,
+		Prover:              prover,
+		GenesisHeight:       1000,
+		GenesisHeader:       genesisHeader,
+	})
+	require.NoError(t, err)
+
+	// the gateway registers the verifiers with the manifest function IDs
+	opts := &bind.CallOpts{Context: ctx}
+	gateway, err := bindings.NewSuccinctGatewayCaller(manifest.Gateway, backend)
+	require.NoError(t, err)
+	verifier, err := gateway.Verifiers(opts, manifest.HeaderRangeFunctionID)
+	require.NoError(t, err)
+	assert.Equal(t, headerRangeVerifier, verifier)
+	assert.Equal(t, headerRangeVerifier, manifest.HeaderRangeVerifier)
+	verifier, err = gateway.Verifiers(opts, manifest.NextHeaderFunctionID)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.NextHeaderVerifier, verifier)
+	code, err := backend.CodeAt(ctx, manifest.NextHeaderVerifier, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, code)
+	owner, err := gateway.VerifierOwners(opts, manifest.NextHeaderFunctionID)
+	require.NoError(t, err)
+	assert.Equal(t, deployer, owner)
+	allowed, err := gateway.AllowedProvers(opts, [32]byte{}, prover)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// the BlobstreamX proxy is initialized with the gateway, the function IDs and the genesis
+	blobstreamX, err := blobstreamxwrapper.NewBlobstreamXCaller(manifest.BlobstreamX, backend)
+	require.NoError(t, err)
+	gatewayAddress, err := blobstreamX.Gateway(opts)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Gateway, gatewayAddress)
+	functionID, err := blobstreamX.HeaderRangeFunctionId(opts)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(manifest.HeaderRangeFunctionID), functionID)
+	functionID, err = blobstreamX.NextHeaderFunctionId(opts)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(manifest.NextHeaderFunctionID), functionID)
+	latestBlock, err := blobstreamX.LatestBlock(opts)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), latestBlock)
+	header, err := blobstreamX.BlockHeightToHeaderHash(opts, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte(genesisHeader), header)
+	guardianRole, err := blobstreamX.GUARDIANROLE(opts)
+	require.NoError(t, err)
+	isGuardian, err := blobstreamX.HasRole(opts, guardianRole, guardian)
+	require.NoError(t, err)
+	assert.True(t, isGuardian)
+
+	// the written manifest loads back as the one the replay command reads
+	path := filepath.Join(t.TempDir(), "deployment.json")
+	require.NoError(t, manifest.Write(path))
+	loaded, err := LoadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, manifest, loaded)
+	assert.Equal(t, "1337", loaded.ChainID)
+}
+
+func TestDeployValidation(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	valid := Config{
+		Signer:              signer.NewKey(key),
+		HeaderRangeVerifier: Verifier{Address: ethcmn.HexToAddress("0x01")},
+		NextHeaderVerifier:  Verifier{Bytecode: returnsCreationCode},
+		GenesisHeight:       1,
+		GenesisHeader:       [32]byte{1},
+	}
+	require.NoError(t, valid.ValidateBasics())
+
+	noSigner := valid
+	noSigner.Signer = nil
+	assert.ErrorContains(t, noSigner.ValidateBasics(), "signer")
+
+	bothVerifiers := valid
+	bothVerifiers.HeaderRangeVerifier.Bytecode = returnsCreationCode
+	assert.ErrorContains(t, bothVerifiers.ValidateBasics(), "header range verifier")
+
+	noGenesis := valid
+	noGenesis.GenesisHeader = [32]byte{}
+	assert.ErrorContains(t, noGenesis.ValidateBasics(), "genesis")
+}
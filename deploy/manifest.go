@@ -0,0 +1,54 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	ethcmn "github.com/ethereum/go-ethereum/common"
+)
+
+// Manifest describes a deployed BlobstreamX stack. It's written by the deploy command and can be
+// consumed by the replay command to target the deployment.
+type Manifest struct {
+	ChainID string `json:"chain_id"`
+	// Gateway the succinct gateway proxy address.
+	Gateway               ethcmn.Address `json:"gateway"`
+	GatewayImplementation ethcmn.Address `json:"gateway_implementation"`
+	// BlobstreamX the BlobstreamX proxy address.
+	BlobstreamX               ethcmn.Address `json:"blobstreamx"`
+	BlobstreamXImplementation ethcmn.Address `json:"blobstreamx_implementation"`
+	HeaderRangeVerifier       ethcmn.Address `json:"header_range_verifier"`
+	NextHeaderVerifier        ethcmn.Address `json:"next_header_verifier"`
+	HeaderRangeFunctionID     ethcmn.Hash    `json:"header_range_function_id"`
+	NextHeaderFunctionID      ethcmn.Hash    `json:"next_header_function_id"`
+	Guardian                  ethcmn.Address `json:"guardian"`
+	Prover                    ethcmn.Address `json:"prover"`
+	GenesisHeight             uint64         `json:"genesis_height"`
+	GenesisHeader             ethcmn.Hash    `json:"genesis_header"`
+}
+
+// Write writes the manifest to the provided path as indented JSON.
+func (m Manifest) Write(path string) error {
+	bz, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bz, '\n'), 0o644)
+}
+
+// LoadManifest reads the deployment manifest at the provided path.
+func LoadManifest(path string) (Manifest, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return Manifest{}, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(bz, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("invalid deployment manifest %s: %w", path, err)
+	}
+	if manifest.BlobstreamX == (ethcmn.Address{}) || manifest.Gateway == (ethcmn.Address{}) {
+		return Manifest{}, fmt.Errorf("the deployment manifest %s has no BlobstreamX or gateway address", path)
+	}
+	return manifest, nil
+}
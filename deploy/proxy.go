@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"encoding/binary"
+
+	ethcmn "github.com/ethereum/go-ethereum/common"
+)
+
+// ImplementationSlot the ERC1967 implementation slot, i.e. keccak256("eip1967.proxy.implementation") - 1.
+var ImplementationSlot = ethcmn.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2e076cc3735a920a3ca505d382bbc")
+
+// proxyRuntimeCode returns the runtime code of a minimal ERC1967 proxy. It delegates all the calls to the
+// implementation stored in the ERC1967 implementation slot, and bubbles up their return data or revert reason.
+func proxyRuntimeCode() []byte {
+	code := []byte{
+		0x36, 0x60, 0x00, 0x60, 0x00, 0x37, // CALLDATACOPY(0, 0, CALLDATASIZE)
+		0x60, 0x00, 0x60, 0x00, 0x36, 0x60, 0x00, // retSize, retOffset, argsSize, argsOffset
+		0x7f, // PUSH32 implementation slot
+	}
+	code = append(code, ImplementationSlot.Bytes()...)
+	code = append(code,
+		0x54, 0x5a, 0xf4, // DELEGATECALL(GAS, SLOAD(slot), ...)
+		0x3d, 0x60, 0x00, 0x60, 0x00, 0x3e, // RETURNDATACOPY(0, 0, RETURNDATASIZE)
+		0x60, 0x00, 0x57, // JUMPI(return, success), the destination is set below
+		0x3d, 0x60, 0x00, 0xfd, // REVERT(0, RETURNDATASIZE)
+		0x5b, 0x3d, 0x60, 0x00, 0xf3, // JUMPDEST, RETURN(0, RETURNDATASIZE)
+	)
+	// the return jump destination
+	code[len(code)-11] = byte(len(code) - 5)
+	return code
+}
+
+// proxyCreationCode returns the creation code of a minimal ERC1967 proxy pointing to the implementation.
+// If the init data is set, the implementation is delegate called with it during the proxy creation, which
+// reverts if the call fails.
+func proxyCreationCode(implementation ethcmn.Address, initData []byte) []byte {
+	// SSTORE(slot, implementation)
+	code := append([]byte{0x73}, implementation.Bytes()...)
+	code = append(code, 0x7f)
+	code = append(code, ImplementationSlot.Bytes()...)
+	code = append(code, 0x55)
+
+	// the offsets of the runtime code and init data appended to the creation code are only known once
+	// the creation code is built, they're set afterwards.
+	var initDataOffsetAt, okDestAt int
+	if len(initData) != 0 {
+		code = append(code, 0x61)
+		code = append(code, uint16Bytes(len(initData))...) // PUSH2 len
+		code = append(code, 0x80, 0x61)                    // DUP1, PUSH2 offset
+		initDataOffsetAt = len(code)
+		code = append(code, 0x00, 0x00)
+		code = append(code,
+			0x60, 0x00, 0x39, // CODECOPY(0, offset, len)
+			0x60, 0x00, 0x60, 0x00, 0x82, 0x60, 0x00, // retSize, retOffset, argsSize, argsOffset
+			0x73, // PUSH20 implementation
+		)
+		code = append(code, implementation.Bytes()...)
+		code = append(code, 0x5a, 0xf4, 0x61) // DELEGATECALL(GAS, implementation, ...), PUSH2 ok
+		okDestAt = len(code)
+		code = append(code, 0x00, 0x00)
+		code = append(code,
+			0x57,                               // JUMPI(ok, success)
+			0x3d, 0x60, 0x00, 0x60, 0x00, 0x3e, // RETURNDATACOPY(0, 0, RETURNDATASIZE)
+			0x3d, 0x60, 0x00, 0xfd, // REVERT(0, RETURNDATASIZE)
+			0x5b, 0x50, // JUMPDEST ok, POP len
+		)
+		copy(code[okDestAt:], uint16Bytes(len(code)-2))
+	}
+
+	// RETURN the runtime code
+	runtime := proxyRuntimeCode()
+	code = append(code, 0x60, byte(len(runtime)), 0x80, 0x61) // PUSH1 len, DUP1, PUSH2 offset
+	runtimeOffsetAt := len(code)
+	code = append(code, 0x00, 0x00)
+	code = append(code, 0x60, 0x00, 0x39, 0x60, 0x00, 0xf3) // CODECOPY(0, offset, len), RETURN(0, len)
+
+	copy(code[runtimeOffsetAt:], uint16Bytes(len(code)))
+	code = append(code, runtime...)
+	if len(initData) != 0 {
+		copy(code[initDataOffsetAt:], uint16Bytes(len(code)))
+		code = append(code, initData...)
+	}
+	return code
+}
+
+func uint16Bytes(value int) []byte {
+	bz := make([]byte, 2)
+	binary.BigEndian.PutUint16(bz, uint16(value))
+	return bz
+}
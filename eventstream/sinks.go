@@ -0,0 +1,237 @@
+package eventstream
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// subscriberBuffer the number of events buffered for a subscriber before it's considered too slow.
+const subscriberBuffer = 256
+
+// WriterSink writes the events to a writer, e.g. stdout or a file.
+type WriterSink struct {
+	logger tmlog.Logger
+	writer io.Writer
+	closer io.Closer
+}
+
+var _ Sink = &WriterSink{}
+
+// NewWriterSink creates a sink writing to the provided writer.
+func NewWriterSink(logger tmlog.Logger, writer io.Writer) *WriterSink {
+	return &WriterSink{logger: logger, writer: writer}
+}
+
+// NewFileSink creates a sink appending the events to the file at the provided path.
+func NewFileSink(logger tmlog.Logger, path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterSink{logger: logger, writer: file, closer: file}, nil
+}
+
+func (s *WriterSink) Publish(line []byte) {
+	if _, err := s.writer.Write(line); err != nil {
+		s.logger.Error("couldn't write the replay event", "err", err.Error())
+	}
+}
+
+func (s *WriterSink) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// hub broadcasts the events to a dynamic set of subscribers. The subscribers that
+// can't keep up are disconnected instead of blocking the replay.
+type hub struct {
+	logger tmlog.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+	closed      bool
+}
+
+func newHub(logger tmlog.Logger) *hub {
+	return &hub{logger: logger, subscribers: make(map[chan []byte]struct{})}
+}
+
+// subscribe returns a channel receiving the events. It's closed when the subscriber
+// is too slow or the hub is closed.
+func (h *hub) subscribe() (chan []byte, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, false
+	}
+	ch := make(chan []byte, subscriberBuffer)
+	h.subscribers[ch] = struct{}{}
+	return ch, true
+}
+
+func (h *hub) unsubscribe(ch chan []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, exists := h.subscribers[ch]; exists {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (h *hub) publish(line []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- line:
+		default:
+			h.logger.Info("replay events subscriber too slow, disconnecting it")
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// UnixSocketSink streams the events, as NDJSON, to the clients connected to a Unix domain socket.
+type UnixSocketSink struct {
+	hub      *hub
+	listener net.Listener
+	wg       sync.WaitGroup
+}
+
+var _ Sink = &UnixSocketSink{}
+
+// NewUnixSocketSink listens on a Unix domain socket at the provided path. A stale socket
+// file left by a previous run is removed.
+func NewUnixSocketSink(logger tmlog.Logger, path string) (*UnixSocketSink, error) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &UnixSocketSink{hub: newHub(logger), listener: listener}
+	s.wg.Add(1)
+	go s.accept(logger)
+	return s, nil
+}
+
+func (s *UnixSocketSink) accept(logger tmlog.Logger) {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Error("couldn't accept replay events socket connection", "err", err.Error())
+			}
+			return
+		}
+		ch, ok := s.hub.subscribe()
+		if !ok {
+			_ = conn.Close()
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			defer s.hub.unsubscribe(ch)
+			for line := range ch {
+				if _, err := conn.Write(line); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func (s *UnixSocketSink) Publish(line []byte) {
+	s.hub.publish(line)
+}
+
+func (s *UnixSocketSink) Close() error {
+	err := s.listener.Close()
+	s.hub.close()
+	s.wg.Wait()
+	return err
+}
+
+// SSESink streams the events to the clients of an HTTP server-sent events endpoint.
+type SSESink struct {
+	hub *hub
+}
+
+var (
+	_ Sink         = &SSESink{}
+	_ http.Handler = &SSESink{}
+)
+
+// NewSSESink creates a new server-sent events sink. It needs to be served using an HTTP server.
+func NewSSESink(logger tmlog.Logger) *SSESink {
+	return &SSESink{hub: newHub(logger)}
+}
+
+// ServeHTTP streams the events to the client until it disconnects.
+func (s *SSESink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch, ok := s.hub.subscribe()
+	if !ok {
+		http.Error(w, "stream closed", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.hub.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-ch:
+			if !ok {
+				return
+			}
+			// the NDJSON line ends with a new line, which, along with the following one, ends the SSE event
+			if _, err := w.Write(append(append([]byte("data: "), line...), '\n')); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *SSESink) Publish(line []byte) {
+	s.hub.publish(line)
+}
+
+func (s *SSESink) Close() error {
+	s.hub.close()
+	return nil
+}
@@ -0,0 +1,97 @@
+package eventstream
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// Type the proof lifecycle transition an event corresponds to.
+type Type string
+
+const (
+	// TypeDiscovered the proof was found in the source contract and is going to be replayed.
+	TypeDiscovered Type = "discovered"
+	// TypeVerified the data commitment of the proof matches the one generated by the trusted endpoint.
+	TypeVerified Type = "verified"
+	// TypeSubmitted a transaction containing the proof was submitted to the target chain.
+	TypeSubmitted Type = "submitted"
+	// TypeConfirmed the target contract committed to the proof range, which is now provable.
+	TypeConfirmed Type = "confirmed"
+	// TypeFailed replaying the proof failed.
+	TypeFailed Type = "failed"
+)
+
+// Event a proof lifecycle transition.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// StartBlock and EndBlock the Celestia blocks range [StartBlock, EndBlock) committed to by the proof.
+	StartBlock     uint64 `json:"start_block"`
+	EndBlock       uint64 `json:"end_block"`
+	DataCommitment string `json:"data_commitment"`
+	SourceNonce    int64  `json:"source_nonce"`
+	SourceTxHash   string `json:"source_tx_hash"`
+	// TargetNonce the nonce of the proof in the target contract. Only set once confirmed.
+	TargetNonce int64 `json:"target_nonce,omitempty"`
+	// TargetTxHash the hash of the transaction submitting the proof to the target chain.
+	TargetTxHash string `json:"target_tx_hash,omitempty"`
+	// Error the reason of the failure. Only set for failed events.
+	Error string `json:"error,omitempty"`
+}
+
+// Sink a destination of the events.
+type Sink interface {
+	// Publish publishes the NDJSON encoded event. It shouldn't block.
+	Publish(line []byte)
+	Close() error
+}
+
+// Stream publishes the events to a set of sinks. A stream without sinks discards the events.
+type Stream struct {
+	logger tmlog.Logger
+
+	mu    sync.Mutex
+	sinks []Sink
+}
+
+// New creates a new stream publishing to the provided sinks.
+func New(logger tmlog.Logger, sinks ...Sink) *Stream {
+	return &Stream{logger: logger, sinks: sinks}
+}
+
+// Publish publishes the event to all the sinks.
+func (s *Stream) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.sinks) == 0 {
+		return
+	}
+	bz, err := json.Marshal(event)
+	if err != nil {
+		s.logger.Error("couldn't encode the replay event", "type", event.Type, "err", err.Error())
+		return
+	}
+	line := append(bz, '\n')
+	for _, sink := range s.sinks {
+		sink.Publish(line)
+	}
+}
+
+// Close closes all the sinks.
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for _, sink := range s.sinks {
+		errs = append(errs, sink.Close())
+	}
+	s.sinks = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,212 @@
+package fulfillcall
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/succinctlabs/succinctx/bindings"
+)
+
+// maxDepth the maximum number of nested wrapper calls the extractor goes through.
+const maxDepth = 4
+
+// Call the decoded arguments of a succinct gateway fulfillCall.
+type Call struct {
+	FunctionID      [32]byte       `json:"_functionId"`
+	Input           []byte         `json:"_input"`
+	Output          []byte         `json:"_output"`
+	Proof           []byte         `json:"_proof"`
+	CallbackAddress ethcmn.Address `json:"_callbackAddress"`
+	CallbackData    []byte         `json:"_callbackData"`
+}
+
+// Extractor extracts the fulfillCall calls made to a gateway by a transaction. The transaction can call
+// the gateway directly, or through known wrapper contracts, e.g. a Safe multisig, a Multicall3 batcher or
+// an ERC-2771 forwarder. Otherwise, the calls are found in the transaction call trace, if the node supports
+// debug_traceTransaction.
+type Extractor struct {
+	gateway     ethcmn.Address
+	fulfillCall abi.Method
+	wrappers    *wrappers
+	// tracer the client used to trace the transactions. Nil if tracing is disabled.
+	tracer *rpc.Client
+}
+
+// NewExtractor creates a new extractor of the calls to the provided gateway. The RPC client is
+// used to trace the transactions that don't call the gateway through a known wrapper, and can be nil.
+func NewExtractor(gateway ethcmn.Address, tracer *rpc.Client) (*Extractor, error) {
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	fulfillCall, ok := gatewayABI.Methods["fulfillCall"]
+	if !ok {
+		return nil, errors.New("couldn't find the fulfillCall method in the gateway ABI")
+	}
+	wrappers, err := newWrappers()
+	if err != nil {
+		return nil, err
+	}
+	return &Extractor{
+		gateway:     gateway,
+		fulfillCall: fulfillCall,
+		wrappers:    wrappers,
+		tracer:      tracer,
+	}, nil
+}
+
+// Extract returns the fulfillCall calls made to the gateway by the transaction. A batching transaction
+// can contain multiple calls.
+func (e *Extractor) Extract(ctx context.Context, tx *coregethtypes.Transaction) ([]Call, error) {
+	if tx.To() == nil {
+		return nil, fmt.Errorf("transaction %s is a contract creation", tx.Hash().Hex())
+	}
+	calls, err := e.extractFromCalldata(*tx.To(), tx.Data(), 0)
+	if err == nil && len(calls) != 0 {
+		return calls, nil
+	}
+	if e.tracer == nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("couldn't find a fulfillCall to the gateway %s in transaction %s", e.gateway.Hex(), tx.Hash().Hex())
+	}
+
+	traceCalls, traceErr := e.extractFromTrace(ctx, tx.Hash())
+	if traceErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("%w, and couldn't trace the transaction: %s", err, traceErr.Error())
+		}
+		return nil, fmt.Errorf("couldn't trace the transaction %s: %w", tx.Hash().Hex(), traceErr)
+	}
+	if len(traceCalls) == 0 {
+		return nil, fmt.Errorf("couldn't find a fulfillCall to the gateway %s in transaction %s trace", e.gateway.Hex(), tx.Hash().Hex())
+	}
+	return traceCalls, nil
+}
+
+// extractFromCalldata decodes the fulfillCall calls from the calldata, going through the known wrappers.
+func (e *Extractor) extractFromCalldata(to ethcmn.Address, data []byte, depth int) ([]Call, error) {
+	if to == e.gateway {
+		if !e.isFulfillCall(data) {
+			return nil, nil
+		}
+		call, err := e.decode(data)
+		if err != nil {
+			return nil, err
+		}
+		return []Call{call}, nil
+	}
+	if depth >= maxDepth {
+		return nil, nil
+	}
+	innerCalls, known, err := e.wrappers.decode(data)
+	if err != nil || !known {
+		return nil, err
+	}
+	var calls []Call
+	for _, inner := range innerCalls {
+		innerFulfillCalls, err := e.extractFromCalldata(inner.To, inner.Data, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		calls = append(calls, innerFulfillCalls...)
+	}
+	return calls, nil
+}
+
+// callFrame a call frame returned by the geth callTracer.
+type callFrame struct {
+	Type  string          `json:"type"`
+	To    *ethcmn.Address `json:"to"`
+	Input hexutil.Bytes   `json:"input"`
+	Error string          `json:"error"`
+	Calls []callFrame     `json:"calls"`
+}
+
+// extractFromTrace decodes the successful fulfillCall calls found in the transaction call trace.
+func (e *Extractor) extractFromTrace(ctx context.Context, hash ethcmn.Hash) ([]Call, error) {
+	var root callFrame
+	err := e.tracer.CallContext(ctx, &root, "debug_traceTransaction", hash, map[string]interface{}{"tracer": "callTracer"})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		calls []Call
+		walk  func(frame callFrame) error
+	)
+	walk = func(frame callFrame) error {
+		if frame.Error != "" {
+			// the calls of a reverted frame weren't applied
+			return nil
+		}
+		if frame.Type == "CALL" && frame.To != nil && *frame.To == e.gateway && e.isFulfillCall(frame.Input) {
+			call, err := e.decode(frame.Input)
+			if err != nil {
+				return err
+			}
+			calls = append(calls, call)
+		}
+		for _, child := range frame.Calls {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return calls, walk(root)
+}
+
+func (e *Extractor) isFulfillCall(data []byte) bool {
+	return len(data) >= 4 && bytes.Equal(data[:4], e.fulfillCall.ID)
+}
+
+// decode decodes the fulfillCall calldata, including its selector.
+func (e *Extractor) decode(data []byte) (Call, error) {
+	values, err := e.fulfillCall.Inputs.Unpack(data[4:])
+	if err != nil {
+		return Call{}, fmt.Errorf("decoding fulfillCall: %w", err)
+	}
+	if len(values) != 6 {
+		return Call{}, fmt.Errorf("expected 6 fulfillCall arguments, got %d", len(values))
+	}
+	functionID, ok := values[0].([32]byte)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _functionId")
+	}
+	input, ok := values[1].([]byte)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _input")
+	}
+	output, ok := values[2].([]byte)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _output")
+	}
+	proof, ok := values[3].([]byte)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _proof")
+	}
+	callbackAddress, ok := values[4].(ethcmn.Address)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _callbackAddress")
+	}
+	callbackData, ok := values[5].([]byte)
+	if !ok {
+		return Call{}, errors.New("invalid fulfillCall _callbackData")
+	}
+	return Call{
+		FunctionID:      functionID,
+		Input:           input,
+		Output:          output,
+		Proof:           proof,
+		CallbackAddress: callbackAddress,
+		CallbackData:    callbackData,
+	}, nil
+}
@@ -0,0 +1,268 @@
+package fulfillcall
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/succinctlabs/succinctx/bindings"
+)
+
+var (
+	testGateway = ethcmn.HexToAddress("0x6c7a05e0AE641c6559fD76ac56641778B6eCd776")
+	testSafe    = ethcmn.HexToAddress("0x0000000000000000000000000000000000005afe")
+	testOther   = ethcmn.HexToAddress("0x000000000000000000000000000000000000dead")
+)
+
+// testCall returns a gateway call whose input and output are derived from the seed.
+func testCall(seed byte) Call {
+	return Call{
+		FunctionID:      [32]byte{seed},
+		Input:           []byte{seed, 1},
+		Output:          []byte{seed, 2},
+		Proof:           []byte{seed, 3},
+		CallbackAddress: ethcmn.Address{seed},
+		CallbackData:    []byte{seed, 4},
+	}
+}
+
+// fixtures encodes the calldata of the gateway and of the wrapper contracts.
+type fixtures struct {
+	t        *testing.T
+	gateway  *abi.ABI
+	wrappers *wrappers
+}
+
+func newFixtures(t *testing.T) fixtures {
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+	require.NoError(t, err)
+	wrappers, err := newWrappers()
+	require.NoError(t, err)
+	return fixtures{t: t, gateway: gatewayABI, wrappers: wrappers}
+}
+
+func (f fixtures) fulfillCall(call Call) []byte {
+	data, err := f.gateway.Pack("fulfillCall", call.FunctionID, call.Input, call.Output, call.Proof, call.CallbackAddress, call.CallbackData)
+	require.NoError(f.t, err)
+	return data
+}
+
+func (f fixtures) pack(method string, args ...interface{}) []byte {
+	data, err := f.wrappers.abi.Pack(method, args...)
+	require.NoError(f.t, err)
+	return data
+}
+
+// execTransaction a Safe transaction calling, or delegate calling with operation 1, the target.
+func (f fixtures) execTransaction(to ethcmn.Address, data []byte, operation uint8) []byte {
+	return f.pack("execTransaction", to, big.NewInt(0), data, operation, big.NewInt(0), big.NewInt(0), big.NewInt(0), ethcmn.Address{}, ethcmn.Address{}, []byte{1})
+}
+
+// multiSend the Safe MultiSend packed transactions.
+func (f fixtures) multiSend(calls ...innerCall) []byte {
+	var transactions []byte
+	for _, call := range calls {
+		transactions = append(transactions, 0)
+		transactions = append(transactions, call.To.Bytes()...)
+		transactions = append(transactions, make([]byte, 32)...)
+		transactions = append(transactions, make([]byte, 24)...)
+		transactions = binary.BigEndian.AppendUint64(transactions, uint64(len(call.Data)))
+		transactions = append(transactions, call.Data...)
+	}
+	return f.pack("multiSend", transactions)
+}
+
+func newTx(to *ethcmn.Address, data []byte) *coregethtypes.Transaction {
+	return coregethtypes.NewTx(&coregethtypes.LegacyTx{To: to, Data: data, Gas: 21000, GasPrice: big.NewInt(1)})
+}
+
+// debugService a stubbed debug namespace, returning the configured call trace.
+type debugService struct {
+	trace *callFrame
+	err   error
+}
+
+func (s *debugService) TraceTransaction(_ context.Context, _ ethcmn.Hash, config map[string]interface{}) (*callFrame, error) {
+	if config["tracer"] != "callTracer" {
+		return nil, errors.New("unexpected tracer")
+	}
+	return s.trace, s.err
+}
+
+// newTracer returns an RPC client serving the stubbed debug namespace.
+func newTracer(t *testing.T, service *debugService) *rpc.Client {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("debug", service))
+	t.Cleanup(server.Stop)
+	client := rpc.DialInProc(server)
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestExtract(t *testing.T) {
+	f := newFixtures(t)
+	first, second := testCall(1), testCall(2)
+	nested := func(depth int) []byte {
+		data := f.fulfillCall(first)
+		to := testGateway
+		for i := 0; i < depth; i++ {
+			data = f.execTransaction(to, data, 0)
+			to = testSafe
+		}
+		return data
+	}
+	unknownWrapper := append([]byte{0xde, 0xad, 0xbe, 0xef}, f.fulfillCall(first)...)
+
+	tests := []struct {
+		name string
+		tx   *coregethtypes.Transaction
+		// trace the call trace returned by the tracer. The tracing is disabled if both trace and traceErr are nil.
+		trace    *callFrame
+		traceErr error
+		want     []Call
+		wantErr  string
+	}{
+		{
+			name: "direct gateway call",
+			tx:   newTx(&testGateway, f.fulfillCall(first)),
+			want: []Call{first},
+		},
+		{
+			name: "safe transaction",
+			tx:   newTx(&testSafe, f.execTransaction(testGateway, f.fulfillCall(first), 0)),
+			want: []Call{first},
+		},
+		{
+			name: "safe multiSend delegate call",
+			tx: newTx(&testSafe, f.execTransaction(testOther, f.multiSend(
+				innerCall{To: testOther, Data: []byte{1, 2, 3, 4}},
+				innerCall{To: testGateway, Data: f.fulfillCall(first)},
+				innerCall{To: testGateway, Data: f.fulfillCall(second)},
+			), 1)),
+			want: []Call{first, second},
+		},
+		{
+			name: "multicall aggregate",
+			tx: newTx(&testOther, f.pack("aggregate", []multicallCall{
+				{Target: testGateway, CallData: f.fulfillCall(first)},
+				{Target: testOther, CallData: f.fulfillCall(second)},
+			})),
+			want: []Call{first},
+		},
+		{
+			name: "multicall3 aggregate3",
+			tx: newTx(&testOther, f.pack("aggregate3", []multicall3Call{
+				{Target: testGateway, AllowFailure: false, CallData: f.fulfillCall(first)},
+				{Target: testGateway, AllowFailure: true, CallData: f.fulfillCall(second)},
+			})),
+			want: []Call{first, second},
+		},
+		{
+			name: "forwarder execute",
+			tx: newTx(&testOther, f.pack("execute", forwardRequest{
+				From:  testOther,
+				To:    testGateway,
+				Value: big.NewInt(0),
+				Gas:   big.NewInt(1000000),
+				Nonce: big.NewInt(1),
+				Data:  f.fulfillCall(first),
+			}, []byte{1})),
+			want: []Call{first},
+		},
+		{
+			name: "wrappers nested up to the maximum depth",
+			tx:   newTx(&testSafe, nested(maxDepth)),
+			want: []Call{first},
+		},
+		{
+			name:    "wrappers nested beyond the maximum depth",
+			tx:      newTx(&testSafe, nested(maxDepth+1)),
+			wantErr: "couldn't find a fulfillCall to the gateway",
+		},
+		{
+			name:    "non gateway target",
+			tx:      newTx(&testOther, f.fulfillCall(first)),
+			wantErr: "couldn't find a fulfillCall to the gateway",
+		},
+		{
+			name:    "safe transaction to a non gateway target",
+			tx:      newTx(&testSafe, f.execTransaction(testOther, f.fulfillCall(first), 0)),
+			wantErr: "couldn't find a fulfillCall to the gateway",
+		},
+		{
+			name:    "truncated fulfillCall",
+			tx:      newTx(&testGateway, f.fulfillCall(first)[:100]),
+			wantErr: "decoding fulfillCall",
+		},
+		{
+			name:    "contract creation",
+			tx:      newTx(nil, f.fulfillCall(first)),
+			wantErr: "is a contract creation",
+		},
+		{
+			name: "traced unknown wrapper",
+			tx:   newTx(&testOther, unknownWrapper),
+			trace: &callFrame{
+				Type:  "CALL",
+				To:    &testOther,
+				Input: unknownWrapper,
+				Calls: []callFrame{
+					// the calls of a reverted frame, and the calls to another contract, are ignored
+					{Type: "CALL", To: &testOther, Error: "execution reverted", Calls: []callFrame{
+						{Type: "CALL", To: &testGateway, Input: f.fulfillCall(second)},
+					}},
+					{Type: "CALL", To: &testOther, Input: f.fulfillCall(second)},
+					{Type: "STATICCALL", To: &testGateway, Input: f.fulfillCall(second)},
+					{Type: "CALL", To: &testOther, Calls: []callFrame{
+						{Type: "CALL", To: &testGateway, Input: f.fulfillCall(first)},
+					}},
+				},
+			},
+			want: []Call{first},
+		},
+		{
+			name:    "traced without a gateway call",
+			tx:      newTx(&testOther, unknownWrapper),
+			trace:   &callFrame{Type: "CALL", To: &testOther, Input: unknownWrapper},
+			wantErr: "couldn't find a fulfillCall to the gateway " + testGateway.Hex() + " in transaction",
+		},
+		{
+			name:     "tracing unsupported",
+			tx:       newTx(&testOther, unknownWrapper),
+			traceErr: errors.New("the method debug_traceTransaction does not exist"),
+			wantErr:  "couldn't trace the transaction",
+		},
+		{
+			name:     "tracing unsupported after a decoding error",
+			tx:       newTx(&testGateway, f.fulfillCall(first)[:100]),
+			traceErr: errors.New("the method debug_traceTransaction does not exist"),
+			wantErr:  "decoding fulfillCall",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var tracer *rpc.Client
+			if test.trace != nil || test.traceErr != nil {
+				tracer = newTracer(t, &debugService{trace: test.trace, err: test.traceErr})
+			}
+			extractor, err := NewExtractor(testGateway, tracer)
+			require.NoError(t, err)
+
+			calls, err := extractor.Extract(context.Background(), test.tx)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, calls)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package fulfillcall
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ParseFunctionID parses a hex encoded, optionally 0x prefixed, function ID. It must be exactly 32 bytes long.
+func ParseFunctionID(raw string) ([32]byte, error) {
+	var functionID [32]byte
+	decoded, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(raw), "0x"))
+	if err != nil {
+		return functionID, fmt.Errorf("invalid function ID %q: %w", raw, err)
+	}
+	if len(decoded) != len(functionID) {
+		return functionID, fmt.Errorf("invalid function ID %q: expected %d bytes, got %d", raw, len(functionID), len(decoded))
+	}
+	copy(functionID[:], decoded)
+	if functionID == [32]byte{} {
+		return functionID, fmt.Errorf("invalid function ID %q: cannot be zero", raw)
+	}
+	return functionID, nil
+}
+
+// ParseFunctionIDMapping parses a list of <source function ID>=<target function ID> entries.
+func ParseFunctionIDMapping(entries []string) (map[[32]byte][32]byte, error) {
+	mapping := make(map[[32]byte][32]byte, len(entries))
+	for _, entry := range entries {
+		rawSource, rawTarget, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid function ID mapping %q, expected <source function ID>=<target function ID>", entry)
+		}
+		source, err := ParseFunctionID(rawSource)
+		if err != nil {
+			return nil, err
+		}
+		target, err := ParseFunctionID(rawTarget)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := mapping[source]; exists {
+			return nil, fmt.Errorf("the source function ID %s is mapped more than once", rawSource)
+		}
+		mapping[source] = target
+	}
+	return mapping, nil
+}
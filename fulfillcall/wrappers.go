@@ -0,0 +1,159 @@
+package fulfillcall
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+)
+
+// wrappersABI the ABI of the known contracts the gateway calls can be sent through: Safe multisigs and
+// their MultiSend library, Multicall and Multicall3 batchers, and ERC-2771 forwarders.
+const wrappersABI = `[
+	{"type":"function","name":"execTransaction","inputs":[
+		{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},
+		{"name":"operation","type":"uint8"},{"name":"safeTxGas","type":"uint256"},{"name":"baseGas","type":"uint256"},
+		{"name":"gasPrice","type":"uint256"},{"name":"gasToken","type":"address"},{"name":"refundReceiver","type":"address"},
+		{"name":"signatures","type":"bytes"}],"outputs":[{"name":"success","type":"bool"}]},
+	{"type":"function","name":"multiSend","inputs":[{"name":"transactions","type":"bytes"}],"outputs":[]},
+	{"type":"function","name":"aggregate","inputs":[
+		{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"tryAggregate","inputs":[
+		{"name":"requireSuccess","type":"bool"},
+		{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"aggregate3","inputs":[
+		{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"aggregate3Value","inputs":[
+		{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"value","type":"uint256"},{"name":"callData","type":"bytes"}]}],"outputs":[]},
+	{"type":"function","name":"execute","inputs":[
+		{"name":"req","type":"tuple","components":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"gas","type":"uint256"},{"name":"nonce","type":"uint256"},{"name":"data","type":"bytes"}]},
+		{"name":"signature","type":"bytes"}],"outputs":[]}
+]`
+
+// innerCall a call made by a wrapper contract.
+type innerCall struct {
+	To   ethcmn.Address
+	Data []byte
+}
+
+type multicallCall struct {
+	Target   ethcmn.Address
+	CallData []byte
+}
+
+type multicall3Call struct {
+	Target       ethcmn.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3ValueCall struct {
+	Target       ethcmn.Address
+	AllowFailure bool
+	Value        *big.Int
+	CallData     []byte
+}
+
+type forwardRequest struct {
+	From  ethcmn.Address
+	To    ethcmn.Address
+	Value *big.Int
+	Gas   *big.Int
+	Nonce *big.Int
+	Data  []byte
+}
+
+// wrappers decodes the calls made by the known wrapper contracts.
+type wrappers struct {
+	abi abi.ABI
+}
+
+func newWrappers() (*wrappers, error) {
+	parsed, err := abi.JSON(strings.NewReader(wrappersABI))
+	if err != nil {
+		return nil, err
+	}
+	return &wrappers{abi: parsed}, nil
+}
+
+// decode returns the calls made by a wrapper contract when called with the provided data. It returns
+// false if the data doesn't correspond to a known wrapper method.
+func (w *wrappers) decode(data []byte) ([]innerCall, bool, error) {
+	if len(data) < 4 {
+		return nil, false, nil
+	}
+	method, err := w.abi.MethodById(data[:4])
+	if err != nil {
+		return nil, false, nil
+	}
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, true, fmt.Errorf("decoding %s: %w", method.Name, err)
+	}
+
+	switch method.Name {
+	case "execTransaction":
+		// the delegate calls, e.g. to MultiSend, are decoded the same way as the calls
+		return []innerCall{{To: values[0].(ethcmn.Address), Data: values[2].([]byte)}}, true, nil
+	case "multiSend":
+		calls, err := decodeMultiSend(values[0].([]byte))
+		return calls, true, err
+	case "aggregate", "tryAggregate":
+		batch := *abi.ConvertType(values[len(values)-1], new([]multicallCall)).(*[]multicallCall)
+		calls := make([]innerCall, 0, len(batch))
+		for _, call := range batch {
+			calls = append(calls, innerCall{To: call.Target, Data: call.CallData})
+		}
+		return calls, true, nil
+	case "aggregate3":
+		batch := *abi.ConvertType(values[0], new([]multicall3Call)).(*[]multicall3Call)
+		calls := make([]innerCall, 0, len(batch))
+		for _, call := range batch {
+			calls = append(calls, innerCall{To: call.Target, Data: call.CallData})
+		}
+		return calls, true, nil
+	case "aggregate3Value":
+		batch := *abi.ConvertType(values[0], new([]multicall3ValueCall)).(*[]multicall3ValueCall)
+		calls := make([]innerCall, 0, len(batch))
+		for _, call := range batch {
+			calls = append(calls, innerCall{To: call.Target, Data: call.CallData})
+		}
+		return calls, true, nil
+	case "execute":
+		req := *abi.ConvertType(values[0], new(forwardRequest)).(*forwardRequest)
+		return []innerCall{{To: req.To, Data: req.Data}}, true, nil
+	default:
+		return nil, false, nil
+	}
+}
+
+// decodeMultiSend decodes the Safe MultiSend packed transactions:
+// abi.encodePacked(uint8 operation, address to, uint256 value, uint256 dataLength, bytes data).
+func decodeMultiSend(transactions []byte) ([]innerCall, error) {
+	var calls []innerCall
+	for offset := 0; offset < len(transactions); {
+		const headerLength = 1 + 20 + 32 + 32
+		if len(transactions)-offset < headerLength {
+			return nil, errors.New("truncated multiSend transaction")
+		}
+		to := ethcmn.BytesToAddress(transactions[offset+1 : offset+21])
+		rawLength := transactions[offset+53 : offset+85]
+		for _, b := range rawLength[:24] {
+			if b != 0 {
+				return nil, errors.New("invalid multiSend data length")
+			}
+		}
+		length := binary.BigEndian.Uint64(rawLength[24:])
+		offset += headerLength
+		if uint64(len(transactions)-offset) < length {
+			return nil, errors.New("truncated multiSend transaction data")
+		}
+		calls = append(calls, innerCall{To: to, Data: transactions[offset : offset+int(length)]})
+		offset += int(length)
+	}
+	return calls, nil
+}
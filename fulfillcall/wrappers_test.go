@@ -0,0 +1,87 @@
+package fulfillcall
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrappersDecode(t *testing.T) {
+	f := newFixtures(t)
+	multiSend := f.multiSend(
+		innerCall{To: testGateway, Data: []byte{1, 2}},
+		innerCall{To: testOther, Data: nil},
+	)
+	multiSendTransactions := func(edit func(transactions []byte) []byte) []byte {
+		values, err := f.wrappers.abi.Methods["multiSend"].Inputs.Unpack(multiSend[4:])
+		require.NoError(t, err)
+		return f.pack("multiSend", edit(append([]byte{}, values[0].([]byte)...)))
+	}
+
+	tests := []struct {
+		name      string
+		data      []byte
+		want      []innerCall
+		wantKnown bool
+		wantErr   string
+	}{
+		{
+			name:      "multiSend",
+			data:      multiSend,
+			want:      []innerCall{{To: testGateway, Data: []byte{1, 2}}, {To: testOther, Data: []byte{}}},
+			wantKnown: true,
+		},
+		{
+			name: "multiSend truncated header",
+			data: multiSendTransactions(func(transactions []byte) []byte {
+				return transactions[:len(transactions)-1]
+			}),
+			wantKnown: true,
+			wantErr:   "truncated multiSend transaction",
+		},
+		{
+			name: "multiSend truncated data",
+			data: multiSendTransactions(func(transactions []byte) []byte {
+				return transactions[:1+20+32+32+1]
+			}),
+			wantKnown: true,
+			wantErr:   "truncated multiSend transaction data",
+		},
+		{
+			name: "multiSend oversized data length",
+			data: multiSendTransactions(func(transactions []byte) []byte {
+				transactions[1+20+32] = 1
+				return transactions
+			}),
+			wantKnown: true,
+			wantErr:   "invalid multiSend data length",
+		},
+		{
+			name:      "truncated wrapper call",
+			data:      f.execTransaction(testGateway, []byte{1}, 0)[:100],
+			wantKnown: true,
+			wantErr:   "decoding execTransaction",
+		},
+		{
+			name: "unknown selector",
+			data: []byte{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name: "short calldata",
+			data: []byte{1, 2},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls, known, err := f.wrappers.decode(test.data)
+			assert.Equal(t, test.wantKnown, known)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, calls)
+		})
+	}
+}
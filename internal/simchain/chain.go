@@ -0,0 +1,187 @@
+// Package simchain runs simulated EVM chains with a BlobstreamX deployment committing the headers of a
+// simulated Celestia chain, and a fake Celestia consensus node RPC endpoint serving them, to test the
+// replay and verify flows end to end without any network access.
+package simchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/stretchr/testify/require"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// GenesisHeight the Celestia height the BlobstreamX contracts are initialized at.
+const GenesisHeight = 1000
+
+// ChainID the chain ID of the simulated chains.
+const ChainID = 1337
+
+// acceptingVerifierCreationCode the creation code of a verifier whose runtime code returns true for any proof.
+var acceptingVerifierCreationCode = ethcmn.FromHex("0x600a600c600039600a6000f3600160005260206000f3")
+
+// Chain a simulated chain served over HTTP and websocket, with a BlobstreamX deployment.
+type Chain struct {
+	Backend *simulated.Backend
+	// Endpoint the host and port the chain is served on, over both HTTP and websocket.
+	Endpoint string
+	Manifest deploy.Manifest
+}
+
+// committingBackend a simulated backend mining a block on each sent transaction.
+type committingBackend struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (b committingBackend) SendTransaction(ctx context.Context, tx *coregethtypes.Transaction) error {
+	if err := b.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.backend.Commit()
+	return nil
+}
+
+// freePort returns a port that is free to listen on.
+func freePort(t testing.TB) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// New starts a simulated chain serving the eth namespace over HTTP and websocket on the same port,
+// and deploys BlobstreamX with a verifier accepting any proof, initialized with the simulated Celestia
+// genesis header. The key is the deployer and the prover. The chain is closed once the test ends.
+func New(ctx context.Context, t testing.TB, key *ecdsa.PrivateKey) *Chain {
+	t.Helper()
+	port := freePort(t)
+	sim := simulated.NewBackend(coregethtypes.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))},
+	}, simulated.WithBlockGasLimit(60_000_000), func(nodeConf *node.Config, ethConf *ethconfig.Config) {
+		// the replayed proofs gas limit is above the Osaka transactions gas cap
+		chainConfig := *ethConf.Genesis.Config
+		chainConfig.OsakaTime = nil
+		ethConf.Genesis.Config = &chainConfig
+		nodeConf.HTTPHost = "127.0.0.1"
+		nodeConf.HTTPPort = port
+		nodeConf.HTTPModules = []string{"eth"}
+		nodeConf.WSHost = "127.0.0.1"
+		nodeConf.WSPort = port
+		nodeConf.WSModules = []string{"eth"}
+	})
+	t.Cleanup(func() { _ = sim.Close() })
+
+	manifest, err := deploy.Deploy(ctx, tmlog.NewNopLogger(), committingBackend{Client: sim.Client(), backend: sim}, deploy.Config{
+		Signer:              signer.NewKey(key),
+		HeaderRangeVerifier: deploy.Verifier{Bytecode: acceptingVerifierCreationCode},
+		NextHeaderVerifier:  deploy.Verifier{Bytecode: acceptingVerifierCreationCode, Salt: [32]byte{1}},
+		Guardian:            crypto.PubkeyToAddress(key.PublicKey),
+		Prover:              crypto.PubkeyToAddress(key.PublicKey),
+		GenesisHeight:       GenesisHeight,
+		GenesisHeader:       HeaderHash(GenesisHeight),
+	})
+	require.NoError(t, err)
+	return &Chain{
+		Backend:  sim,
+		Endpoint: fmt.Sprintf("127.0.0.1:%d", port),
+		Manifest: manifest,
+	}
+}
+
+// Mine commits a block at each interval until the test ends, for the transactions sent over RPC to be included.
+func (c *Chain) Mine(t testing.TB, interval time.Duration) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		<-stopped
+	})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				c.Backend.Commit()
+			}
+		}
+	}()
+}
+
+// CommitHeaderRange fulfills a header range call on the chain gateway, committing the range to BlobstreamX
+// with the simulated Celestia headers and data commitment.
+func (c *Chain) CommitHeaderRange(ctx context.Context, t testing.TB, key *ecdsa.PrivateKey, start, end uint64) {
+	t.Helper()
+	backend := committingBackend{Client: c.Backend.Client(), backend: c.Backend}
+	gateway, err := bindings.NewSuccinctGatewayTransactor(c.Manifest.Gateway, backend)
+	require.NoError(t, err)
+	blobstreamXABI, err := blobstreamxwrapper.BlobstreamXMetaData.GetAbi()
+	require.NoError(t, err)
+
+	trustedHeader := HeaderHash(start)
+	input := binary.BigEndian.AppendUint64(nil, start)
+	input = append(input, trustedHeader[:]...)
+	input = binary.BigEndian.AppendUint64(input, end)
+	targetHeader := HeaderHash(end)
+	commitment := DataCommitment(start, end)
+	output := append(append([]byte{}, targetHeader[:]...), commitment[:]...)
+	callbackData, err := blobstreamXABI.Pack("commitHeaderRange", start, end)
+	require.NoError(t, err)
+
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(ChainID))
+	require.NoError(t, err)
+	opts.Context = ctx
+	opts.GasLimit = 5_000_000
+	tx, err := gateway.FulfillCall(opts, c.Manifest.HeaderRangeFunctionID, input, output, nil, c.Manifest.BlobstreamX, callbackData)
+	require.NoError(t, err)
+	receipt, err := bind.WaitMined(ctx, backend, tx)
+	require.NoError(t, err)
+	require.Equal(t, coregethtypes.ReceiptStatusSuccessful, receipt.Status)
+}
+
+// LatestBlock returns the latest block of the chain BlobstreamX contract.
+func (c *Chain) LatestBlock(ctx context.Context, t testing.TB) uint64 {
+	t.Helper()
+	blobstreamX, err := blobstreamxwrapper.NewBlobstreamXCaller(c.Manifest.BlobstreamX, c.Backend.Client())
+	require.NoError(t, err)
+	latestBlock, err := blobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+	require.NoError(t, err)
+	return latestBlock
+}
+
+// Transact sends the transaction built by send with the key, mines it, and requires it to succeed.
+// The contract bindings used by send can be created with the chain backend client.
+func (c *Chain) Transact(ctx context.Context, t testing.TB, key *ecdsa.PrivateKey, send func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error)) {
+	t.Helper()
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(ChainID))
+	require.NoError(t, err)
+	opts.Context = ctx
+	tx, err := send(opts)
+	require.NoError(t, err)
+	c.Backend.Commit()
+	receipt, err := bind.WaitMined(ctx, c.Backend.Client(), tx)
+	require.NoError(t, err)
+	require.Equal(t, coregethtypes.ReceiptStatusSuccessful, receipt.Status)
+}
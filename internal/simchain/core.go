@@ -0,0 +1,96 @@
+package simchain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpcserver "github.com/tendermint/tendermint/rpc/jsonrpc/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// subscriber a websocket client subscribed to the core events.
+type subscriber struct {
+	conn    rpctypes.WSRPCConnection
+	request *rpctypes.RPCRequest
+	query   string
+}
+
+// Core a fake Celestia consensus node RPC endpoint, serving the simulated Celestia headers and data
+// commitments over HTTP, and the new block headers events over websocket.
+type Core struct {
+	// URL the endpoint URL.
+	URL string
+
+	mu          sync.Mutex
+	subscribers []subscriber
+}
+
+// NewCore serves a fake core endpoint until the test ends.
+func NewCore(t testing.TB) *Core {
+	t.Helper()
+	core := &Core{}
+	funcs := map[string]*rpcserver.RPCFunc{
+		"header":          rpcserver.NewRPCFunc(core.header, "height"),
+		"data_commitment": rpcserver.NewRPCFunc(core.dataCommitment, "start,end"),
+		"subscribe":       rpcserver.NewWSRPCFunc(core.subscribe, "query"),
+	}
+	mux := http.NewServeMux()
+	rpcserver.RegisterRPCFuncs(mux, funcs, tmlog.NewNopLogger())
+	websockets := rpcserver.NewWebsocketManager(funcs)
+	websockets.SetLogger(tmlog.NewNopLogger())
+	mux.HandleFunc("/websocket", websockets.WebsocketHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	core.URL = server.URL
+	return core
+}
+
+func (c *Core) header(_ *rpctypes.Context, height *int64) (*ctypes.ResultHeader, error) {
+	if height == nil {
+		return nil, fmt.Errorf("the fake core endpoint has no latest height")
+	}
+	return &ctypes.ResultHeader{Header: Header(uint64(*height))}, nil
+}
+
+func (c *Core) dataCommitment(_ *rpctypes.Context, start, end uint64) (*ctypes.ResultDataCommitment, error) {
+	commitment := DataCommitment(start, end)
+	return &ctypes.ResultDataCommitment{DataCommitment: tmbytes.HexBytes(commitment[:])}, nil
+}
+
+func (c *Core) subscribe(ctx *rpctypes.Context, query string) (*ctypes.ResultSubscribe, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subscribers = append(c.subscribers, subscriber{conn: ctx.WSConn, request: ctx.JSONReq, query: query})
+	return &ctypes.ResultSubscribe{}, nil
+}
+
+// EmitNewBlockHeader sends the new block header event of the height to all the subscribers.
+func (c *Core) EmitNewBlockHeader(ctx context.Context, t testing.TB, height uint64) {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sub := range c.subscribers {
+		err := sub.conn.WriteRPCResponse(ctx, rpctypes.NewRPCSuccessResponse(sub.request.ID, &ctypes.ResultEvent{
+			Query:  sub.query,
+			Data:   tmtypes.EventDataNewBlockHeader{Header: *Header(height)},
+			Events: map[string][]string{"tm.event": {tmtypes.EventNewBlockHeader}},
+		}))
+		require.NoError(t, err)
+	}
+}
+
+// Subscribers returns the number of subscriptions to the core events.
+func (c *Core) Subscribers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.subscribers)
+}
@@ -0,0 +1,33 @@
+package simchain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// Header returns the simulated Celestia header at the provided height.
+func Header(height uint64) *tmtypes.Header {
+	return &tmtypes.Header{
+		ChainID:        "simchain",
+		Height:         int64(height),
+		Time:           time.Unix(int64(height), 0).UTC(),
+		ValidatorsHash: tmhash.Sum([]byte("validators")),
+	}
+}
+
+// HeaderHash returns the hash of the simulated Celestia header at the provided height.
+func HeaderHash(height uint64) [32]byte {
+	var hash [32]byte
+	copy(hash[:], Header(height).Hash())
+	return hash
+}
+
+// DataCommitment returns the simulated Celestia data commitment for the provided range.
+func DataCommitment(start, end uint64) [32]byte {
+	var commitment [32]byte
+	copy(commitment[:], tmhash.Sum([]byte(fmt.Sprintf("data commitment %d-%d", start, end))))
+	return commitment
+}
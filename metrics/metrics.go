@@ -0,0 +1,195 @@
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "blobstream_ops"
+
+// The classes of failures tracked by the replay failures metric.
+const (
+	// FailureRPC an RPC request to the source, target or core endpoints failed.
+	FailureRPC = "rpc"
+	// FailureDecode the proof couldn't be decoded from the source transaction.
+	FailureDecode = "decode"
+	// FailureVerification the proof didn't pass the verification.
+	FailureVerification = "verification"
+	// FailureSubmission the transaction containing the proof couldn't be submitted.
+	FailureSubmission = "submission"
+	// FailureTimeout the transaction containing the proof wasn't included in time.
+	FailureTimeout = "timeout"
+	// FailureNotApplied the transaction was included but the target contract wasn't updated.
+	FailureNotApplied = "not_applied"
+)
+
+// Replay the metrics of the replay service.
+type Replay struct {
+	SourceLatestBlock prometheus.Gauge
+	TargetLatestBlock prometheus.Gauge
+	LagBlocks         prometheus.Gauge
+	LagSeconds        prometheus.Gauge
+	ProofsReplayed    prometheus.Counter
+	Failures          *prometheus.CounterVec
+	GasUsed           prometheus.Histogram
+	GasPrice          prometheus.Histogram
+	SignerBalance     prometheus.Gauge
+	ReplayLatency     prometheus.Histogram
+}
+
+// NewReplay creates the replay metrics and registers them in the provided registerer.
+func NewReplay(registerer prometheus.Registerer) *Replay {
+	factory := promauto.With(registerer)
+	return &Replay{
+		SourceLatestBlock: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "source_latest_block",
+			Help:      "The latest Celestia block committed to in the source BlobstreamX contract.",
+		}),
+		TargetLatestBlock: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "target_latest_block",
+			Help:      "The latest Celestia block committed to in the target BlobstreamX contract.",
+		}),
+		LagBlocks: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "lag_blocks",
+			Help:      "The number of Celestia blocks the target contract is behind the source contract.",
+		}),
+		LagSeconds: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "lag_seconds",
+			Help:      "The time elapsed since the oldest source proof that is not yet replayed was committed in the source chain.",
+		}),
+		ProofsReplayed: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "proofs_replayed_total",
+			Help:      "The number of proofs successfully replayed to the target contract.",
+		}),
+		Failures: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "failures_total",
+			Help:      "The number of replay failures by class.",
+		}, []string{"class"}),
+		GasUsed: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "proof_gas_used",
+			Help:      "The gas used by the transactions replaying the proofs.",
+			Buckets:   prometheus.ExponentialBuckets(100_000, 2, 10),
+		}),
+		GasPrice: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "proof_gas_price_gwei",
+			Help:      "The gas price paid by the transactions replaying the proofs, in gwei.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+		}),
+		SignerBalance: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "signer_balance_wei",
+			Help:      "The balance of the account signing the replay transactions in the target chain, in wei.",
+		}),
+		ReplayLatency: factory.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "replay",
+			Name:      "proof_replay_duration_seconds",
+			Help:      "The time it took to replay a proof, from getting its source transaction until the target contract is updated.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+	}
+}
+
+// Verify the metrics of the verify command.
+type Verify struct {
+	CommitmentsVerified prometheus.Counter
+	Mismatches          prometheus.Counter
+	RPCErrors           prometheus.Counter
+	ScanDuration        prometheus.Gauge
+	ProofsVerified      prometheus.Counter
+	InvalidProofs       prometheus.Counter
+}
+
+// NewVerify creates the verify metrics and registers them in the provided registerer.
+func NewVerify(registerer prometheus.Registerer) *Verify {
+	factory := promauto.With(registerer)
+	return &Verify{
+		CommitmentsVerified: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "commitments_verified_total",
+			Help:      "The number of data commitments that matched the ones generated by the trusted endpoint.",
+		}),
+		Mismatches: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "mismatches_total",
+			Help:      "The number of data commitments that didn't match the ones generated by the trusted endpoint.",
+		}),
+		RPCErrors: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "rpc_errors_total",
+			Help:      "The number of failed requests to the EVM and core endpoints.",
+		}),
+		ScanDuration: factory.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "scan_duration_seconds",
+			Help:      "The duration of the last verification scan of the contract.",
+		}),
+		ProofsVerified: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "proofs_verified_total",
+			Help:      "The number of historical proofs successfully verified off-chain.",
+		}),
+		InvalidProofs: factory.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "verify",
+			Name:      "invalid_proofs_total",
+			Help:      "The number of historical proofs that failed the off-chain verification.",
+		}),
+	}
+}
+
+// NewRegistry creates a registry containing the Go runtime and process collectors.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+// Handler returns the HTTP handler exposing the metrics of the registry.
+func Handler(registry *prometheus.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// ToGwei converts an amount in wei to a float in gwei.
+func ToGwei(wei *big.Int) float64 {
+	gwei, _ := new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e9)).Float64()
+	return gwei
+}
+
+// ToFloat converts a big integer to a float.
+func ToFloat(value *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(value).Float64()
+	return f
+}
@@ -0,0 +1,254 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// The kinds of alerts.
+const (
+	// KindMismatch a data commitment doesn't match the one generated by the trusted endpoint.
+	KindMismatch = "data_commitment_mismatch"
+	// KindHeaderMismatch a header hash stored in the target contract doesn't match the Celestia header.
+	KindHeaderMismatch = "header_hash_mismatch"
+	// KindTargetStateMismatch the target contract state diverged from the source contract after a replay.
+	KindTargetStateMismatch = "target_state_mismatch"
+	// KindLag the target contract is lagging behind the source contract.
+	KindLag = "replay_lag"
+	// KindLowBalance the signer balance is too low to keep replaying proofs.
+	KindLowBalance = "low_signer_balance"
+	// KindSubmissionFailures the proofs submission failed repeatedly.
+	KindSubmissionFailures = "submission_failures"
+	// KindGuardianFreeze the guardian froze the target contract after a confirmed mismatch.
+	KindGuardianFreeze = "guardian_freeze"
+	// KindGuardianFreezeFailed the guardian didn't freeze the target contract after a mismatch.
+	KindGuardianFreezeFailed = "guardian_freeze_failed"
+	// KindGovernanceChange a source or target contract was upgraded, or its governance configuration changed.
+	KindGovernanceChange = "governance_change"
+)
+
+// Severity the severity of an alert.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// The supported webhook payload formats.
+const (
+	// FormatGeneric the alert is posted as is, in JSON.
+	FormatGeneric = "generic"
+	// FormatSlack the alert is posted as a Slack incoming webhook message.
+	FormatSlack = "slack"
+	// FormatPagerDuty the alert is posted as a PagerDuty Events API v2 trigger event.
+	FormatPagerDuty = "pagerduty"
+)
+
+// DefaultPagerDutyURL the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+const source = "blobstream-ops"
+
+// Alert an alert sent to the webhooks.
+type Alert struct {
+	Kind     string            `json:"kind"`
+	Severity Severity          `json:"severity"`
+	Summary  string            `json:"summary"`
+	Details  map[string]string `json:"details,omitempty"`
+	// DedupKey identifies the alerts that are duplicates of each other. Defaults to the kind.
+	DedupKey string    `json:"dedup_key"`
+	Time     time.Time `json:"time"`
+}
+
+// Webhook an endpoint the alerts are posted to.
+type Webhook struct {
+	URL    string
+	Format string
+	// RoutingKey the PagerDuty integration key, only used with the PagerDuty format.
+	RoutingKey string
+}
+
+func (w Webhook) ValidateBasics() error {
+	if w.URL == "" {
+		return errors.New("the webhook URL cannot be empty")
+	}
+	switch w.Format {
+	case FormatGeneric, FormatSlack:
+		return nil
+	case FormatPagerDuty:
+		if w.RoutingKey == "" {
+			return errors.New("the PagerDuty routing key cannot be empty")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown webhook format %q", w.Format)
+	}
+}
+
+// Notifier posts the alerts to a set of webhooks. The alerts sharing the same dedup key
+// are only sent once per cooldown period.
+type Notifier struct {
+	logger   tmlog.Logger
+	webhooks []Webhook
+	cooldown time.Duration
+	client   *http.Client
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// New creates a new notifier. If no webhook is provided, the alerts are only logged.
+func New(logger tmlog.Logger, webhooks []Webhook, cooldown time.Duration) (*Notifier, error) {
+	for _, webhook := range webhooks {
+		if err := webhook.ValidateBasics(); err != nil {
+			return nil, err
+		}
+	}
+	return &Notifier{
+		logger:   logger,
+		webhooks: webhooks,
+		cooldown: cooldown,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		lastSent: make(map[string]time.Time),
+	}, nil
+}
+
+// Notify sends the alert to all the webhooks, unless an alert with the same dedup key
+// was sent less than a cooldown period ago.
+func (n *Notifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.DedupKey == "" {
+		alert.DedupKey = alert.Kind
+	}
+	if alert.Time.IsZero() {
+		alert.Time = time.Now().UTC()
+	}
+
+	n.mu.Lock()
+	lastSent, sent := n.lastSent[alert.DedupKey]
+	if sent && alert.Time.Sub(lastSent) < n.cooldown {
+		n.mu.Unlock()
+		n.logger.Debug("alert suppressed during cooldown", "kind", alert.Kind, "dedup_key", alert.DedupKey, "last_sent", lastSent)
+		return nil
+	}
+	n.lastSent[alert.DedupKey] = alert.Time
+	n.mu.Unlock()
+
+	n.logger.Info("sending alert", "kind", alert.Kind, "severity", alert.Severity, "summary", alert.Summary)
+	var errs []error
+	for _, webhook := range n.webhooks {
+		if err := n.post(ctx, webhook, alert); err != nil {
+			n.logger.Error("couldn't send alert", "format", webhook.Format, "err", err.Error())
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, webhook Webhook, alert Alert) error {
+	payload, err := encode(webhook, alert)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// encode encodes the alert in the payload format of the webhook.
+func encode(webhook Webhook, alert Alert) ([]byte, error) {
+	switch webhook.Format {
+	case FormatGeneric:
+		return json.Marshal(struct {
+			Source string `json:"source"`
+			Alert
+		}{Source: source, Alert: alert})
+	case FormatSlack:
+		var text strings.Builder
+		fmt.Fprintf(&text, "*[%s] %s*", strings.ToUpper(string(alert.Severity)), alert.Summary)
+		for _, key := range sortedKeys(alert.Details) {
+			fmt.Fprintf(&text, "\n• %s: `%s`", key, alert.Details[key])
+		}
+		return json.Marshal(map[string]string{"text": text.String()})
+	case FormatPagerDuty:
+		return json.Marshal(map[string]interface{}{
+			"routing_key":  webhook.RoutingKey,
+			"event_action": "trigger",
+			"dedup_key":    alert.DedupKey,
+			"payload": map[string]interface{}{
+				"summary":        alert.Summary,
+				"source":         source,
+				"severity":       pagerDutySeverity(alert.Severity),
+				"timestamp":      alert.Time.Format(time.RFC3339),
+				"class":          alert.Kind,
+				"custom_details": alert.Details,
+			},
+		})
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", webhook.Format)
+	}
+}
+
+// pagerDutySeverity maps the severity to one of the PagerDuty severities: critical, error, warning or info.
+func pagerDutySeverity(severity Severity) string {
+	switch severity {
+	case SeverityCritical:
+		return "critical"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NewWebhooks creates the webhooks posting generic JSON payloads to the generic URLs, Slack messages
+// to the Slack URLs, and PagerDuty events using the routing key, if set.
+func NewWebhooks(genericURLs []string, slackURLs []string, pagerDutyRoutingKey string, pagerDutyURL string) []Webhook {
+	webhooks := make([]Webhook, 0, len(genericURLs)+len(slackURLs)+1)
+	for _, url := range genericURLs {
+		webhooks = append(webhooks, Webhook{URL: url, Format: FormatGeneric})
+	}
+	for _, url := range slackURLs {
+		webhooks = append(webhooks, Webhook{URL: url, Format: FormatSlack})
+	}
+	if pagerDutyRoutingKey != "" {
+		if pagerDutyURL == "" {
+			pagerDutyURL = DefaultPagerDutyURL
+		}
+		webhooks = append(webhooks, Webhook{URL: pagerDutyURL, Format: FormatPagerDuty, RoutingKey: pagerDutyRoutingKey})
+	}
+	return webhooks
+}
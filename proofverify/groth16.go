@@ -0,0 +1,245 @@
+package proofverify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// publicInputs the number of public inputs of the succinct gateway circuits: the input and output hashes.
+const publicInputs = 2
+
+// proofLength the length of an ABI encoded (uint256[2] a, uint256[2][2] b, uint256[2] c) Groth16 proof.
+const proofLength = 8 * 32
+
+// VerifyingKey a Groth16 verifying key over BN254.
+type VerifyingKey struct {
+	Alpha bn254.G1Affine
+	Beta  bn254.G2Affine
+	Gamma bn254.G2Affine
+	Delta bn254.G2Affine
+	// IC the points the public inputs are combined with. It contains one more point than the number of public inputs.
+	IC []bn254.G1Affine
+}
+
+// rawVerifyingKey the JSON verifying key format exported by snarkjs and the gnark based tooling. The coordinates
+// are decimal or 0x prefixed hex strings, and the G2 coordinates are ordered as [c0, c1].
+type rawVerifyingKey struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	Alpha    []string   `json:"vk_alpha_1"`
+	Beta     [][]string `json:"vk_beta_2"`
+	Gamma    [][]string `json:"vk_gamma_2"`
+	Delta    [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+}
+
+// LoadVerifyingKey loads a Groth16 verifying key from a JSON file.
+func LoadVerifyingKey(path string) (*VerifyingKey, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	vk, err := ParseVerifyingKey(bz)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vk, nil
+}
+
+// ParseVerifyingKey parses a JSON Groth16 verifying key.
+func ParseVerifyingKey(bz []byte) (*VerifyingKey, error) {
+	var raw rawVerifyingKey
+	if err := json.Unmarshal(bz, &raw); err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(raw.Protocol) {
+	case "groth16", "":
+	case "plonk":
+		return nil, errors.New("PLONK verifying keys are not supported yet, only Groth16 ones are")
+	default:
+		return nil, fmt.Errorf("unsupported proving system %q", raw.Protocol)
+	}
+	switch strings.ToLower(raw.Curve) {
+	case "bn128", "bn254", "":
+	default:
+		return nil, fmt.Errorf("unsupported curve %q, expected bn254", raw.Curve)
+	}
+	if len(raw.IC) != publicInputs+1 {
+		return nil, fmt.Errorf("expected %d IC points for %d public inputs, got %d", publicInputs+1, publicInputs, len(raw.IC))
+	}
+
+	var (
+		vk  VerifyingKey
+		err error
+	)
+	if vk.Alpha, err = parseG1(raw.Alpha); err != nil {
+		return nil, fmt.Errorf("vk_alpha_1: %w", err)
+	}
+	if vk.Beta, err = parseG2(raw.Beta); err != nil {
+		return nil, fmt.Errorf("vk_beta_2: %w", err)
+	}
+	if vk.Gamma, err = parseG2(raw.Gamma); err != nil {
+		return nil, fmt.Errorf("vk_gamma_2: %w", err)
+	}
+	if vk.Delta, err = parseG2(raw.Delta); err != nil {
+		return nil, fmt.Errorf("vk_delta_2: %w", err)
+	}
+	vk.IC = make([]bn254.G1Affine, len(raw.IC))
+	for i, point := range raw.IC {
+		if vk.IC[i], err = parseG1(point); err != nil {
+			return nil, fmt.Errorf("IC[%d]: %w", i, err)
+		}
+	}
+	return &vk, nil
+}
+
+// Verify verifies the ABI encoded Groth16 proof against the public inputs.
+func (vk *VerifyingKey) Verify(proof []byte, inputs []*big.Int) error {
+	if len(inputs)+1 != len(vk.IC) {
+		return fmt.Errorf("expected %d public inputs, got %d", len(vk.IC)-1, len(inputs))
+	}
+	a, b, c, err := decodeProof(proof)
+	if err != nil {
+		return err
+	}
+
+	// vkX = IC[0] + sum(inputs[i] * IC[i+1])
+	var vkX bn254.G1Jac
+	vkX.FromAffine(&vk.IC[0])
+	for i, input := range inputs {
+		if input.Sign() < 0 || input.Cmp(fr.Modulus()) >= 0 {
+			return fmt.Errorf("public input %d is not in the scalar field", i)
+		}
+		var term bn254.G1Affine
+		term.ScalarMultiplication(&vk.IC[i+1], input)
+		vkX.AddMixed(&term)
+	}
+	var vkXAffine, negA bn254.G1Affine
+	vkXAffine.FromJacobian(&vkX)
+	negA.Neg(&a)
+
+	// e(-A, B) * e(alpha, beta) * e(vkX, gamma) * e(C, delta) == 1
+	ok, err := bn254.PairingCheck(
+		[]bn254.G1Affine{negA, vk.Alpha, vkXAffine, c},
+		[]bn254.G2Affine{b, vk.Beta, vk.Gamma, vk.Delta},
+	)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid proof: pairing check failed")
+	}
+	return nil
+}
+
+// decodeProof decodes an ABI encoded (uint256[2] a, uint256[2][2] b, uint256[2] c) proof, as expected by the
+// Solidity verifiers. The G2 coordinates are ordered as [c1, c0], following the EVM pairing precompile.
+func decodeProof(proof []byte) (a bn254.G1Affine, b bn254.G2Affine, c bn254.G1Affine, err error) {
+	if len(proof) != proofLength {
+		return a, b, c, fmt.Errorf("invalid proof length %d, expected %d", len(proof), proofLength)
+	}
+	words := make([]*big.Int, 8)
+	for i := range words {
+		words[i] = new(big.Int).SetBytes(proof[i*32 : (i+1)*32])
+	}
+	if a, err = newG1(words[0], words[1]); err != nil {
+		return a, b, c, fmt.Errorf("proof A: %w", err)
+	}
+	if b, err = newG2(words[3], words[2], words[5], words[4]); err != nil {
+		return a, b, c, fmt.Errorf("proof B: %w", err)
+	}
+	if c, err = newG1(words[6], words[7]); err != nil {
+		return a, b, c, fmt.Errorf("proof C: %w", err)
+	}
+	return a, b, c, nil
+}
+
+func parseG1(coordinates []string) (bn254.G1Affine, error) {
+	if len(coordinates) < 2 {
+		return bn254.G1Affine{}, errors.New("expected the x and y coordinates")
+	}
+	x, err := parseCoordinate(coordinates[0])
+	if err != nil {
+		return bn254.G1Affine{}, err
+	}
+	y, err := parseCoordinate(coordinates[1])
+	if err != nil {
+		return bn254.G1Affine{}, err
+	}
+	return newG1(x, y)
+}
+
+func parseG2(coordinates [][]string) (bn254.G2Affine, error) {
+	if len(coordinates) < 2 || len(coordinates[0]) != 2 || len(coordinates[1]) != 2 {
+		return bn254.G2Affine{}, errors.New("expected the [c0, c1] x and y coordinates")
+	}
+	values := make([]*big.Int, 0, 4)
+	for _, coordinate := range append(coordinates[0], coordinates[1]...) {
+		value, err := parseCoordinate(coordinate)
+		if err != nil {
+			return bn254.G2Affine{}, err
+		}
+		values = append(values, value)
+	}
+	return newG2(values[0], values[1], values[2], values[3])
+}
+
+func parseCoordinate(raw string) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(strings.TrimSpace(raw), 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid coordinate %q", raw)
+	}
+	return value, nil
+}
+
+func newG1(x, y *big.Int) (bn254.G1Affine, error) {
+	var point bn254.G1Affine
+	if err := setFp(&point.X, x); err != nil {
+		return point, err
+	}
+	if err := setFp(&point.Y, y); err != nil {
+		return point, err
+	}
+	if !point.IsOnCurve() || !point.IsInSubGroup() {
+		return point, errors.New("the G1 point is not on the curve")
+	}
+	return point, nil
+}
+
+func newG2(x0, x1, y0, y1 *big.Int) (bn254.G2Affine, error) {
+	var point bn254.G2Affine
+	for _, coordinate := range []struct {
+		element *fp.Element
+		value   *big.Int
+	}{
+		{&point.X.A0, x0},
+		{&point.X.A1, x1},
+		{&point.Y.A0, y0},
+		{&point.Y.A1, y1},
+	} {
+		if err := setFp(coordinate.element, coordinate.value); err != nil {
+			return point, err
+		}
+	}
+	if !point.IsOnCurve() || !point.IsInSubGroup() {
+		return point, errors.New("the G2 point is not on the curve")
+	}
+	return point, nil
+}
+
+func setFp(element *fp.Element, value *big.Int) error {
+	if value.Sign() < 0 || value.Cmp(fp.Modulus()) >= 0 {
+		return errors.New("the coordinate is not in the base field")
+	}
+	element.SetBigInt(value)
+	return nil
+}
@@ -0,0 +1,105 @@
+package proofverify
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+)
+
+// hashMask the mask applied to the input and output hashes by the succinct gateway verifiers,
+// so that they fit in the BN254 scalar field: (1 << 253) - 1.
+var hashMask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 253), big.NewInt(1))
+
+// Verifier verifies the succinct gateway proofs off-chain, using the verifying keys of the function IDs.
+type Verifier struct {
+	keys map[[32]byte]*VerifyingKey
+}
+
+// NewVerifier creates a new verifier using the provided verifying keys.
+func NewVerifier(keys map[[32]byte]*VerifyingKey) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// LoadVerifier loads the verifying keys from the provided files, indexed by function ID, and from the
+// artifacts directory, which contains a <function ID in hex>.json verifying key per function. The
+// directory is optional, and the files take precedence over it.
+func LoadVerifier(artifactsDir string, files map[[32]byte]string) (*Verifier, error) {
+	keys := make(map[[32]byte]*VerifyingKey)
+	if artifactsDir != "" {
+		entries, err := os.ReadDir(artifactsDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || filepath.Ext(name) != ".json" {
+				continue
+			}
+			functionID, err := fulfillcall.ParseFunctionID(strings.TrimSuffix(name, ".json"))
+			if err != nil {
+				// not a verifying key
+				continue
+			}
+			vk, err := LoadVerifyingKey(filepath.Join(artifactsDir, name))
+			if err != nil {
+				return nil, err
+			}
+			keys[functionID] = vk
+		}
+	}
+	for functionID, path := range files {
+		vk, err := LoadVerifyingKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys[functionID] = vk
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no verifying key found")
+	}
+	return &Verifier{keys: keys}, nil
+}
+
+// Has returns true if the verifier has a verifying key for the function ID.
+func (v *Verifier) Has(functionID [32]byte) bool {
+	_, ok := v.keys[functionID]
+	return ok
+}
+
+// Verify verifies the proof of a fulfillCall, the same way the gateway does on-chain: the public inputs
+// are the sha256 hashes of the circuit input and output.
+func (v *Verifier) Verify(functionID [32]byte, input []byte, output []byte, proof []byte) error {
+	vk, ok := v.keys[functionID]
+	if !ok {
+		return fmt.Errorf("no verifying key for function ID 0x%s", hex.EncodeToString(functionID[:]))
+	}
+	inputHash := sha256.Sum256(input)
+	outputHash := sha256.Sum256(output)
+	return vk.Verify(proof, []*big.Int{
+		new(big.Int).And(new(big.Int).SetBytes(inputHash[:]), hashMask),
+		new(big.Int).And(new(big.Int).SetBytes(outputHash[:]), hashMask),
+	})
+}
+
+// ParseKeyFiles parses a list of <function ID>=<verifying key path> entries.
+func ParseKeyFiles(entries []string) (map[[32]byte]string, error) {
+	files := make(map[[32]byte]string, len(entries))
+	for _, entry := range entries {
+		rawFunctionID, path, ok := strings.Cut(entry, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid verifying key %q, expected <function ID>=<path>", entry)
+		}
+		functionID, err := fulfillcall.ParseFunctionID(rawFunctionID)
+		if err != nil {
+			return nil, err
+		}
+		files[functionID] = path
+	}
+	return files, nil
+}
@@ -0,0 +1,160 @@
+package proofverify
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proofFixture a known-good fulfillCall proof of the testdata verifying key. The key and the proof were
+// generated from a known trapdoor, so that the proof satisfies the Groth16 verification equation for the
+// input and output hashes without running a circuit.
+type proofFixture struct {
+	FunctionID string        `json:"functionId"`
+	Input      hexutil.Bytes `json:"input"`
+	Output     hexutil.Bytes `json:"output"`
+	Proof      hexutil.Bytes `json:"proof"`
+}
+
+func loadProofFixture(t *testing.T) ([32]byte, proofFixture) {
+	t.Helper()
+	bz, err := os.ReadFile(filepath.Join("testdata", "proof.json"))
+	require.NoError(t, err)
+	var fixture proofFixture
+	require.NoError(t, json.Unmarshal(bz, &fixture))
+	functionID, err := fulfillcall.ParseFunctionID(fixture.FunctionID)
+	require.NoError(t, err)
+	return functionID, fixture
+}
+
+func TestVerifierVerify(t *testing.T) {
+	verifier, err := LoadVerifier("testdata", nil)
+	require.NoError(t, err)
+	functionID, fixture := loadProofFixture(t)
+	require.True(t, verifier.Has(functionID))
+
+	tamperedProof := append([]byte{}, fixture.Proof...)
+	// the C point x coordinate, which leaves the point off the curve
+	tamperedProof[6*32+31] ^= 1
+	otherProof := append([]byte{}, fixture.Proof...)
+	// swap the A and C points, which are both valid G1 points
+	copy(otherProof[:64], fixture.Proof[6*32:])
+	copy(otherProof[6*32:], fixture.Proof[:64])
+	tamperedOutput := append([]byte{}, fixture.Output...)
+	tamperedOutput[0] ^= 1
+
+	tests := []struct {
+		name       string
+		functionID [32]byte
+		input      []byte
+		output     []byte
+		proof      []byte
+		wantErr    string
+	}{
+		{
+			name:       "known-good proof",
+			functionID: functionID,
+			input:      fixture.Input,
+			output:     fixture.Output,
+			proof:      fixture.Proof,
+		},
+		{
+			name:       "tampered proof point",
+			functionID: functionID,
+			input:      fixture.Input,
+			output:     fixture.Output,
+			proof:      tamperedProof,
+			wantErr:    "not on the curve",
+		},
+		{
+			name:       "swapped proof points",
+			functionID: functionID,
+			input:      fixture.Input,
+			output:     fixture.Output,
+			proof:      otherProof,
+			wantErr:    "pairing check failed",
+		},
+		{
+			name:       "tampered output",
+			functionID: functionID,
+			input:      fixture.Input,
+			output:     tamperedOutput,
+			proof:      fixture.Proof,
+			wantErr:    "pairing check failed",
+		},
+		{
+			name:       "truncated proof",
+			functionID: functionID,
+			input:      fixture.Input,
+			output:     fixture.Output,
+			proof:      fixture.Proof[:proofLength-1],
+			wantErr:    "invalid proof length",
+		},
+		{
+			name:       "unknown function ID",
+			functionID: [32]byte{1},
+			input:      fixture.Input,
+			output:     fixture.Output,
+			proof:      fixture.Proof,
+			wantErr:    "no verifying key",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifier.Verify(test.functionID, test.input, test.output, test.proof)
+			if test.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestParseVerifyingKey(t *testing.T) {
+	functionID, _ := loadProofFixture(t)
+	bz, err := os.ReadFile(filepath.Join("testdata", hexutil.Encode(functionID[:])+".json"))
+	require.NoError(t, err)
+	_, err = ParseVerifyingKey(bz)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr string
+	}{
+		{
+			name:    "PLONK key",
+			from:    `"protocol": "groth16"`,
+			to:      `"protocol": "plonk"`,
+			wantErr: "PLONK verifying keys are not supported",
+		},
+		{
+			name:    "other curve",
+			from:    `"curve": "bn128"`,
+			to:      `"curve": "bls12381"`,
+			wantErr: "unsupported curve",
+		},
+		{
+			name:    "point off the curve",
+			from:    `"vk_alpha_1": [` + "\n" + `    "`,
+			to:      `"vk_alpha_1": [` + "\n" + `    "1`,
+			wantErr: "vk_alpha_1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Contains(t, string(bz), test.from)
+			_, err := ParseVerifyingKey([]byte(strings.Replace(string(bz), test.from, test.to, 1)))
+			assert.ErrorContains(t, err, test.wantErr)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package replay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// ArchiveVersion the version of the proofs archive format.
+const ArchiveVersion = 1
+
+// Archive the proofs of a source BlobstreamX contract, exported so that they can be replayed without
+// connecting to the source chain. The archive is content-hashed: its hash is checked when it's loaded.
+type Archive struct {
+	Version        int            `json:"version"`
+	SourceChainID  *big.Int       `json:"source_chain_id"`
+	SourceContract ethcmn.Address `json:"source_contract"`
+	SourceGateway  ethcmn.Address `json:"source_gateway"`
+	// HeaderRangeFunctionID the source contract header range function ID, to map it to the target one.
+	HeaderRangeFunctionID ethcmn.Hash `json:"header_range_function_id"`
+	// NextHeaderFunctionID the source contract next header function ID, to map it to the target one.
+	NextHeaderFunctionID ethcmn.Hash     `json:"next_header_function_id"`
+	ExportedAt           time.Time       `json:"exported_at"`
+	Proofs               []ArchivedProof `json:"proofs"`
+	// Hash the SHA-256 hash of the archive, computed with an empty hash.
+	Hash ethcmn.Hash `json:"hash"`
+}
+
+// ArchivedProof a source proof, along with the event it emitted and its source transaction metadata.
+type ArchivedProof struct {
+	ProofNonce     int64       `json:"proof_nonce"`
+	StartBlock     uint64      `json:"start_block"`
+	EndBlock       uint64      `json:"end_block"`
+	DataCommitment ethcmn.Hash `json:"data_commitment"`
+	// TargetHeader the header hash the source contract committed to at the end block. It's archived for the
+	// replayed state to be verified without decoding the proof output, which is unknown for unchecked circuits.
+	TargetHeader      ethcmn.Hash  `json:"target_header"`
+	SourceTxHash      ethcmn.Hash  `json:"source_tx_hash"`
+	SourceBlockNumber uint64       `json:"source_block_number"`
+	SourceBlockHash   ethcmn.Hash  `json:"source_block_hash"`
+	SourceBlockTime   uint64       `json:"source_block_time"`
+	SourceLogIndex    uint         `json:"source_log_index"`
+	Call              ArchivedCall `json:"call"`
+}
+
+// ArchivedCall the decoded source fulfillCall arguments of a proof.
+type ArchivedCall struct {
+	FunctionID      ethcmn.Hash    `json:"function_id"`
+	Input           hexutil.Bytes  `json:"input"`
+	Output          hexutil.Bytes  `json:"output"`
+	Proof           hexutil.Bytes  `json:"proof"`
+	CallbackAddress ethcmn.Address `json:"callback_address"`
+	CallbackData    hexutil.Bytes  `json:"callback_data"`
+}
+
+// LoadArchive reads the proofs archive at the provided path, and checks its version and hash.
+func LoadArchive(path string) (*Archive, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var archive Archive
+	if err := json.Unmarshal(bz, &archive); err != nil {
+		return nil, fmt.Errorf("couldn't decode the proofs archive %s: %w", path, err)
+	}
+	if archive.Version != ArchiveVersion {
+		return nil, fmt.Errorf("unsupported proofs archive version %d, expected %d", archive.Version, ArchiveVersion)
+	}
+	hash, err := archive.contentHash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != archive.Hash {
+		return nil, fmt.Errorf("the proofs archive %s hash %s doesn't match its content hash %s", path, archive.Hash.Hex(), hash.Hex())
+	}
+	return &archive, nil
+}
+
+// Write hashes the archive and writes it to the provided path.
+func (a *Archive) Write(path string) error {
+	hash, err := a.contentHash()
+	if err != nil {
+		return err
+	}
+	a.Hash = hash
+	bz, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bz, '\n'), 0o644)
+}
+
+// LatestBlock returns the latest block the archived proofs commit to.
+func (a *Archive) LatestBlock() uint64 {
+	var latestBlock uint64
+	for _, proof := range a.Proofs {
+		if proof.EndBlock > latestBlock {
+			latestBlock = proof.EndBlock
+		}
+	}
+	return latestBlock
+}
+
+// contentHash computes the SHA-256 hash of the archive encoding, with an empty hash.
+func (a *Archive) contentHash() (ethcmn.Hash, error) {
+	unhashed := *a
+	unhashed.Hash = ethcmn.Hash{}
+	bz, err := json.Marshal(unhashed)
+	if err != nil {
+		return ethcmn.Hash{}, err
+	}
+	return sha256.Sum256(bz), nil
+}
+
+// event returns the data commitment stored event emitted by the source contract for the proof.
+func (p ArchivedProof) event(sourceContract ethcmn.Address) blobstreamxwrapper.BlobstreamXDataCommitmentStored {
+	return blobstreamxwrapper.BlobstreamXDataCommitmentStored{
+		ProofNonce:     big.NewInt(p.ProofNonce),
+		StartBlock:     p.StartBlock,
+		EndBlock:       p.EndBlock,
+		DataCommitment: p.DataCommitment,
+		Raw: coregethtypes.Log{
+			Address:     sourceContract,
+			TxHash:      p.SourceTxHash,
+			BlockNumber: p.SourceBlockNumber,
+			BlockHash:   p.SourceBlockHash,
+			Index:       p.SourceLogIndex,
+		},
+	}
+}
+
+func (c ArchivedCall) args() fulfillCallArgs {
+	return fulfillCallArgs{
+		FunctionID:      c.FunctionID,
+		Input:           c.Input,
+		Output:          c.Output,
+		Proof:           c.Proof,
+		CallbackAddress: c.CallbackAddress,
+		CallbackData:    c.CallbackData,
+	}
+}
+
+// ExportArchive decodes the proofs of the source contract starting at the provided height, or all of them
+// if it's zero, and returns them as an archive. The header range and next header proofs are checked against
+// their events, and against the previous proof they build on. The proofs of other circuits are archived
+// unchecked, they're checked when replayed depending on the configured circuits encodings.
+func ExportArchive(
+	ctx context.Context,
+	logger tmlog.Logger,
+	config Config,
+	sourceEVMClient *ethclient.Client,
+	fromHeight uint64,
+) (*Archive, error) {
+	sourceContract := ethcmn.HexToAddress(config.SourceBlobstreamContractAddress)
+	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(sourceContract, sourceEVMClient)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	sourceGateway := ethcmn.HexToAddress(config.SourceChainGatewayAddress)
+	extractor, err := fulfillcall.NewExtractor(sourceGateway, sourceEVMClient.Client())
+	if err != nil {
+		return nil, err
+	}
+	source := &chainSource{
+		logger:      logger,
+		client:      sourceEVMClient,
+		blobstreamX: sourceBlobstreamX,
+		extractor:   extractor,
+		filterRange: config.FilterRange,
+	}
+
+	chainID, err := sourceEVMClient.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Ethereum chain ID: %w", err)
+	}
+	headerRangeFunctionID, err := sourceBlobstreamX.HeaderRangeFunctionId(opts)
+	if err != nil {
+		return nil, err
+	}
+	nextHeaderFunctionID, err := sourceBlobstreamX.NextHeaderFunctionId(opts)
+	if err != nil {
+		return nil, err
+	}
+	source.circuits = circuits{
+		headerRangeFunctionID: CircuitHeaderRange,
+		nextHeaderFunctionID:  CircuitNextHeader,
+	}
+
+	events, err := source.events(ctx, fromHeight)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]blobstreamxwrapper.BlobstreamXDataCommitmentStored, 0, len(events))
+	for _, event := range events {
+		if event.StartBlock >= fromHeight {
+			sorted = append(sorted, event)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ProofNonce.Cmp(sorted[j].ProofNonce) < 0
+	})
+
+	archive := &Archive{
+		Version:               ArchiveVersion,
+		SourceChainID:         chainID,
+		SourceContract:        sourceContract,
+		SourceGateway:         sourceGateway,
+		HeaderRangeFunctionID: headerRangeFunctionID,
+		NextHeaderFunctionID:  nextHeaderFunctionID,
+		ExportedAt:            time.Now().UTC().Truncate(time.Second),
+		Proofs:                make([]ArchivedProof, 0, len(sorted)),
+	}
+	var previous *circuitIO
+	for i := range sorted {
+		event := &sorted[i]
+		args, err := source.call(ctx, event)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get the proof of nonce %d: %w", event.ProofNonce.Int64(), err)
+		}
+		targetHeader, err := source.headerHash(ctx, event.EndBlock)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := source.circuits[args.FunctionID]; ok {
+			io, err := source.circuits.decode(args)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't decode the proof of nonce %d: %w", event.ProofNonce.Int64(), err)
+			}
+			if err := matchEvent(event, io); err != nil {
+				return nil, fmt.Errorf("proof of nonce %d: %w", event.ProofNonce.Int64(), err)
+			}
+			if previous != nil && previous.TargetBlock == io.TrustedBlock && previous.TargetHeader != io.TrustedHeader {
+				return nil, fmt.Errorf("the proof of nonce %d doesn't build on the header committed by the previous proof", event.ProofNonce.Int64())
+			}
+			if io.TargetHeader != targetHeader {
+				return nil, fmt.Errorf("the proof of nonce %d target header doesn't match the source contract header at height %d", event.ProofNonce.Int64(), event.EndBlock)
+			}
+			previous = &io
+		} else {
+			logger.Info("archiving the proof of another circuit unchecked", "nonce", event.ProofNonce.Int64(), "function_id", ethcmn.Hash(args.FunctionID).Hex())
+			previous = nil
+		}
+		blockTime, err := source.eventTime(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+
+		archive.Proofs = append(archive.Proofs, ArchivedProof{
+			ProofNonce:        event.ProofNonce.Int64(),
+			StartBlock:        event.StartBlock,
+			EndBlock:          event.EndBlock,
+			DataCommitment:    event.DataCommitment,
+			TargetHeader:      targetHeader,
+			SourceTxHash:      event.Raw.TxHash,
+			SourceBlockNumber: event.Raw.BlockNumber,
+			SourceBlockHash:   event.Raw.BlockHash,
+			SourceBlockTime:   uint64(blockTime.Unix()),
+			SourceLogIndex:    event.Raw.Index,
+			Call: ArchivedCall{
+				FunctionID:      args.FunctionID,
+				Input:           args.Input,
+				Output:          args.Output,
+				Proof:           args.Proof,
+				CallbackAddress: args.CallbackAddress,
+				CallbackData:    args.CallbackData,
+			},
+		})
+		logger.Info("exported the proof", "nonce", event.ProofNonce.Int64(), "start_block", event.StartBlock, "end_block", event.EndBlock)
+	}
+	return archive, nil
+}
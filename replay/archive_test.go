@@ -0,0 +1,189 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// exportSimArchive commits the header ranges ending at the end blocks to the source chain, and exports
+// its proofs.
+func exportSimArchive(ctx context.Context, t *testing.T, source *simchain.Chain, key *ecdsa.PrivateKey, endBlocks ...uint64) *Archive {
+	t.Helper()
+	start := uint64(simchain.GenesisHeight)
+	for _, end := range endBlocks {
+		source.CommitHeaderRange(ctx, t, key, start, end)
+		start = end
+	}
+	client, err := ethclient.Dial("http://" + source.Endpoint)
+	require.NoError(t, err)
+	defer client.Close()
+	archive, err := ExportArchive(ctx, tmlog.NewNopLogger(), Config{
+		SourceBlobstreamContractAddress: source.Manifest.BlobstreamX.Hex(),
+		SourceChainGatewayAddress:       source.Manifest.Gateway.Hex(),
+		FilterRange:                     5000,
+	}, client, 0)
+	require.NoError(t, err)
+	return archive
+}
+
+// TestReplayArchiveUncheckedCircuit replays an archive whose proofs are of an unchecked circuit. The replayed
+// state is verified against the header hashes carried by the archive.
+func TestReplayArchiveUncheckedCircuit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	target.Mine(t, 50*time.Millisecond)
+	archive := exportSimArchive(ctx, t, source, key, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+	for _, proof := range archive.Proofs {
+		assert.Equal(t, simchain.HeaderHash(proof.EndBlock), [32]byte(proof.TargetHeader))
+	}
+
+	replayer := newSimReplayer(t, source, target, key, Config{
+		Archive:  archive,
+		Circuits: map[[32]byte]CircuitEncoding{source.Manifest.HeaderRangeFunctionID: CircuitUnchecked},
+	})
+	require.NoError(t, replayer.Catchup(ctx))
+	assert.Equal(t, uint64(simchain.GenesisHeight+20), target.LatestBlock(ctx, t))
+}
+
+func TestLoadArchive(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	archive := exportSimArchive(ctx, t, source, key, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+	require.Len(t, archive.Proofs, 2)
+
+	tests := []struct {
+		name string
+		// write writes the archive to the path, editing it.
+		write   func(t *testing.T, path string, archive Archive)
+		wantErr string
+	}{
+		{
+			name: "round trip",
+			write: func(t *testing.T, path string, archive Archive) {
+				require.NoError(t, archive.Write(path))
+			},
+		},
+		{
+			name: "tampered content",
+			write: func(t *testing.T, path string, archive Archive) {
+				require.NoError(t, archive.Write(path))
+				bz, err := os.ReadFile(path)
+				require.NoError(t, err)
+				dataCommitment := archive.Proofs[1].DataCommitment.Hex()
+				require.Contains(t, string(bz), dataCommitment)
+				bz = bytes.Replace(bz, []byte(dataCommitment), []byte(archive.Proofs[0].DataCommitment.Hex()), 1)
+				require.NoError(t, os.WriteFile(path, bz, 0o644))
+			},
+			wantErr: "doesn't match its content hash",
+		},
+		{
+			name: "version mismatch",
+			write: func(t *testing.T, path string, archive Archive) {
+				archive.Version = ArchiveVersion + 1
+				require.NoError(t, archive.Write(path))
+			},
+			wantErr: "unsupported proofs archive version 2, expected 1",
+		},
+		{
+			name: "invalid encoding",
+			write: func(t *testing.T, path string, _ Archive) {
+				require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+			},
+			wantErr: "couldn't decode the proofs archive",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "proofs-archive.json")
+			test.write(t, path, *archive)
+			loaded, err := LoadArchive(path)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEqual(t, ethcmn.Hash{}, loaded.Hash)
+			unhashed := *loaded
+			unhashed.Hash = ethcmn.Hash{}
+			assert.Equal(t, *archive, unhashed)
+			assert.Equal(t, uint64(simchain.GenesisHeight+20), loaded.LatestBlock())
+		})
+	}
+}
+
+// TestReplayArchive exports the source proofs, then replays them from the loaded archive. A proof not
+// building on the header committed by the previous one is rejected.
+func TestReplayArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		// edit edits the exported archive before it's written.
+		edit            func(archive *Archive)
+		wantLatestBlock uint64
+		wantErr         string
+	}{
+		{
+			name:            "replayed",
+			wantLatestBlock: simchain.GenesisHeight + 20,
+		},
+		{
+			name: "broken proof chain",
+			edit: func(archive *Archive) {
+				input := append([]byte{}, archive.Proofs[1].Call.Input...)
+				// the trusted header follows the trusted block
+				input[8]++
+				archive.Proofs[1].Call.Input = input
+			},
+			wantLatestBlock: simchain.GenesisHeight + 10,
+			wantErr:         "doesn't match the target contract header",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			source := simchain.New(ctx, t, key)
+			target := simchain.New(ctx, t, key)
+			target.Mine(t, 50*time.Millisecond)
+			archive := exportSimArchive(ctx, t, source, key, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+			if test.edit != nil {
+				test.edit(archive)
+			}
+			path := filepath.Join(t.TempDir(), "proofs-archive.json")
+			require.NoError(t, archive.Write(path))
+			loaded, err := LoadArchive(path)
+			require.NoError(t, err)
+
+			replayer := newSimReplayer(t, source, target, key, Config{Archive: loaded})
+			err = replayer.Catchup(ctx)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.wantLatestBlock, target.LatestBlock(ctx, t))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/celestiaorg/blobstream-ops/auditlog"
+)
+
+// appendAuditEntry appends an entry of the provided kind about the proof currently being replayed
+// to the audit log, if enabled. The errors are only logged to not stop the replay.
+func (r *Replayer) appendAuditEntry(ctx context.Context, kind string, targetTxHash string) {
+	if r.auditLog == nil {
+		return
+	}
+	proof := r.state.snapshot().CurrentProof
+	if proof == nil {
+		return
+	}
+	entry := auditlog.Entry{
+		Kind:                  kind,
+		ChainID:               r.targetChainID(ctx),
+		Contract:              r.config.TargetBlobstreamContractAddress,
+		SourceContract:        r.config.SourceBlobstreamContractAddress,
+		ProofNonce:            proof.Nonce,
+		StartBlock:            proof.StartBlock,
+		EndBlock:              proof.EndBlock,
+		DataCommitment:        proof.DataCommitment,
+		TrustedDataCommitment: proof.TrustedDataCommitment,
+		SourceTxHash:          proof.SourceTxHash,
+		TargetTxHash:          targetTxHash,
+	}
+	if proof.TrustedDataCommitment != "" {
+		entry.TrustedEndpoint = r.config.CoreRPC
+	}
+	if err := r.auditLog.Append(entry); err != nil {
+		r.logger.Error("couldn't append to the audit log", "kind", kind, "nonce", proof.Nonce, "err", err.Error())
+	}
+}
+
+// targetChainID returns the target chain ID, or an empty string if it can't be queried.
+func (r *Replayer) targetChainID(ctx context.Context) string {
+	if r.chainID != "" {
+		return r.chainID
+	}
+	chainID, err := r.targetEVMClient.ChainID(ctx)
+	if err != nil {
+		r.logger.Debug("couldn't get the target chain ID", "err", err.Error())
+		return ""
+	}
+	r.chainID = chainID.String()
+	return r.chainID
+}
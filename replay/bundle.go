@@ -0,0 +1,330 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/celestiaorg/blobstream-ops/tracing"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// BundleVersion the version of the bundle format.
+	BundleVersion = 1
+
+	// bundleWaitTimeout the time to wait for a broadcast bundle transaction to be included. The signed
+	// transactions can't be accelerated.
+	bundleWaitTimeout = 10 * time.Minute
+)
+
+// Bundle the proof submission transactions prepared for an offline signer. The transactions are prepared
+// unsigned by `replay prepare`, signed offline by `replay sign`, then broadcast in order by `replay broadcast`.
+type Bundle struct {
+	Version        int            `json:"version"`
+	ChainID        *big.Int       `json:"chain_id"`
+	From           ethcmn.Address `json:"from"`
+	TargetContract ethcmn.Address `json:"target_contract"`
+	Gateway        ethcmn.Address `json:"gateway"`
+	CreatedAt      time.Time      `json:"created_at"`
+	Transactions   []BundleTx     `json:"transactions"`
+}
+
+// BundleTx a proof submission transaction of a bundle.
+type BundleTx struct {
+	ProofNonce     int64         `json:"proof_nonce"`
+	StartBlock     uint64        `json:"start_block"`
+	EndBlock       uint64        `json:"end_block"`
+	DataCommitment string        `json:"data_commitment"`
+	SourceTxHash   ethcmn.Hash   `json:"source_tx_hash"`
+	Nonce          uint64        `json:"nonce"`
+	GasLimit       uint64        `json:"gas_limit"`
+	GasPrice       *big.Int      `json:"gas_price"`
+	Data           hexutil.Bytes `json:"data"`
+	// Signed the signed transaction, set once the bundle is signed.
+	Signed hexutil.Bytes `json:"signed,omitempty"`
+}
+
+// PrepareConfig the configuration of the prepared transactions.
+type PrepareConfig struct {
+	// From the address of the offline signer.
+	From ethcmn.Address
+	// GasPrice the gas price of the transactions. If nil, the gas price suggested by the target chain is used.
+	GasPrice *big.Int
+}
+
+// LoadBundle reads the bundle at the provided path.
+func LoadBundle(path string) (Bundle, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return Bundle{}, err
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(bz, &bundle); err != nil {
+		return Bundle{}, fmt.Errorf("couldn't decode the bundle %s: %w", path, err)
+	}
+	if bundle.Version != BundleVersion {
+		return Bundle{}, fmt.Errorf("unsupported bundle version %d, expected %d", bundle.Version, BundleVersion)
+	}
+	if bundle.ChainID == nil {
+		return Bundle{}, fmt.Errorf("the bundle %s has no chain ID", path)
+	}
+	return bundle, nil
+}
+
+// Write writes the bundle to the provided path.
+func (b Bundle) Write(path string) error {
+	bz, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bz, '\n'), 0o644)
+}
+
+// Signed returns true if all the bundle transactions are signed.
+func (b Bundle) Signed() bool {
+	for _, tx := range b.Transactions {
+		if len(tx.Signed) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unsignedTx returns the unsigned transaction described by the bundle transaction.
+func (b Bundle) unsignedTx(tx BundleTx) *coregethtypes.Transaction {
+	to := b.Gateway
+	return coregethtypes.NewTx(&coregethtypes.LegacyTx{
+		Nonce:    tx.Nonce,
+		To:       &to,
+		Gas:      tx.GasLimit,
+		GasPrice: tx.GasPrice,
+		Data:     tx.Data,
+	})
+}
+
+// signedTx decodes the signed transaction, and checks that it's the bundle transaction signed by the
+// bundle signer.
+func (b Bundle) signedTx(tx BundleTx) (*coregethtypes.Transaction, error) {
+	if len(tx.Signed) == 0 {
+		return nil, fmt.Errorf("the transaction of proof nonce %d isn't signed", tx.ProofNonce)
+	}
+	signed := new(coregethtypes.Transaction)
+	if err := signed.UnmarshalBinary(tx.Signed); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction of proof nonce %d: %w", tx.ProofNonce, err)
+	}
+	txSigner := coregethtypes.LatestSignerForChainID(b.ChainID)
+	if txSigner.Hash(signed) != txSigner.Hash(b.unsignedTx(tx)) {
+		return nil, fmt.Errorf("the signed transaction of proof nonce %d doesn't match the prepared one", tx.ProofNonce)
+	}
+	sender, err := coregethtypes.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of the transaction of proof nonce %d: %w", tx.ProofNonce, err)
+	}
+	if sender != b.From {
+		return nil, fmt.Errorf("the transaction of proof nonce %d is signed by %s instead of %s", tx.ProofNonce, sender.Hex(), b.From.Hex())
+	}
+	return signed, nil
+}
+
+// Sign signs the bundle transactions. It doesn't need any connection to the target chain.
+func (b *Bundle) Sign(ctx context.Context, txSigner signer.Signer) error {
+	if txSigner.Address() != b.From {
+		return fmt.Errorf("the bundle is prepared for %s, but the signer address is %s", b.From.Hex(), txSigner.Address().Hex())
+	}
+	for i, tx := range b.Transactions {
+		signed, err := txSigner.SignTx(ctx, b.unsignedTx(tx), b.ChainID)
+		if err != nil {
+			return fmt.Errorf("couldn't sign the transaction of proof nonce %d: %w", tx.ProofNonce, err)
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		b.Transactions[i].Signed = raw
+	}
+	return nil
+}
+
+// Prepare plans and decodes the proofs needed for the target contract to reach the latest block of the
+// source contract, like the catchup, and returns their unsigned submission transactions. The proofs are
+// checked and verified the same way, except that each one builds on the target header of the previous
+// proof, as they aren't submitted yet.
+func (r *Replayer) Prepare(ctx context.Context, config PrepareConfig) (_ Bundle, err error) {
+	ctx, span := tracing.Start(ctx, "replay.prepare")
+	defer func() { tracing.End(span, err) }()
+
+	latestSourceContractBlock, err := r.latestSourceBlock(ctx)
+	if err != nil {
+		return Bundle{}, err
+	}
+	latestTargetContractBlock, err := r.latestTargetBlock(ctx, latestSourceContractBlock)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	chainID, err := r.targetEVMClient.ChainID(ctx)
+	if err != nil {
+		return Bundle{}, r.fail(metrics.FailureRPC, fmt.Errorf("failed to get Ethereum chain ID: %w", err))
+	}
+	nonce, err := r.targetEVMClient.PendingNonceAt(ctx, config.From)
+	if err != nil {
+		return Bundle{}, r.fail(metrics.FailureRPC, err)
+	}
+	gasPrice := config.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = r.targetEVMClient.SuggestGasPrice(ctx)
+		if err != nil {
+			return Bundle{}, r.fail(metrics.FailureRPC, fmt.Errorf("failed to get Ethereum gas estimate: %w", err))
+		}
+	}
+	bundle := Bundle{
+		Version:        BundleVersion,
+		ChainID:        chainID,
+		From:           config.From,
+		TargetContract: ethcmn.HexToAddress(r.config.TargetBlobstreamContractAddress),
+		Gateway:        ethcmn.HexToAddress(r.config.TargetChainGatewayAddress),
+		CreatedAt:      time.Now().UTC(),
+		Transactions:   []BundleTx{},
+	}
+
+	if latestTargetContractBlock >= latestSourceContractBlock {
+		r.logger.Info("target contract is already up to date", "latest_target_contract_block", latestTargetContractBlock)
+		return bundle, nil
+	}
+	r.logger.Info("preparing the catchup transactions", "latest_source_contract_block", latestSourceContractBlock, "latest_target_contract_block", latestTargetContractBlock)
+
+	dataCommitmentEvents, err := r.source.events(ctx, latestTargetContractBlock)
+	if err != nil {
+		return Bundle{}, r.fail(metrics.FailureRPC, err)
+	}
+
+	defer r.clearCurrentProof()
+	// the first proof builds on the target contract header, the next ones on the previous proof target header
+	var trustedHeader *[32]byte
+	for startHeight := latestTargetContractBlock; startHeight < latestSourceContractBlock; {
+		event, exists := dataCommitmentEvents[int64(startHeight)]
+		if !exists {
+			return Bundle{}, fmt.Errorf("couldn't find a proof that starts at height %d in events", startHeight)
+		}
+		r.setCurrentProof(&event)
+
+		if trustedHeader == nil {
+			err = r.verifyProof(ctx, &event, startHeight)
+		} else {
+			err = r.verifyPreparedProof(ctx, &event, *trustedHeader)
+		}
+		if err != nil {
+			return Bundle{}, err
+		}
+
+		decodedArgs, io, err := r.decodeProof(ctx, &event, trustedHeader, "startHeight", startHeight)
+		if err != nil {
+			return Bundle{}, err
+		}
+		if io == nil {
+			// the next proof builds on the target header of this one, which is read from its output
+			return Bundle{}, fmt.Errorf("the proof of nonce %d is of an unchecked circuit, which can't be prepared as the next proofs can't be checked against it", event.ProofNonce.Int64())
+		}
+		data, err := r.fulfillCallData(decodedArgs)
+		if err != nil {
+			return Bundle{}, err
+		}
+
+		bundle.Transactions = append(bundle.Transactions, BundleTx{
+			ProofNonce:     event.ProofNonce.Int64(),
+			StartBlock:     event.StartBlock,
+			EndBlock:       event.EndBlock,
+			DataCommitment: hex.EncodeToString(event.DataCommitment[:]),
+			SourceTxHash:   event.Raw.TxHash,
+			Nonce:          nonce,
+			GasLimit:       replayGasLimit,
+			GasPrice:       gasPrice,
+			Data:           data,
+		})
+		r.logger.Info("prepared the proof transaction", "nonce", event.ProofNonce.Int64(), "start_block", event.StartBlock, "end_block", event.EndBlock, "tx_nonce", nonce)
+		nonce++
+		trustedHeader = &io.TargetHeader
+		startHeight = event.EndBlock
+	}
+	return bundle, nil
+}
+
+// BroadcastBundle sends the signed bundle transactions in order, waiting for each one to be included before
+// sending the next one. The transactions already included are skipped, so that an interrupted broadcast can
+// be resumed.
+func BroadcastBundle(ctx context.Context, logger tmlog.Logger, targetEVMClient *ethclient.Client, bundle Bundle) error {
+	chainID, err := targetEVMClient.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get Ethereum chain ID: %w", err)
+	}
+	if chainID.Cmp(bundle.ChainID) != 0 {
+		return fmt.Errorf("the bundle is prepared for chain ID %s, but the target chain ID is %s", bundle.ChainID, chainID)
+	}
+	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamXCaller(bundle.TargetContract, targetEVMClient)
+	if err != nil {
+		return err
+	}
+
+	// all the signatures are checked before sending anything
+	signedTxs := make([]*coregethtypes.Transaction, len(bundle.Transactions))
+	for i, tx := range bundle.Transactions {
+		signedTxs[i], err = bundle.signedTx(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, tx := range bundle.Transactions {
+		signed := signedTxs[i]
+		logger.Info("sending transaction", "proof_nonce", tx.ProofNonce, "start_block", tx.StartBlock, "end_block", tx.EndBlock, "hash", signed.Hash().Hex())
+		err := targetEVMClient.SendTransaction(ctx, signed)
+		switch {
+		case err == nil:
+		case strings.Contains(err.Error(), "already known"):
+			logger.Info("transaction already pending", "proof_nonce", tx.ProofNonce, "hash", signed.Hash().Hex())
+		case strings.Contains(err.Error(), "nonce too low"):
+			// the transaction was included by a previous broadcast, unless its nonce was used by another one
+			if _, err := targetEVMClient.TransactionReceipt(ctx, signed.Hash()); err != nil {
+				return fmt.Errorf("the nonce %d of the transaction of proof nonce %d is already used, the bundle should be prepared again", tx.Nonce, tx.ProofNonce)
+			}
+			logger.Info("transaction already included", "proof_nonce", tx.ProofNonce, "hash", signed.Hash().Hex())
+		default:
+			return fmt.Errorf("couldn't send the transaction of proof nonce %d: %w", tx.ProofNonce, err)
+		}
+
+		receipt, err := waitForTransaction(ctx, logger, targetEVMClient, signed.Hash(), bundleWaitTimeout)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("the transaction %s of proof nonce %d wasn't included in %s, the bundle should be prepared again with a higher gas price", signed.Hash().Hex(), tx.ProofNonce, bundleWaitTimeout)
+			}
+			return err
+		}
+		if receipt.Status == coregethtypes.ReceiptStatusSuccessful {
+			continue
+		}
+		latestBlock, err := targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return err
+		}
+		if latestBlock < tx.EndBlock {
+			return fmt.Errorf("the transaction %s of proof nonce %d reverted", signed.Hash().Hex(), tx.ProofNonce)
+		}
+		logger.Info("the transaction reverted but the target contract already committed to the proof range", "proof_nonce", tx.ProofNonce, "hash", signed.Hash().Hex())
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// lockedBuffer a buffer safe for concurrent use, collecting the logs of a broadcast.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// prepareSimBundle commits the header ranges ending at the end blocks to the source chain, and prepares
+// the bundle catching up the target chain, whose transactions are signed by the key.
+func prepareSimBundle(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, source, target *simchain.Chain, config Config, endBlocks ...uint64) (Bundle, error) {
+	t.Helper()
+	start := uint64(simchain.GenesisHeight)
+	for _, end := range endBlocks {
+		source.CommitHeaderRange(ctx, t, key, start, end)
+		start = end
+	}
+	replayer := newSimReplayer(t, source, target, key, config)
+	return replayer.Prepare(ctx, PrepareConfig{From: crypto.PubkeyToAddress(key.PublicKey)})
+}
+
+func TestBundleSignedTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bundle := Bundle{
+		Version: BundleVersion,
+		ChainID: big.NewInt(simchain.ChainID),
+		From:    crypto.PubkeyToAddress(key.PublicKey),
+		Gateway: ethcmn.Address{1},
+		Transactions: []BundleTx{{
+			ProofNonce: 1,
+			Nonce:      3,
+			GasLimit:   replayGasLimit,
+			GasPrice:   big.NewInt(1e9),
+			Data:       []byte{1, 2, 3, 4},
+		}},
+	}
+	// sign signs the bundle transaction after applying the edit, and returns the original transaction
+	// set with the signed one.
+	sign := func(t *testing.T, key *ecdsa.PrivateKey, chainID int64, edit func(tx *BundleTx)) BundleTx {
+		tx := bundle.Transactions[0]
+		edited := tx
+		edit(&edited)
+		signed, err := coregethtypes.SignTx(bundle.unsignedTx(edited), coregethtypes.LatestSignerForChainID(big.NewInt(chainID)), key)
+		require.NoError(t, err)
+		tx.Signed, err = signed.MarshalBinary()
+		require.NoError(t, err)
+		return tx
+	}
+
+	tests := []struct {
+		name    string
+		tx      func(t *testing.T) BundleTx
+		wantErr string
+	}{
+		{
+			name: "signed by the bundle signer",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, key, simchain.ChainID, func(*BundleTx) {})
+			},
+		},
+		{
+			name: "not signed",
+			tx: func(*testing.T) BundleTx {
+				return bundle.Transactions[0]
+			},
+			wantErr: "the transaction of proof nonce 1 isn't signed",
+		},
+		{
+			name: "invalid encoding",
+			tx: func(*testing.T) BundleTx {
+				tx := bundle.Transactions[0]
+				tx.Signed = []byte{1, 2, 3}
+				return tx
+			},
+			wantErr: "invalid signed transaction of proof nonce 1",
+		},
+		{
+			name: "different nonce",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, key, simchain.ChainID, func(tx *BundleTx) { tx.Nonce++ })
+			},
+			wantErr: "doesn't match the prepared one",
+		},
+		{
+			name: "different gas price",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, key, simchain.ChainID, func(tx *BundleTx) { tx.GasPrice = big.NewInt(2e9) })
+			},
+			wantErr: "doesn't match the prepared one",
+		},
+		{
+			name: "different data",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, key, simchain.ChainID, func(tx *BundleTx) { tx.Data = []byte{4, 3, 2, 1} })
+			},
+			wantErr: "doesn't match the prepared one",
+		},
+		{
+			name: "different chain ID",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, key, simchain.ChainID+1, func(*BundleTx) {})
+			},
+			wantErr: "invalid signature of the transaction of proof nonce 1",
+		},
+		{
+			name: "different sender",
+			tx: func(t *testing.T) BundleTx {
+				return sign(t, otherKey, simchain.ChainID, func(*BundleTx) {})
+			},
+			wantErr: "is signed by " + crypto.PubkeyToAddress(otherKey.PublicKey).Hex(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tx := test.tx(t)
+			signed, err := bundle.signedTx(tx)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			raw, err := signed.MarshalBinary()
+			require.NoError(t, err)
+			assert.Equal(t, tx.Signed, hexutil.Bytes(raw))
+		})
+	}
+}
+
+// TestPrepare prepares the catchup of proofs building on each other, and checks that each one builds on
+// the target header of the previous one.
+func TestPrepare(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	endBlocks := []uint64{simchain.GenesisHeight + 10, simchain.GenesisHeight + 20, simchain.GenesisHeight + 30}
+
+	bundle, err := prepareSimBundle(ctx, t, key, source, target, Config{}, endBlocks...)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(simchain.ChainID), bundle.ChainID)
+	assert.Equal(t, target.Manifest.Gateway, bundle.Gateway)
+	assert.Equal(t, target.Manifest.BlobstreamX, bundle.TargetContract)
+	require.Len(t, bundle.Transactions, len(endBlocks))
+
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+	require.NoError(t, err)
+	startBlock := uint64(simchain.GenesisHeight)
+	for i, tx := range bundle.Transactions {
+		assert.Equal(t, startBlock, tx.StartBlock)
+		assert.Equal(t, endBlocks[i], tx.EndBlock)
+		assert.Equal(t, bundle.Transactions[0].Nonce+uint64(i), tx.Nonce)
+		assert.Empty(t, tx.Signed)
+
+		values, err := gatewayABI.Methods["fulfillCall"].Inputs.Unpack(tx.Data[4:])
+		require.NoError(t, err)
+		assert.Equal(t, [32]byte(target.Manifest.HeaderRangeFunctionID), values[0])
+		io, err := decodeHeaderRangeIO(values[1].([]byte), values[2].([]byte))
+		require.NoError(t, err)
+		// the first proof builds on the target contract genesis header, the next ones on the previous proof
+		assert.Equal(t, simchain.HeaderHash(startBlock), io.TrustedHeader)
+		startBlock = tx.EndBlock
+	}
+}
+
+// TestPrepareUncheckedCircuit checks that proofs of unchecked circuits aren't prepared, as the next proofs
+// can't be checked against their target header.
+func TestPrepareUncheckedCircuit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+
+	_, err = prepareSimBundle(ctx, t, key, source, target, Config{
+		Circuits: map[[32]byte]CircuitEncoding{source.Manifest.HeaderRangeFunctionID: CircuitUnchecked},
+	}, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+	require.ErrorContains(t, err, "is of an unchecked circuit, which can't be prepared")
+}
+
+func TestBroadcastBundle(t *testing.T) {
+	tests := []struct {
+		name string
+		// edit edits the bundle before it's signed.
+		edit func(bundle *Bundle)
+		// before runs once the bundle is signed, before broadcasting it.
+		before func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, target *simchain.Chain, client *ethclient.Client, bundle Bundle)
+		// mineAfterLog holds the target chain mining until the broadcast logs the message.
+		mineAfterLog string
+		// wantLatestBlock the latest block of the target contract once broadcast.
+		wantLatestBlock uint64
+		wantErr         string
+	}{
+		{
+			name:            "broadcast",
+			wantLatestBlock: simchain.GenesisHeight + 20,
+		},
+		{
+			name: "first transaction already pending",
+			before: func(ctx context.Context, t *testing.T, _ *ecdsa.PrivateKey, _ *simchain.Chain, client *ethclient.Client, bundle Bundle) {
+				signed, err := bundle.signedTx(bundle.Transactions[0])
+				require.NoError(t, err)
+				require.NoError(t, client.SendTransaction(ctx, signed))
+			},
+			mineAfterLog:    "transaction already pending",
+			wantLatestBlock: simchain.GenesisHeight + 20,
+		},
+		{
+			name: "first transaction already included",
+			before: func(ctx context.Context, t *testing.T, _ *ecdsa.PrivateKey, target *simchain.Chain, client *ethclient.Client, bundle Bundle) {
+				signed, err := bundle.signedTx(bundle.Transactions[0])
+				require.NoError(t, err)
+				require.NoError(t, client.SendTransaction(ctx, signed))
+				target.Backend.Commit()
+				require.Equal(t, uint64(simchain.GenesisHeight+10), target.LatestBlock(ctx, t))
+			},
+			mineAfterLog:    "transaction already included",
+			wantLatestBlock: simchain.GenesisHeight + 20,
+		},
+		{
+			name: "nonce used by another transaction",
+			before: func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, target *simchain.Chain, _ *ethclient.Client, bundle Bundle) {
+				// a transfer using the nonce of the first transaction
+				target.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					tx, err := opts.Signer(opts.From, coregethtypes.NewTx(&coregethtypes.LegacyTx{
+						Nonce:    bundle.Transactions[0].Nonce,
+						To:       &ethcmn.Address{1},
+						Gas:      21000,
+						GasPrice: bundle.Transactions[0].GasPrice,
+					}))
+					if err != nil {
+						return nil, err
+					}
+					return tx, target.Backend.Client().SendTransaction(ctx, tx)
+				})
+			},
+			wantLatestBlock: simchain.GenesisHeight,
+			wantErr:         "of the transaction of proof nonce 1 is already used",
+		},
+		{
+			name: "reverted transaction",
+			edit: func(bundle *Bundle) {
+				bundle.Transactions[1].Data = []byte{1, 2, 3, 4}
+			},
+			wantLatestBlock: simchain.GenesisHeight + 10,
+			wantErr:         "of proof nonce 2 reverted",
+		},
+		{
+			name: "different chain ID",
+			edit: func(bundle *Bundle) {
+				bundle.ChainID = big.NewInt(simchain.ChainID + 1)
+			},
+			wantLatestBlock: simchain.GenesisHeight,
+			wantErr:         "the bundle is prepared for chain ID 1338, but the target chain ID is 1337",
+		},
+		{
+			name: "tampered signed transaction",
+			before: func(_ context.Context, _ *testing.T, _ *ecdsa.PrivateKey, _ *simchain.Chain, _ *ethclient.Client, bundle Bundle) {
+				bundle.Transactions[1].Data[0]++
+			},
+			wantLatestBlock: simchain.GenesisHeight,
+			wantErr:         "the signed transaction of proof nonce 2 doesn't match the prepared one",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			source := simchain.New(ctx, t, key)
+			target := simchain.New(ctx, t, key)
+			bundle, err := prepareSimBundle(ctx, t, key, source, target, Config{}, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+			require.NoError(t, err)
+			require.Len(t, bundle.Transactions, 2)
+			if test.edit != nil {
+				test.edit(&bundle)
+			}
+			require.NoError(t, bundle.Sign(ctx, signer.NewKey(key)))
+			targetClient, err := ethclient.Dial("http://" + target.Endpoint)
+			require.NoError(t, err)
+			defer targetClient.Close()
+			if test.before != nil {
+				test.before(ctx, t, key, target, targetClient, bundle)
+			}
+
+			logs := &lockedBuffer{}
+			broadcast := make(chan error, 1)
+			go func() { broadcast <- BroadcastBundle(ctx, tmlog.NewTMLogger(logs), targetClient, bundle) }()
+			if test.mineAfterLog != "" {
+				require.Eventually(t, func() bool {
+					return bytes.Contains([]byte(logs.String()), []byte(test.mineAfterLog))
+				}, 10*time.Second, 10*time.Millisecond)
+			}
+			target.Mine(t, 50*time.Millisecond)
+			err = <-broadcast
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, test.wantLatestBlock, target.LatestBlock(ctx, t))
+		})
+	}
+}
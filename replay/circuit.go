@@ -0,0 +1,210 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+)
+
+const (
+	// headerRangeInputLength the length of the header range circuit input:
+	// abi.encodePacked(uint64 trustedBlock, bytes32 trustedHeader, uint64 targetBlock).
+	headerRangeInputLength = 8 + 32 + 8
+	// nextHeaderInputLength the length of the next header circuit input:
+	// abi.encodePacked(uint64 trustedBlock, bytes32 trustedHeader).
+	nextHeaderInputLength = 8 + 32
+	// circuitOutputLength the length of the header range and next header circuits output:
+	// abi.encode(bytes32 targetHeader, bytes32 dataCommitment).
+	circuitOutputLength = 32 + 32
+)
+
+// CircuitEncoding the encoding of the input and output of a circuit, used to check its proofs against their
+// events before replaying them.
+type CircuitEncoding string
+
+const (
+	// CircuitHeaderRange the header range circuit encoding.
+	CircuitHeaderRange CircuitEncoding = "header-range"
+	// CircuitNextHeader the next header circuit encoding.
+	CircuitNextHeader CircuitEncoding = "next-header"
+	// CircuitUnchecked the proofs of the circuit are replayed without checking their input and output
+	// against their events. They're only verified by the target gateway verifier, and by the target
+	// contract state verification once replayed.
+	CircuitUnchecked CircuitEncoding = "unchecked"
+)
+
+// ParseCircuitEncoding parses the name of a circuit encoding.
+func ParseCircuitEncoding(name string) (CircuitEncoding, error) {
+	switch encoding := CircuitEncoding(name); encoding {
+	case CircuitHeaderRange, CircuitNextHeader, CircuitUnchecked:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("unknown circuit encoding %q, expected one of %s, %s or %s", name, CircuitHeaderRange, CircuitNextHeader, CircuitUnchecked)
+	}
+}
+
+// errUncheckedCircuit returned when decoding the input and output of a circuit whose proofs aren't checked.
+var errUncheckedCircuit = errors.New("the circuit input and output are unchecked")
+
+// circuits maps the source function IDs to the encoding of their circuit input and output. The encoding of
+// the function IDs that aren't mapped is detected from their input length.
+type circuits map[[32]byte]CircuitEncoding
+
+// circuitIO the decoded input and output of the header range and next header circuits.
+type circuitIO struct {
+	TrustedBlock   uint64
+	TrustedHeader  [32]byte
+	TargetBlock    uint64
+	TargetHeader   [32]byte
+	DataCommitment [32]byte
+}
+
+// decodeHeaderRangeIO decodes the input and output of the header range circuit.
+func decodeHeaderRangeIO(input []byte, output []byte) (circuitIO, error) {
+	if len(input) != headerRangeInputLength {
+		return circuitIO{}, fmt.Errorf("invalid header range input length %d, expected %d", len(input), headerRangeInputLength)
+	}
+	io := circuitIO{
+		TrustedBlock: binary.BigEndian.Uint64(input[:8]),
+		TargetBlock:  binary.BigEndian.Uint64(input[40:48]),
+	}
+	copy(io.TrustedHeader[:], input[8:40])
+	if err := io.decodeOutput(output); err != nil {
+		return circuitIO{}, err
+	}
+	return io, nil
+}
+
+// decodeNextHeaderIO decodes the input and output of the next header circuit. The target block
+// is the one following the trusted block.
+func decodeNextHeaderIO(input []byte, output []byte) (circuitIO, error) {
+	if len(input) != nextHeaderInputLength {
+		return circuitIO{}, fmt.Errorf("invalid next header input length %d, expected %d", len(input), nextHeaderInputLength)
+	}
+	io := circuitIO{TrustedBlock: binary.BigEndian.Uint64(input[:8])}
+	io.TargetBlock = io.TrustedBlock + 1
+	copy(io.TrustedHeader[:], input[8:40])
+	if err := io.decodeOutput(output); err != nil {
+		return circuitIO{}, err
+	}
+	return io, nil
+}
+
+func (io *circuitIO) decodeOutput(output []byte) error {
+	if len(output) != circuitOutputLength {
+		return fmt.Errorf("invalid circuit output length %d, expected %d", len(output), circuitOutputLength)
+	}
+	copy(io.TargetHeader[:], output[:32])
+	copy(io.DataCommitment[:], output[32:64])
+	return nil
+}
+
+// selectCall returns the call proving the event range and data commitment, out of the fulfillCall calls
+// of the source transaction. If none matches, the first one is returned to be rejected by the checks.
+func (c circuits) selectCall(event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, calls []fulfillcall.Call) fulfillcall.Call {
+	for _, call := range calls {
+		io, err := c.decode(fulfillCallArgs(call))
+		if err == nil && io.TrustedBlock == event.StartBlock && io.TargetBlock == event.EndBlock && io.DataCommitment == event.DataCommitment {
+			return call
+		}
+	}
+	return calls[0]
+}
+
+// matchEvent checks that the circuit input and output of the proof correspond to the event range and data
+// commitment.
+func matchEvent(event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, io circuitIO) error {
+	if io.TrustedBlock != event.StartBlock || io.TargetBlock != event.EndBlock {
+		return fmt.Errorf("the proof range [%d, %d) doesn't match the event range [%d, %d)", io.TrustedBlock, io.TargetBlock, event.StartBlock, event.EndBlock)
+	}
+	if io.DataCommitment != event.DataCommitment {
+		return fmt.Errorf(
+			"the proof data commitment %s doesn't match the event data commitment %s",
+			hex.EncodeToString(io.DataCommitment[:]),
+			hex.EncodeToString(event.DataCommitment[:]),
+		)
+	}
+	return nil
+}
+
+// decode decodes the circuit input and output of the proof, using the encoding of its function ID. If the
+// function ID isn't mapped, the circuit is identified by its input encoding. errUncheckedCircuit is returned
+// if the circuit is unchecked.
+func (c circuits) decode(args fulfillCallArgs) (circuitIO, error) {
+	encoding, ok := c[args.FunctionID]
+	if !ok {
+		switch len(args.Input) {
+		case headerRangeInputLength:
+			encoding = CircuitHeaderRange
+		case nextHeaderInputLength:
+			encoding = CircuitNextHeader
+		default:
+			return circuitIO{}, fmt.Errorf(
+				"unknown circuit input encoding of length %d of the function ID %s",
+				len(args.Input),
+				hex.EncodeToString(args.FunctionID[:]),
+			)
+		}
+	}
+	switch encoding {
+	case CircuitHeaderRange:
+		return decodeHeaderRangeIO(args.Input, args.Output)
+	case CircuitNextHeader:
+		return decodeNextHeaderIO(args.Input, args.Output)
+	case CircuitUnchecked:
+		return circuitIO{}, errUncheckedCircuit
+	default:
+		return circuitIO{}, fmt.Errorf("unknown circuit encoding %q", encoding)
+	}
+}
+
+// checkCircuitIO decodes the circuit input and output of the proof, and checks that they correspond
+// to the event and build on the target contract trusted header. This makes sure a mismatched or
+// malicious source transaction is rejected before submitting it to the target chain.
+// If the trusted header is set, the proof should build on it instead of the target contract one, i.e.
+// when it builds on a proof not submitted yet.
+// The decoded input and output are returned, or nil for the proofs of unchecked circuits, which are accepted
+// as is.
+func (r *Replayer) checkCircuitIO(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, args fulfillCallArgs, trustedHeader *[32]byte) (*circuitIO, error) {
+	io, err := r.circuits.decode(args)
+	if errors.Is(err, errUncheckedCircuit) {
+		r.logger.Info("the proof circuit is unchecked, replaying it without checking its input and output", "function_id", hex.EncodeToString(args.FunctionID[:]))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.fail(metrics.FailureDecode, err)
+	}
+
+	if err := matchEvent(event, io); err != nil {
+		return nil, r.fail(metrics.FailureVerification, err)
+	}
+
+	if trustedHeader == nil {
+		targetHeader, err := r.targetBlobstreamX.BlockHeightToHeaderHash(&bind.CallOpts{Context: ctx}, io.TrustedBlock)
+		if err != nil {
+			return nil, r.fail(metrics.FailureRPC, err)
+		}
+		trustedHeader = &targetHeader
+	}
+	if !bytes.Equal(trustedHeader[:], io.TrustedHeader[:]) {
+		return nil, r.fail(
+			metrics.FailureVerification,
+			fmt.Errorf(
+				"the proof trusted header %s doesn't match the target contract header %s at height %d",
+				hex.EncodeToString(io.TrustedHeader[:]),
+				hex.EncodeToString(trustedHeader[:]),
+				io.TrustedBlock,
+			),
+		)
+	}
+	r.logger.Debug("proof circuit input and output match the event", "trusted_block", io.TrustedBlock, "target_block", io.TargetBlock, "target_header", hex.EncodeToString(io.TargetHeader[:]))
+	return &io, nil
+}
@@ -0,0 +1,321 @@
+package replay
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+)
+
+var (
+	headerRangeFunctionID = [32]byte{1}
+	nextHeaderFunctionID  = [32]byte{2}
+	uncheckedFunctionID   = [32]byte{3}
+	unmappedFunctionID    = [32]byte{4}
+)
+
+// testCircuits maps the test function IDs, leaving unmappedFunctionID to be detected from its input length.
+var testCircuits = circuits{
+	headerRangeFunctionID: CircuitHeaderRange,
+	nextHeaderFunctionID:  CircuitNextHeader,
+	uncheckedFunctionID:   CircuitUnchecked,
+}
+
+// headerRangeArgs returns the arguments of a header range proof from start to end of the simulated chain.
+func headerRangeArgs(functionID [32]byte, start, end uint64) fulfillCallArgs {
+	trustedHeader := simchain.HeaderHash(start)
+	input := binary.BigEndian.AppendUint64(nil, start)
+	input = append(input, trustedHeader[:]...)
+	input = binary.BigEndian.AppendUint64(input, end)
+	return fulfillCallArgs{FunctionID: functionID, Input: input, Output: circuitOutput(start, end)}
+}
+
+// nextHeaderArgs returns the arguments of a next header proof from start of the simulated chain.
+func nextHeaderArgs(functionID [32]byte, start uint64) fulfillCallArgs {
+	trustedHeader := simchain.HeaderHash(start)
+	input := binary.BigEndian.AppendUint64(nil, start)
+	input = append(input, trustedHeader[:]...)
+	return fulfillCallArgs{FunctionID: functionID, Input: input, Output: circuitOutput(start, start+1)}
+}
+
+// circuitOutput returns the circuit output of a proof from start to end of the simulated chain.
+func circuitOutput(start, end uint64) []byte {
+	targetHeader := simchain.HeaderHash(end)
+	commitment := simchain.DataCommitment(start, end)
+	return append(append([]byte{}, targetHeader[:]...), commitment[:]...)
+}
+
+// expectedIO returns the decoded input and output of a proof from start to end of the simulated chain.
+func expectedIO(start, end uint64) circuitIO {
+	return circuitIO{
+		TrustedBlock:   start,
+		TrustedHeader:  simchain.HeaderHash(start),
+		TargetBlock:    end,
+		TargetHeader:   simchain.HeaderHash(end),
+		DataCommitment: simchain.DataCommitment(start, end),
+	}
+}
+
+// dataCommitmentStored returns the event of a proof from start to end of the simulated chain.
+func dataCommitmentStored(start, end uint64) *blobstreamxwrapper.BlobstreamXDataCommitmentStored {
+	return &blobstreamxwrapper.BlobstreamXDataCommitmentStored{
+		StartBlock:     start,
+		EndBlock:       end,
+		DataCommitment: simchain.DataCommitment(start, end),
+	}
+}
+
+func TestCircuitsDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    fulfillCallArgs
+		want    circuitIO
+		wantErr string
+	}{
+		{
+			name: "header range",
+			args: headerRangeArgs(headerRangeFunctionID, 1000, 1010),
+			want: expectedIO(1000, 1010),
+		},
+		{
+			name: "next header",
+			args: nextHeaderArgs(nextHeaderFunctionID, 1000),
+			want: expectedIO(1000, 1001),
+		},
+		{
+			name: "unmapped header range",
+			args: headerRangeArgs(unmappedFunctionID, 1000, 1010),
+			want: expectedIO(1000, 1010),
+		},
+		{
+			name: "unmapped next header",
+			args: nextHeaderArgs(unmappedFunctionID, 1000),
+			want: expectedIO(1000, 1001),
+		},
+		{
+			name:    "unmapped unknown input length",
+			args:    fulfillCallArgs{FunctionID: unmappedFunctionID, Input: make([]byte, 47), Output: circuitOutput(1000, 1010)},
+			wantErr: "unknown circuit input encoding of length 47",
+		},
+		{
+			name:    "header range with a next header input",
+			args:    fulfillCallArgs{FunctionID: headerRangeFunctionID, Input: nextHeaderArgs(unmappedFunctionID, 1000).Input, Output: circuitOutput(1000, 1001)},
+			wantErr: "invalid header range input length 40, expected 48",
+		},
+		{
+			name: "truncated header range input",
+			args: func() fulfillCallArgs {
+				args := headerRangeArgs(headerRangeFunctionID, 1000, 1010)
+				args.Input = args.Input[:47]
+				return args
+			}(),
+			wantErr: "invalid header range input length 47, expected 48",
+		},
+		{
+			name: "truncated next header input",
+			args: func() fulfillCallArgs {
+				args := nextHeaderArgs(nextHeaderFunctionID, 1000)
+				args.Input = args.Input[:39]
+				return args
+			}(),
+			wantErr: "invalid next header input length 39, expected 40",
+		},
+		{
+			name: "truncated output",
+			args: func() fulfillCallArgs {
+				args := headerRangeArgs(headerRangeFunctionID, 1000, 1010)
+				args.Output = args.Output[:63]
+				return args
+			}(),
+			wantErr: "invalid circuit output length 63, expected 64",
+		},
+		{
+			name:    "unchecked",
+			args:    headerRangeArgs(uncheckedFunctionID, 1000, 1010),
+			wantErr: errUncheckedCircuit.Error(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			io, err := testCircuits.decode(test.args)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, io)
+		})
+	}
+}
+
+func TestMatchEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		io      circuitIO
+		wantErr string
+	}{
+		{
+			name: "matching",
+			io:   expectedIO(1000, 1010),
+		},
+		{
+			name:    "wrong start",
+			io:      func() circuitIO { io := expectedIO(1000, 1010); io.TrustedBlock = 1001; return io }(),
+			wantErr: "the proof range [1001, 1010) doesn't match the event range [1000, 1010)",
+		},
+		{
+			name:    "wrong end",
+			io:      func() circuitIO { io := expectedIO(1000, 1010); io.TargetBlock = 1011; return io }(),
+			wantErr: "the proof range [1000, 1011) doesn't match the event range [1000, 1010)",
+		},
+		{
+			name:    "wrong data commitment",
+			io:      func() circuitIO { io := expectedIO(1000, 1010); io.DataCommitment = [32]byte{1}; return io }(),
+			wantErr: "the proof data commitment 01",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := matchEvent(dataCommitmentStored(1000, 1010), test.io)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestSelectCall(t *testing.T) {
+	call := func(args fulfillCallArgs) fulfillcall.Call {
+		return fulfillcall.Call(args)
+	}
+	matching := call(headerRangeArgs(headerRangeFunctionID, 1000, 1010))
+	otherRange := call(headerRangeArgs(headerRangeFunctionID, 1010, 1020))
+	undecodable := call(fulfillCallArgs{FunctionID: unmappedFunctionID, Input: []byte{1}})
+	tests := []struct {
+		name  string
+		calls []fulfillcall.Call
+		want  fulfillcall.Call
+	}{
+		{
+			name:  "single matching call",
+			calls: []fulfillcall.Call{matching},
+			want:  matching,
+		},
+		{
+			name:  "batch where the second call matches",
+			calls: []fulfillcall.Call{otherRange, matching},
+			want:  matching,
+		},
+		{
+			name:  "batch where the first call can't be decoded",
+			calls: []fulfillcall.Call{undecodable, matching},
+			want:  matching,
+		},
+		{
+			name:  "no matching call",
+			calls: []fulfillcall.Call{otherRange, undecodable},
+			want:  otherRange,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, testCircuits.selectCall(dataCommitmentStored(1000, 1010), test.calls))
+		})
+	}
+}
+
+// TestCheckCircuitIO checks the proofs against the target contract initialized at the genesis height.
+func TestCheckCircuitIO(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	replayer := newSimReplayer(t, source, target, key, Config{})
+	replayer.circuits = testCircuits
+
+	const start, end = simchain.GenesisHeight, simchain.GenesisHeight + 10
+	pendingHeader := simchain.HeaderHash(end)
+	tests := []struct {
+		name  string
+		event *blobstreamxwrapper.BlobstreamXDataCommitmentStored
+		args  fulfillCallArgs
+		// trustedHeader the header of a pending proof the proof builds on, if any.
+		trustedHeader *[32]byte
+		want          *circuitIO
+		wantErr       string
+	}{
+		{
+			name:  "building on the target contract header",
+			event: dataCommitmentStored(start, end),
+			args:  headerRangeArgs(headerRangeFunctionID, start, end),
+			want:  func() *circuitIO { io := expectedIO(start, end); return &io }(),
+		},
+		{
+			name:  "building on a pending proof",
+			event: dataCommitmentStored(end, end+10),
+			args:  headerRangeArgs(headerRangeFunctionID, end, end+10),
+			// the target contract has no header at the end height yet
+			trustedHeader: &pendingHeader,
+			want:          func() *circuitIO { io := expectedIO(end, end+10); return &io }(),
+		},
+		{
+			name:    "not building on the target contract header",
+			event:   dataCommitmentStored(end, end+10),
+			args:    headerRangeArgs(headerRangeFunctionID, end, end+10),
+			wantErr: "doesn't match the target contract header",
+		},
+		{
+			name:    "wrong event range",
+			event:   dataCommitmentStored(start, end+1),
+			args:    headerRangeArgs(headerRangeFunctionID, start, end),
+			wantErr: "doesn't match the event range",
+		},
+		{
+			name:  "wrong output data commitment",
+			event: dataCommitmentStored(start, end),
+			args: func() fulfillCallArgs {
+				args := headerRangeArgs(headerRangeFunctionID, start, end)
+				args.Output = circuitOutput(start, end+1)
+				return args
+			}(),
+			wantErr: "doesn't match the event data commitment",
+		},
+		{
+			name:  "truncated output",
+			event: dataCommitmentStored(start, end),
+			args: func() fulfillCallArgs {
+				args := headerRangeArgs(headerRangeFunctionID, start, end)
+				args.Output = args.Output[:32]
+				return args
+			}(),
+			wantErr: "invalid circuit output length 32",
+		},
+		{
+			name:  "unchecked",
+			event: dataCommitmentStored(start, end+1),
+			args:  headerRangeArgs(uncheckedFunctionID, start, end),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			io, err := replayer.checkCircuitIO(ctx, test.event, test.args, test.trustedHeader)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, io)
+		})
+	}
+}
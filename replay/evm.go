@@ -2,20 +2,21 @@ package replay
 
 import (
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/celestiaorg/blobstream-ops/tracing"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcmn "github.com/ethereum/go-ethereum/common"
 	coregethtypes "github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	bindings2 "github.com/succinctlabs/blobstreamx/bindings"
-	"github.com/succinctlabs/succinctx/bindings"
 	tmlog "github.com/tendermint/tendermint/libs/log"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type fulfillCallArgs struct {
@@ -27,52 +28,13 @@ type fulfillCallArgs struct {
 	CallbackData    []byte         `json:"_callbackData"`
 }
 
-func toFulfillCallArgs(args map[string]interface{}) (fulfillCallArgs, error) {
-	fID, ok := args["_functionId"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _functionId in map")
-	}
-	input, ok := args["_input"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _input in map")
-	}
-	output, ok := args["_output"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _output in map")
-	}
-	proof, ok := args["_proof"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _proof in map")
-	}
-	callbackAddress, ok := args["_callbackAddress"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _callbackAddress in map")
-	}
-	callbackData, ok := args["_callbackData"]
-	if !ok {
-		return fulfillCallArgs{}, fmt.Errorf("couldn't find the _callbackData in map")
-	}
-
-	return fulfillCallArgs{
-		FunctionID:      fID.([32]byte),
-		Input:           input.([]byte),
-		Output:          output.([]byte),
-		Proof:           proof.([]byte),
-		CallbackAddress: callbackAddress.(ethcmn.Address),
-		CallbackData:    callbackData.([]byte),
-	}, nil
-}
+// replayGasLimit the gas limit of the proof submission transactions.
+const replayGasLimit = 25000000
 
 type transactOpsBuilder func(ctx context.Context, client *ethclient.Client, gasLim uint64) (*bind.TransactOpts, error)
 
-func newTransactOptsBuilder(privKey *ecdsa.PrivateKey) transactOpsBuilder {
-	publicKey := privKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		panic(fmt.Errorf("invalid public key; expected: %T, got: %T", &ecdsa.PublicKey{}, publicKey))
-	}
-
-	evmAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
+func newTransactOptsBuilder(txSigner signer.Signer) transactOpsBuilder {
+	evmAddress := txSigner.Address()
 	return func(ctx context.Context, client *ethclient.Client, gasLim uint64) (*bind.TransactOpts, error) {
 		nonce, err := client.PendingNonceAt(ctx, evmAddress)
 		if err != nil {
@@ -84,9 +46,15 @@ func newTransactOptsBuilder(privKey *ecdsa.PrivateKey) transactOpsBuilder {
 			return nil, fmt.Errorf("failed to get Ethereum chain ID: %w", err)
 		}
 
-		auth, err := bind.NewKeyedTransactorWithChainID(privKey, ethChainID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create Ethereum transactor: %w", err)
+		auth := &bind.TransactOpts{
+			From: evmAddress,
+			Signer: func(address ethcmn.Address, tx *coregethtypes.Transaction) (*coregethtypes.Transaction, error) {
+				if address != evmAddress {
+					return nil, bind.ErrNotAuthorized
+				}
+				return txSigner.SignTx(ctx, tx, ethChainID)
+			},
+			Context: ctx,
 		}
 
 		bigGasPrice, err := client.SuggestGasPrice(ctx)
@@ -103,80 +71,137 @@ func newTransactOptsBuilder(privKey *ecdsa.PrivateKey) transactOpsBuilder {
 	}
 }
 
-func submitProof(
+// submitProof sends the transaction submitting the proof to the target gateway using the configured sender,
+// and waits for it to be included. The transaction is accelerated if it's not included before the timeout.
+func (r *Replayer) submitProof(
 	ctx context.Context,
-	logger tmlog.Logger,
-	client *ethclient.Client,
-	opts *bind.TransactOpts,
-	succinctGateway *bindings.SuccinctGateway,
-	targetBlobstreamXContract *bindings2.BlobstreamX,
 	args fulfillCallArgs,
 	proofNonce int64,
 	waitTimeout time.Duration,
-) error {
+) (_ *coregethtypes.Receipt, err error) {
+	ctx, span := tracing.Start(ctx, "replay.submit_proof", tracing.AttributeNonce.Int64(proofNonce))
+	defer func() { tracing.End(span, err) }()
+
+	if r.sender == nil {
+		return nil, errors.New("no transaction sender set")
+	}
+	data, err := r.fulfillCallData(args)
+	if err != nil {
+		return nil, r.fail(metrics.FailureSubmission, err)
+	}
+	gasPrice, err := r.targetEVMClient.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, r.fail(metrics.FailureRPC, fmt.Errorf("failed to get Ethereum gas estimate: %w", err))
+	}
+	req := TxRequest{
+		To:       ethcmn.HexToAddress(r.config.TargetChainGatewayAddress),
+		Data:     data,
+		GasLimit: replayGasLimit,
+		GasPrice: gasPrice,
+	}
+
+	defer r.state.update(func(status *Status) {
+		status.PendingTx = nil
+	})
 	for i := 0; i < 10; i++ {
-		logger.Info("submitting transaction for proof", "nonce", proofNonce, "gas_price", opts.GasPrice.Int64())
-		tx, err := succinctGateway.FulfillCall(
-			opts,
-			args.FunctionID,
-			args.Input,
-			args.Output,
-			args.Proof,
-			args.CallbackAddress,
-			args.CallbackData,
-		)
+		r.logger.Info("submitting transaction for proof", "nonce", proofNonce, "gas_price", req.GasPrice.Int64())
+		sendCtx, sendSpan := tracing.Start(ctx, "replay.send_transaction", tracing.AttributeNonce.Int64(proofNonce))
+		tx, err := r.sender.Send(sendCtx, req)
+		tracing.End(sendSpan, err)
 		if err != nil {
-			return err
+			return nil, r.fail(metrics.FailureSubmission, err)
 		}
-		logger.Info("transaction submitted", "hash", tx.Hash().Hex())
-		_, err = waitForTransaction(ctx, logger, client, tx, waitTimeout)
+		r.logger.Info("transaction submitted", "hash", tx.Hash.Hex())
+		r.publishEvent(eventstream.TypeSubmitted, func(event *eventstream.Event) {
+			event.TargetTxHash = tx.Hash.Hex()
+		})
+		r.state.update(func(status *Status) {
+			status.PendingTx = &TxStatus{
+				Hash:        tx.Hash.Hex(),
+				Nonce:       tx.Nonce,
+				GasPrice:    tx.GasPrice.String(),
+				SubmittedAt: time.Now(),
+			}
+		})
+		receipt, err := waitForTransaction(ctx, r.logger, r.targetEVMClient, tx.Hash, waitTimeout)
 		if err != nil {
-			actualNonce, err2 := targetBlobstreamXContract.StateProofNonce(&bind.CallOpts{})
+			actualNonce, err2 := r.targetBlobstreamX.StateProofNonce(&bind.CallOpts{Context: ctx})
 			if err2 != nil {
-				return err2
+				return nil, r.fail(metrics.FailureRPC, err2)
 			}
 			if actualNonce.Int64() > proofNonce {
-				logger.Info("no need to replay this nonce, the contract has already committed to it", "nonce", actualNonce)
-				return nil
+				r.logger.Info("no need to replay this nonce, the contract has already committed to it", "nonce", actualNonce)
+				return nil, nil
 			}
 
 			if errors.Is(err, context.DeadlineExceeded) {
-				logger.Debug("transaction still not included, accelerating...")
+				r.recordFailure(metrics.FailureTimeout)
+				r.logger.Debug("transaction still not included, accelerating...")
 				// we need to speed up the transaction by increasing the gas price
-				bigGasPrice, err := client.SuggestGasPrice(ctx)
+				bigGasPrice, err := r.targetEVMClient.SuggestGasPrice(ctx)
 				if err != nil {
-					return fmt.Errorf("failed to get Ethereum gas estimate: %w", err)
+					return nil, r.fail(metrics.FailureRPC, fmt.Errorf("failed to get Ethereum gas estimate: %w", err))
 				}
 
 				// 20% increase of the suggested gas price
-				opts.GasPrice = big.NewInt(bigGasPrice.Int64() + bigGasPrice.Int64()/5)
-				logger.Debug("transaction still not included, accelerating...", "new_gas_price", opts.GasPrice.Int64())
+				req.GasPrice = big.NewInt(bigGasPrice.Int64() + bigGasPrice.Int64()/5)
+				req.Replaces = &tx
+				r.logger.Debug("transaction still not included, accelerating...", "new_gas_price", req.GasPrice.Int64())
 				continue
 			}
-			logger.Error("transaction failed", "err", err.Error())
-			logger.Debug("retrying...")
-			return err
+			r.logger.Error("transaction failed", "err", err.Error())
+			r.logger.Debug("retrying...")
+			return nil, r.fail(metrics.FailureSubmission, err)
+		}
+		r.metrics.GasUsed.Observe(float64(receipt.GasUsed))
+		gasPrice = receipt.EffectiveGasPrice
+		if gasPrice == nil {
+			gasPrice = tx.GasPrice
 		}
-		return nil
+		r.metrics.GasPrice.Observe(metrics.ToGwei(gasPrice))
+		if receipt.Status != coregethtypes.ReceiptStatusSuccessful {
+			r.logger.Error("transaction reverted", "hash", tx.Hash.Hex(), "nonce", proofNonce)
+			return nil, r.fail(metrics.FailureSubmission, fmt.Errorf("the transaction %s submitting proof nonce %d reverted", tx.Hash.Hex(), proofNonce))
+		}
+		return receipt, nil
 	}
-	return fmt.Errorf("failed to submit proof nonce %d", proofNonce)
+	return nil, fmt.Errorf("failed to submit proof nonce %d", proofNonce)
+}
+
+// fulfillCallData encodes the calldata of the target gateway fulfillCall call submitting the proof.
+func (r *Replayer) fulfillCallData(args fulfillCallArgs) ([]byte, error) {
+	return r.gatewayABI.Pack(
+		"fulfillCall",
+		args.FunctionID,
+		args.Input,
+		args.Output,
+		args.Proof,
+		args.CallbackAddress,
+		args.CallbackData,
+	)
 }
 
 func waitForTransaction(
 	ctx context.Context,
 	logger tmlog.Logger,
 	backend bind.DeployBackend,
-	tx *coregethtypes.Transaction,
+	hash ethcmn.Hash,
 	timeout time.Duration,
-) (*coregethtypes.Receipt, error) {
-	logger.Debug("waiting for transaction to be confirmed", "hash", tx.Hash().String())
+) (_ *coregethtypes.Receipt, err error) {
+	logger.Debug("waiting for transaction to be confirmed", "hash", hash.String())
+
+	ctx, span := tracing.Start(ctx, "replay.wait_inclusion", tracing.AttributeTxHash.String(hash.Hex()))
+	defer func() { tracing.End(span, err) }()
 
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	receipt, err := bind.WaitMined(ctx, backend, tx)
+	receipt, err := bind.WaitMinedHash(ctx, backend, hash)
+	if receipt != nil {
+		span.SetAttributes(attribute.Int64("evm.block_number", receipt.BlockNumber.Int64()), attribute.Int64("evm.gas_used", int64(receipt.GasUsed)))
+	}
 	if err == nil && receipt != nil && receipt.Status == 1 {
-		logger.Info("transaction confirmed", "hash", tx.Hash().String(), "block", receipt.BlockNumber.Uint64())
+		logger.Info("transaction confirmed", "hash", hash.String(), "block", receipt.BlockNumber.Uint64())
 		return receipt, nil
 	}
 
@@ -0,0 +1,206 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/blobstreamxext"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// Genesis the trusted state a target contract is bootstrapped from.
+type Genesis struct {
+	// Height the start block of the source proof the replay starts from.
+	Height uint64
+	// Header the header hash stored in the source contract at the genesis height.
+	Header [32]byte
+	// ProofNonce the nonce of the source proof starting at the genesis height.
+	ProofNonce int64
+}
+
+// FindGenesis picks the source proof starting at the provided height, or the latest source proof if the
+// height is zero, and reads the header hash stored in the source contract at its start block. If the
+// tendermint RPC client is set, the header hash is confirmed against the Celestia header.
+func FindGenesis(
+	ctx context.Context,
+	logger tmlog.Logger,
+	config Config,
+	sourceEVMClient *ethclient.Client,
+	trpc *http.HTTP,
+	height uint64,
+) (Genesis, error) {
+	opts := &bind.CallOpts{Context: ctx}
+	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(config.SourceBlobstreamContractAddress), sourceEVMClient)
+	if err != nil {
+		return Genesis{}, err
+	}
+
+	latestSourceContractBlock, err := sourceBlobstreamX.LatestBlock(opts)
+	if err != nil {
+		return Genesis{}, err
+	}
+	if height >= latestSourceContractBlock {
+		return Genesis{}, fmt.Errorf("the source contract has no proof starting at height %d, its latest block is %d", height, latestSourceContractBlock)
+	}
+	lookupStartHeight, err := sourceEVMClient.BlockNumber(ctx)
+	if err != nil {
+		return Genesis{}, err
+	}
+	latestSourceContractNonce, err := sourceBlobstreamX.StateProofNonce(opts)
+	if err != nil {
+		return Genesis{}, err
+	}
+	// the events are queried until one starting below the needed height is found
+	lookupEnd := height + 1
+	if height == 0 {
+		lookupEnd = latestSourceContractBlock
+	}
+	events, err := getAllDataCommitmentStoredEvents(
+		ctx,
+		logger,
+		&sourceBlobstreamX.BlobstreamXFilterer,
+		int64(lookupStartHeight),
+		config.FilterRange,
+		latestSourceContractNonce.Int64(),
+		int64(lookupEnd),
+	)
+	if err != nil {
+		return Genesis{}, err
+	}
+
+	var event *blobstreamxwrapper.BlobstreamXDataCommitmentStored
+	if height == 0 {
+		for _, candidate := range events {
+			if candidate.EndBlock == latestSourceContractBlock {
+				candidate := candidate
+				event = &candidate
+				break
+			}
+		}
+	} else if candidate, ok := events[int64(height)]; ok {
+		event = &candidate
+	}
+	if event == nil {
+		if height == 0 {
+			return Genesis{}, fmt.Errorf("couldn't find the source proof ending at the source contract latest block %d", latestSourceContractBlock)
+		}
+		return Genesis{}, fmt.Errorf("couldn't find a source proof starting at height %d", height)
+	}
+
+	header, err := sourceBlobstreamX.BlockHeightToHeaderHash(opts, event.StartBlock)
+	if err != nil {
+		return Genesis{}, err
+	}
+	if header == [32]byte{} {
+		return Genesis{}, fmt.Errorf("the source contract has no header hash at height %d", event.StartBlock)
+	}
+	genesis := Genesis{
+		Height:     event.StartBlock,
+		Header:     header,
+		ProofNonce: event.ProofNonce.Int64(),
+	}
+	logger.Info("found genesis", "height", genesis.Height, "header_hash", ethcmn.Hash(header).Hex(), "proof_nonce", genesis.ProofNonce)
+
+	if trpc == nil {
+		logger.Info("no core RPC set, the genesis header hash is not confirmed against Celestia")
+		return genesis, nil
+	}
+	coreHeight := int64(genesis.Height)
+	coreHeader, err := trpc.Header(ctx, &coreHeight)
+	if err != nil {
+		return Genesis{}, err
+	}
+	if coreHeaderHash := coreHeader.Header.Hash(); !bytes.Equal(coreHeaderHash, header[:]) {
+		return Genesis{}, fmt.Errorf(
+			"the source header hash %s at height %d doesn't match the Celestia header hash %s",
+			hex.EncodeToString(header[:]),
+			genesis.Height,
+			hex.EncodeToString(coreHeaderHash),
+		)
+	}
+	logger.Info("genesis header hash confirmed against Celestia", "height", genesis.Height)
+	return genesis, nil
+}
+
+// InitParams the parameters a target contract that isn't initialized yet is initialized with.
+type InitParams struct {
+	Guardian              ethcmn.Address
+	HeaderRangeFunctionID [32]byte
+	NextHeaderFunctionID  [32]byte
+}
+
+// InitializeTarget bootstraps the target contract from the provided genesis so that the replay can start
+// from it. If the target contract isn't initialized, i.e. it has no gateway, it's initialized using the
+// provided parameters and the configured target gateway. Otherwise, its genesis state is updated, which
+// requires the signer to be the target contract guardian.
+func InitializeTarget(
+	ctx context.Context,
+	logger tmlog.Logger,
+	config Config,
+	targetEVMClient *ethclient.Client,
+	genesis Genesis,
+	params InitParams,
+) (*coregethtypes.Receipt, error) {
+	targetAddress := ethcmn.HexToAddress(config.TargetBlobstreamContractAddress)
+	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(targetAddress, targetEVMClient)
+	if err != nil {
+		return nil, err
+	}
+	gateway, err := targetBlobstreamX.Gateway(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+
+	// the gas limit is estimated by the target chain
+	opts, err := newTransactOptsBuilder(config.Signer)(ctx, targetEVMClient, 0)
+	if err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+
+	var tx *coregethtypes.Transaction
+	if gateway == (ethcmn.Address{}) {
+		if params.HeaderRangeFunctionID == [32]byte{} || params.NextHeaderFunctionID == [32]byte{} {
+			return nil, fmt.Errorf("the target contract isn't initialized, the header range and next header function IDs are needed to initialize it")
+		}
+		logger.Info("initializing the target contract", "height", genesis.Height, "guardian", params.Guardian.Hex(), "gateway", config.TargetChainGatewayAddress)
+		tx, err = targetBlobstreamX.Initialize(opts, blobstreamxwrapper.BlobstreamXInitParameters{
+			Guardian:              params.Guardian,
+			Gateway:               ethcmn.HexToAddress(config.TargetChainGatewayAddress),
+			Height:                genesis.Height,
+			Header:                genesis.Header,
+			NextHeaderFunctionId:  params.NextHeaderFunctionID,
+			HeaderRangeFunctionId: params.HeaderRangeFunctionID,
+		})
+	} else {
+		var ext *blobstreamxext.BlobstreamX
+		ext, err = blobstreamxext.New(targetAddress, targetEVMClient)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("updating the target contract genesis state", "height", genesis.Height)
+		tx, err = ext.UpdateGenesisState(opts, genesis.Height, genesis.Header)
+	}
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("transaction submitted", "hash", tx.Hash().Hex())
+
+	receipt, err := waitForTransaction(ctx, logger, targetEVMClient, tx.Hash(), 5*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != coregethtypes.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("the transaction %s reverted", tx.Hash().Hex())
+	}
+	return receipt, nil
+}
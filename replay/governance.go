@@ -0,0 +1,391 @@
+package replay
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/blobstreamxext"
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+)
+
+// The governance events watched on the BlobstreamX contracts and their gateways. The freeze, gateway and
+// function IDs updates don't emit events, and are detected by comparing the contracts state instead.
+var (
+	blobstreamXGovernanceEvents = []string{"Upgraded", "AdminChanged", "BeaconUpgraded", "RoleGranted", "RoleRevoked", "RoleAdminChanged", "Initialized"}
+	gatewayGovernanceEvents     = []string{"FunctionRegistered", "FunctionVerifierUpdated", "OwnershipTransferred", "ProverUpdated", "WhitelistStatusUpdated", "SetFeeVault", "Initialized"}
+)
+
+// revertErrorCode the JSON-RPC error code of the reverted calls.
+const revertErrorCode = 3
+
+// GovernanceChange a governance or upgrade change detected on the source or target contracts.
+type GovernanceChange struct {
+	// Chain either source or target.
+	Chain    string `json:"chain"`
+	Contract string `json:"contract"`
+	// Change the name of the emitted event, or of the changed state field.
+	Change     string    `json:"change"`
+	Details    string    `json:"details"`
+	Block      uint64    `json:"block,omitempty"`
+	TxHash     string    `json:"tx_hash,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// watchedChain the governance watch state of the BlobstreamX contract of a chain.
+type watchedChain struct {
+	name        string
+	client      *ethclient.Client
+	address     ethcmn.Address
+	blobstreamX *blobstreamxwrapper.BlobstreamX
+	ext         *blobstreamxext.BlobstreamX
+	// lastBlock the last block the events were queried up to.
+	lastBlock uint64
+	// gateway the gateway the contract used at the last block.
+	gateway ethcmn.Address
+	// state the governance state of the contracts at the last block. Nil until the first poll.
+	state map[string]string
+}
+
+// WatchGovernance polls the source and target contracts, and their gateways, for governance changes: upgrades,
+// role changes, freezes, gateway, function IDs and verifiers updates. On any change, it alerts and pauses the
+// proofs submission until the changes are acknowledged using Acknowledge. The polling errors are only logged
+// so that a flaky endpoint doesn't stop the replay.
+func (r *Replayer) WatchGovernance(ctx context.Context, interval time.Duration) error {
+	blobstreamXABI, err := blobstreamxwrapper.BlobstreamXMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	abis := []*abi.ABI{blobstreamXABI, gatewayABI}
+	topics, err := governanceTopics(blobstreamXABI, gatewayABI)
+	if err != nil {
+		return err
+	}
+
+	chains, err := r.watchedChains()
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("watching the source and target contracts governance changes", "interval", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		var changes []GovernanceChange
+		for _, chain := range chains {
+			chainChanges, err := r.pollGovernance(ctx, chain, topics, abis)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				r.logger.Error("couldn't poll the governance changes", "chain", chain.name, "err", err.Error())
+				continue
+			}
+			changes = append(changes, chainChanges...)
+		}
+		if len(changes) != 0 {
+			r.holdForGovernance(ctx, changes)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchedChains returns the governance watch state of the source and target contracts, before their first poll.
+func (r *Replayer) watchedChains() ([]*watchedChain, error) {
+	var chains []*watchedChain
+	for _, chain := range []struct {
+		name        string
+		client      *ethclient.Client
+		address     string
+		blobstreamX *blobstreamxwrapper.BlobstreamX
+	}{
+		{name: "source", client: r.sourceEVMClient, address: r.config.SourceBlobstreamContractAddress, blobstreamX: r.sourceBlobstreamX},
+		{name: "target", client: r.targetEVMClient, address: r.config.TargetBlobstreamContractAddress, blobstreamX: r.targetBlobstreamX},
+	} {
+		address := ethcmn.HexToAddress(chain.address)
+		ext, err := blobstreamxext.New(address, chain.client)
+		if err != nil {
+			return nil, err
+		}
+		chains = append(chains, &watchedChain{
+			name:        chain.name,
+			client:      chain.client,
+			address:     address,
+			blobstreamX: chain.blobstreamX,
+			ext:         ext,
+		})
+	}
+	return chains, nil
+}
+
+// pollGovernance returns the governance changes of the chain since the last poll. The first poll only
+// records the current state.
+func (r *Replayer) pollGovernance(ctx context.Context, chain *watchedChain, topics []ethcmn.Hash, abis []*abi.ABI) ([]GovernanceChange, error) {
+	latestBlock, err := chain.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, gateway, err := governanceState(ctx, chain, latestBlock)
+	if err != nil {
+		return nil, err
+	}
+	if chain.state == nil {
+		chain.state, chain.gateway, chain.lastBlock = state, gateway, latestBlock
+		return nil, nil
+	}
+	if latestBlock <= chain.lastBlock {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var changes []GovernanceChange
+	// the events of both the previous and new gateways, in case the gateway was updated
+	addresses := []ethcmn.Address{chain.address, chain.gateway}
+	if gateway != chain.gateway {
+		addresses = append(addresses, gateway)
+	}
+	filterRange := uint64(r.config.FilterRange)
+	for start := chain.lastBlock + 1; start <= latestBlock; start += filterRange {
+		end := min(start+filterRange-1, latestBlock)
+		logs, err := chain.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: addresses,
+			Topics:    [][]ethcmn.Hash{topics},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, log := range logs {
+			name, details := describeLog(abis, log)
+			changes = append(changes, GovernanceChange{
+				Chain:      chain.name,
+				Contract:   log.Address.Hex(),
+				Change:     name,
+				Details:    details,
+				Block:      log.BlockNumber,
+				TxHash:     log.TxHash.Hex(),
+				DetectedAt: now,
+			})
+		}
+	}
+
+	fields := make([]string, 0, len(state))
+	for field := range state {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if previous := chain.state[field]; previous != state[field] {
+			changes = append(changes, GovernanceChange{
+				Chain:      chain.name,
+				Contract:   chain.address.Hex(),
+				Change:     field,
+				Details:    fmt.Sprintf("%s -> %s", previous, state[field]),
+				Block:      latestBlock,
+				DetectedAt: now,
+			})
+		}
+	}
+
+	chain.state, chain.gateway, chain.lastBlock = state, gateway, latestBlock
+	return changes, nil
+}
+
+// governanceState reads the governance state of the BlobstreamX contract and its gateway at the provided block.
+func governanceState(ctx context.Context, chain *watchedChain, block uint64) (map[string]string, ethcmn.Address, error) {
+	blockNumber := new(big.Int).SetUint64(block)
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: blockNumber}
+	implementation, err := chain.client.StorageAt(ctx, chain.address, deploy.ImplementationSlot, blockNumber)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	frozen, err := frozenState(chain.ext, opts)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	gateway, err := chain.blobstreamX.Gateway(opts)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	headerRangeFunctionID, err := chain.blobstreamX.HeaderRangeFunctionId(opts)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	nextHeaderFunctionID, err := chain.blobstreamX.NextHeaderFunctionId(opts)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	gatewayImplementation, err := chain.client.StorageAt(ctx, gateway, deploy.ImplementationSlot, blockNumber)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	succinctGateway, err := bindings.NewSuccinctGateway(gateway, chain.client)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	headerRangeVerifier, err := succinctGateway.Verifiers(opts, headerRangeFunctionID)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	nextHeaderVerifier, err := succinctGateway.Verifiers(opts, nextHeaderFunctionID)
+	if err != nil {
+		return nil, ethcmn.Address{}, err
+	}
+	return map[string]string{
+		"implementation":           ethcmn.BytesToAddress(implementation).Hex(),
+		"frozen":                   frozen,
+		"gateway":                  gateway.Hex(),
+		"gateway_implementation":   ethcmn.BytesToAddress(gatewayImplementation).Hex(),
+		"header_range_function_id": hex.EncodeToString(headerRangeFunctionID[:]),
+		"next_header_function_id":  hex.EncodeToString(nextHeaderFunctionID[:]),
+		"header_range_verifier":    headerRangeVerifier.Hex(),
+		"next_header_verifier":     nextHeaderVerifier.Hex(),
+	}, gateway, nil
+}
+
+// frozenState returns whether the contract is frozen, or unsupported if it reverts on the frozen getter, as
+// the contracts deployed before the freeze was added do.
+func frozenState(ext *blobstreamxext.BlobstreamX, opts *bind.CallOpts) (string, error) {
+	frozen, err := ext.Frozen(opts)
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == revertErrorCode {
+		return "unsupported", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(frozen), nil
+}
+
+// governanceTopics returns the topics of the watched governance events.
+func governanceTopics(blobstreamXABI, gatewayABI *abi.ABI) ([]ethcmn.Hash, error) {
+	seen := make(map[ethcmn.Hash]bool)
+	var topics []ethcmn.Hash
+	for _, events := range []struct {
+		abi   *abi.ABI
+		names []string
+	}{
+		{abi: blobstreamXABI, names: blobstreamXGovernanceEvents},
+		{abi: gatewayABI, names: gatewayGovernanceEvents},
+	} {
+		for _, name := range events.names {
+			event, ok := events.abi.Events[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown governance event %s", name)
+			}
+			if !seen[event.ID] {
+				seen[event.ID] = true
+				topics = append(topics, event.ID)
+			}
+		}
+	}
+	return topics, nil
+}
+
+// describeLog returns the name of the event of the log and its decoded arguments.
+func describeLog(abis []*abi.ABI, log coregethtypes.Log) (string, string) {
+	if len(log.Topics) == 0 {
+		return "unknown", ""
+	}
+	for _, contractABI := range abis {
+		event, err := contractABI.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+		values := make(map[string]interface{})
+		if len(log.Data) != 0 {
+			if err := event.Inputs.NonIndexed().UnpackIntoMap(values, log.Data); err != nil {
+				return event.Name, "0x" + hex.EncodeToString(log.Data)
+			}
+		}
+		var indexed abi.Arguments
+		for _, input := range event.Inputs {
+			if input.Indexed {
+				indexed = append(indexed, input)
+			}
+		}
+		if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+			return event.Name, ""
+		}
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		args := make([]string, 0, len(names))
+		for _, name := range names {
+			value := values[name]
+			if bz, ok := value.([32]byte); ok {
+				value = "0x" + hex.EncodeToString(bz[:])
+			}
+			args = append(args, fmt.Sprintf("%s=%v", name, value))
+		}
+		return event.Name, strings.Join(args, " ")
+	}
+	return log.Topics[0].Hex(), ""
+}
+
+// holdForGovernance alerts about the governance changes and pauses the proofs submission until they're
+// acknowledged.
+func (r *Replayer) holdForGovernance(ctx context.Context, changes []GovernanceChange) {
+	details := make(map[string]string, len(changes))
+	for i, change := range changes {
+		r.logger.Error(
+			"governance change detected, pausing the proofs submission",
+			"chain", change.Chain,
+			"contract", change.Contract,
+			"change", change.Change,
+			"details", change.Details,
+			"block", change.Block,
+			"tx_hash", change.TxHash,
+		)
+		details[fmt.Sprintf("change_%d", i)] = fmt.Sprintf("%s %s %s: %s", change.Chain, change.Contract, change.Change, change.Details)
+	}
+	r.state.update(func(status *Status) {
+		status.GovernanceChanges = append(status.GovernanceChanges, changes...)
+	})
+	r.state.setPaused(true)
+	r.notify(ctx, notify.Alert{
+		Kind:     notify.KindGovernanceChange,
+		Severity: notify.SeverityCritical,
+		Summary:  fmt.Sprintf("%d governance changes detected on the source or target contracts, the replay is paused until they're acknowledged", len(changes)),
+		Details:  details,
+		DedupKey: fmt.Sprintf("%s-%s-%d", notify.KindGovernanceChange, changes[0].Chain, changes[0].Block),
+	})
+}
+
+// Acknowledge acknowledges the detected governance changes, and resumes the proofs submission.
+func (r *Replayer) Acknowledge() {
+	r.state.update(func(status *Status) {
+		if len(status.GovernanceChanges) != 0 {
+			r.logger.Info("governance changes acknowledged", "changes", len(status.GovernanceChanges))
+		}
+		status.GovernanceChanges = nil
+	})
+	r.Resume()
+}
@@ -0,0 +1,211 @@
+package replay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// newSimReplayer creates a replayer from the source to the target simulated chains, signing with the key.
+func newSimReplayer(t *testing.T, source, target *simchain.Chain, key *ecdsa.PrivateKey, config Config) *Replayer {
+	t.Helper()
+	sourceClient, err := ethclient.Dial("http://" + source.Endpoint)
+	require.NoError(t, err)
+	t.Cleanup(sourceClient.Close)
+	targetClient, err := ethclient.Dial("http://" + target.Endpoint)
+	require.NoError(t, err)
+	t.Cleanup(targetClient.Close)
+
+	config.SourceBlobstreamContractAddress = source.Manifest.BlobstreamX.Hex()
+	config.TargetBlobstreamContractAddress = target.Manifest.BlobstreamX.Hex()
+	config.SourceChainGatewayAddress = source.Manifest.Gateway.Hex()
+	config.TargetChainGatewayAddress = target.Manifest.Gateway.Hex()
+	config.Signer = signer.NewKey(key)
+	config.FunctionIDs = map[[32]byte][32]byte{
+		source.Manifest.HeaderRangeFunctionID: target.Manifest.HeaderRangeFunctionID,
+		source.Manifest.NextHeaderFunctionID:  target.Manifest.NextHeaderFunctionID,
+	}
+	if config.FilterRange == 0 {
+		config.FilterRange = 5000
+	}
+	logger := tmlog.NewNopLogger()
+	notifier, err := notify.New(logger, nil, 0)
+	require.NoError(t, err)
+	replayer, err := NewReplayer(
+		logger,
+		config,
+		nil,
+		sourceClient,
+		targetClient,
+		metrics.NewReplay(prometheus.NewRegistry()),
+		notifier,
+		eventstream.New(logger),
+		nil,
+	)
+	require.NoError(t, err)
+	return replayer
+}
+
+// TestPollGovernance applies a governance change to the source or target contracts, and checks that it's
+// detected by the next poll, then that the replay is paused until the change is acknowledged.
+func TestPollGovernance(t *testing.T) {
+	tests := []struct {
+		name string
+		// change applies the governance change, returning the chain it was applied on.
+		change      func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, source, target *simchain.Chain) string
+		wantChanges []string
+	}{
+		{
+			name: "no change",
+			change: func(context.Context, *testing.T, *ecdsa.PrivateKey, *simchain.Chain, *simchain.Chain) string {
+				return ""
+			},
+		},
+		{
+			name: "gateway update",
+			change: func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, _, target *simchain.Chain) string {
+				blobstreamX, err := blobstreamxwrapper.NewBlobstreamXTransactor(target.Manifest.BlobstreamX, target.Backend.Client())
+				require.NoError(t, err)
+				// the gateway implementation can be read as a gateway without any registered function
+				target.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return blobstreamX.UpdateGateway(opts, target.Manifest.GatewayImplementation)
+				})
+				return "target"
+			},
+			wantChanges: []string{"gateway", "gateway_implementation", "header_range_verifier", "next_header_verifier"},
+		},
+		{
+			name: "function ID update",
+			change: func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, source, _ *simchain.Chain) string {
+				blobstreamX, err := blobstreamxwrapper.NewBlobstreamXTransactor(source.Manifest.BlobstreamX, source.Backend.Client())
+				require.NoError(t, err)
+				source.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return blobstreamX.UpdateHeaderRangeId(opts, [32]byte{2})
+				})
+				return "source"
+			},
+			wantChanges: []string{"header_range_function_id", "header_range_verifier"},
+		},
+		{
+			name: "verifier update",
+			change: func(ctx context.Context, t *testing.T, key *ecdsa.PrivateKey, _, target *simchain.Chain) string {
+				gateway, err := bindings.NewSuccinctGatewayTransactor(target.Manifest.Gateway, target.Backend.Client())
+				require.NoError(t, err)
+				target.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+					return gateway.UpdateFunction(opts, target.Manifest.NextHeaderVerifier, deploy.DefaultHeaderRangeSalt)
+				})
+				return "target"
+			},
+			wantChanges: []string{"FunctionVerifierUpdated", "header_range_verifier"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			source := simchain.New(ctx, t, key)
+			target := simchain.New(ctx, t, key)
+			// the events are queried one block at a time
+			replayer := newSimReplayer(t, source, target, key, Config{FilterRange: 1})
+
+			blobstreamXABI, err := blobstreamxwrapper.BlobstreamXMetaData.GetAbi()
+			require.NoError(t, err)
+			gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
+			require.NoError(t, err)
+			abis := []*abi.ABI{blobstreamXABI, gatewayABI}
+			topics, err := governanceTopics(blobstreamXABI, gatewayABI)
+			require.NoError(t, err)
+			chains, err := replayer.watchedChains()
+			require.NoError(t, err)
+			for _, chain := range chains {
+				changes, err := replayer.pollGovernance(ctx, chain, topics, abis)
+				require.NoError(t, err)
+				require.Empty(t, changes, "the first poll only records the state")
+			}
+
+			changedChain := test.change(ctx, t, key, source, target)
+			// a few empty blocks are queried along with the change
+			source.Backend.Commit()
+			target.Backend.Commit()
+			var changes []GovernanceChange
+			for _, chain := range chains {
+				chainChanges, err := replayer.pollGovernance(ctx, chain, topics, abis)
+				require.NoError(t, err)
+				changes = append(changes, chainChanges...)
+			}
+			names := make([]string, 0, len(changes))
+			for _, change := range changes {
+				assert.Equal(t, changedChain, change.Chain)
+				names = append(names, change.Change)
+			}
+			assert.ElementsMatch(t, test.wantChanges, names)
+			if len(changes) == 0 {
+				return
+			}
+
+			replayer.holdForGovernance(ctx, changes)
+			status := replayer.Status()
+			assert.True(t, status.Paused)
+			assert.Len(t, status.GovernanceChanges, len(changes))
+			replayer.Resume()
+			assert.True(t, replayer.Status().Paused, "the replay resumed with unacknowledged governance changes")
+			replayer.Acknowledge()
+			status = replayer.Status()
+			assert.False(t, status.Paused)
+			assert.Empty(t, status.GovernanceChanges)
+		})
+	}
+}
+
+// TestWatchGovernancePauses watches the governance of the contracts, and checks that a change pauses the replay.
+func TestWatchGovernancePauses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	replayer := newSimReplayer(t, source, target, key, Config{})
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	watched := make(chan error, 1)
+	go func() { watched <- replayer.WatchGovernance(watchCtx, 50*time.Millisecond) }()
+	// the first poll records the state before the change
+	time.Sleep(500 * time.Millisecond)
+	blobstreamX, err := blobstreamxwrapper.NewBlobstreamXTransactor(target.Manifest.BlobstreamX, target.Backend.Client())
+	require.NoError(t, err)
+	target.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+		return blobstreamX.GrantRole(opts, crypto.Keccak256Hash([]byte("GUARDIAN_ROLE")), ethcmn.Address{1})
+	})
+	require.Eventually(t, func() bool { return replayer.Status().Paused }, 10*time.Second, 10*time.Millisecond)
+	stopWatch()
+	require.NoError(t, <-watched)
+
+	status := replayer.Status()
+	require.Len(t, status.GovernanceChanges, 1)
+	assert.Equal(t, "RoleGranted", status.GovernanceChanges[0].Change)
+	assert.Equal(t, "target", status.GovernanceChanges[0].Chain)
+}
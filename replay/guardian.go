@@ -0,0 +1,167 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/blobstreamxext"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/celestiaorg/blobstream-ops/tracing"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// freezeTimeout the time to wait for the freeze transaction to be included.
+const freezeTimeout = 5 * time.Minute
+
+// Guardian freezes the target contract when a mismatch detected during the verification is confirmed by a
+// quorum of trusted core endpoints. It signs the freeze transaction using its own signer, which should be the
+// target contract guardian, and not the replay signer.
+type Guardian struct {
+	signer    signer.Signer
+	endpoints []*http.HTTP
+	quorum    int
+}
+
+// NewGuardian creates a new guardian confirming the mismatches against the provided trusted core endpoints.
+// If the quorum is zero, a majority of the endpoints needs to confirm a mismatch before freezing.
+func NewGuardian(guardianSigner signer.Signer, endpoints []*http.HTTP, quorum int) (*Guardian, error) {
+	if guardianSigner == nil {
+		return nil, errors.New("the guardian signer cannot be empty")
+	}
+	if len(endpoints) == 0 {
+		return nil, errors.New("the guardian needs at least one trusted core endpoint")
+	}
+	if quorum == 0 {
+		quorum = len(endpoints)/2 + 1
+	}
+	if quorum < 0 || quorum > len(endpoints) {
+		return nil, fmt.Errorf("invalid guardian quorum %d for %d trusted core endpoints", quorum, len(endpoints))
+	}
+	return &Guardian{
+		signer:    guardianSigner,
+		endpoints: endpoints,
+		quorum:    quorum,
+	}, nil
+}
+
+// Address returns the guardian address.
+func (g *Guardian) Address() ethcmn.Address {
+	return g.signer.Address()
+}
+
+// mismatchCheck queries a trusted core endpoint and returns true if it confirms the mismatch.
+type mismatchCheck func(ctx context.Context, trpc *http.HTTP) (bool, error)
+
+// guard freezes the target contract if the guardian is enabled and a quorum of its trusted core endpoints
+// confirms the mismatch, then alerts about the outcome. The errors are only logged and alerted as the
+// replay stops on a mismatch anyway.
+func (r *Replayer) guard(ctx context.Context, kind string, check mismatchCheck) {
+	guardian := r.config.Guardian
+	if guardian == nil {
+		return
+	}
+	ctx, span := tracing.Start(ctx, "replay.guard")
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	confirmations := 0
+	for i, trpc := range guardian.endpoints {
+		confirmed, checkErr := check(ctx, trpc)
+		if checkErr != nil {
+			r.logger.Error("couldn't confirm the mismatch against the trusted core endpoint", "endpoint", i, "err", checkErr.Error())
+			continue
+		}
+		if confirmed {
+			confirmations++
+		}
+	}
+	details := map[string]string{
+		"mismatch":        kind,
+		"target_contract": r.config.TargetBlobstreamContractAddress,
+		"guardian":        guardian.Address().Hex(),
+		"confirmations":   fmt.Sprintf("%d/%d", confirmations, len(guardian.endpoints)),
+		"quorum":          fmt.Sprint(guardian.quorum),
+	}
+	if confirmations < guardian.quorum {
+		err = fmt.Errorf("the mismatch is confirmed by %d trusted core endpoints, below the quorum of %d", confirmations, guardian.quorum)
+		r.logger.Error("the mismatch isn't confirmed by a quorum of trusted core endpoints, not freezing the target contract", "confirmations", confirmations, "quorum", guardian.quorum)
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindGuardianFreezeFailed,
+			Severity: notify.SeverityCritical,
+			Summary:  fmt.Sprintf("the %s isn't confirmed by a quorum of trusted core endpoints, the target contract wasn't frozen", kind),
+			Details:  details,
+			DedupKey: fmt.Sprintf("%s-%s", notify.KindGuardianFreezeFailed, r.config.TargetBlobstreamContractAddress),
+		})
+		return
+	}
+
+	var txHash string
+	txHash, err = r.freeze(ctx, guardian)
+	if err != nil {
+		r.logger.Error("couldn't freeze the target contract", "err", err.Error())
+		details["error"] = err.Error()
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindGuardianFreezeFailed,
+			Severity: notify.SeverityCritical,
+			Summary:  fmt.Sprintf("the %s is confirmed but the target contract couldn't be frozen", kind),
+			Details:  details,
+			DedupKey: fmt.Sprintf("%s-%s", notify.KindGuardianFreezeFailed, r.config.TargetBlobstreamContractAddress),
+		})
+		return
+	}
+	if txHash != "" {
+		details["tx_hash"] = txHash
+	}
+	r.notify(ctx, notify.Alert{
+		Kind:     notify.KindGuardianFreeze,
+		Severity: notify.SeverityCritical,
+		Summary:  fmt.Sprintf("the %s is confirmed, the guardian froze the target contract", kind),
+		Details:  details,
+		DedupKey: fmt.Sprintf("%s-%s", notify.KindGuardianFreeze, r.config.TargetBlobstreamContractAddress),
+	})
+}
+
+// freeze sends the freeze transaction to the target contract and waits for it to succeed. It returns the
+// transaction hash, which is empty if the contract was already frozen.
+func (r *Replayer) freeze(ctx context.Context, guardian *Guardian) (string, error) {
+	targetBlobstreamX, err := blobstreamxext.New(ethcmn.HexToAddress(r.config.TargetBlobstreamContractAddress), r.targetEVMClient)
+	if err != nil {
+		return "", err
+	}
+	frozen, err := targetBlobstreamX.Frozen(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return "", err
+	}
+	if frozen {
+		r.logger.Info("the target contract is already frozen")
+		return "", nil
+	}
+
+	// the gas limit is estimated by the target chain
+	opts, err := newTransactOptsBuilder(guardian.signer)(ctx, r.targetEVMClient, 0)
+	if err != nil {
+		return "", err
+	}
+	opts.Context = ctx
+	r.logger.Info("freezing the target contract", "guardian", guardian.Address().Hex())
+	tx, err := targetBlobstreamX.UpdateFreeze(opts, true)
+	if err != nil {
+		return "", err
+	}
+	r.logger.Info("freeze transaction submitted", "hash", tx.Hash().Hex())
+	receipt, err := waitForTransaction(ctx, r.logger, r.targetEVMClient, tx.Hash(), freezeTimeout)
+	if err != nil {
+		return tx.Hash().Hex(), err
+	}
+	if receipt.Status != coregethtypes.ReceiptStatusSuccessful {
+		return tx.Hash().Hex(), fmt.Errorf("the freeze transaction %s reverted", tx.Hash().Hex())
+	}
+	r.logger.Info("target contract frozen", "hash", tx.Hash().Hex())
+	return tx.Hash().Hex(), nil
+}
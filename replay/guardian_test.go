@@ -0,0 +1,217 @@
+package replay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// newCoreEndpoints returns trusted core endpoint clients. They're only compared by the checks, and never started.
+func newCoreEndpoints(t *testing.T, count int) []*tmhttp.HTTP {
+	endpoints := make([]*tmhttp.HTTP, count)
+	for i := range endpoints {
+		endpoint, err := tmhttp.New("http://127.0.0.1:1", "/websocket")
+		require.NoError(t, err)
+		endpoints[i] = endpoint
+	}
+	return endpoints
+}
+
+func TestNewGuardian(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tests := []struct {
+		name       string
+		signer     signer.Signer
+		endpoints  int
+		quorum     int
+		wantQuorum int
+		wantErr    string
+	}{
+		{name: "majority of one endpoint", signer: signer.NewKey(key), endpoints: 1, wantQuorum: 1},
+		{name: "majority of three endpoints", signer: signer.NewKey(key), endpoints: 3, wantQuorum: 2},
+		{name: "majority of four endpoints", signer: signer.NewKey(key), endpoints: 4, wantQuorum: 3},
+		{name: "explicit quorum", signer: signer.NewKey(key), endpoints: 3, quorum: 3, wantQuorum: 3},
+		{name: "quorum above the endpoints", signer: signer.NewKey(key), endpoints: 3, quorum: 4, wantErr: "invalid guardian quorum 4 for 3 trusted core endpoints"},
+		{name: "negative quorum", signer: signer.NewKey(key), endpoints: 3, quorum: -1, wantErr: "invalid guardian quorum -1"},
+		{name: "no endpoint", signer: signer.NewKey(key), wantErr: "the guardian needs at least one trusted core endpoint"},
+		{name: "no signer", endpoints: 1, wantErr: "the guardian signer cannot be empty"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var endpoints []*tmhttp.HTTP
+			if test.endpoints != 0 {
+				endpoints = newCoreEndpoints(t, test.endpoints)
+			}
+			guardian, err := NewGuardian(test.signer, endpoints, test.quorum)
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantQuorum, guardian.quorum)
+		})
+	}
+}
+
+// deployFreezeStub deploys a contract answering the frozen state to any call, standing for a freezable
+// target contract: the freeze transaction succeeds without changing it.
+func deployFreezeStub(ctx context.Context, t *testing.T, chain *simchain.Chain, key *ecdsa.PrivateKey, frozen bool) ethcmn.Address {
+	t.Helper()
+	frozenByte := "00"
+	if frozen {
+		frozenByte = "01"
+	}
+	// the creation code copies and returns the runtime code, which returns the frozen state as a 32 bytes word
+	creationCode := ethcmn.FromHex("0x600a600c600039600a6000f360" + frozenByte + "60005260206000f3")
+	var address ethcmn.Address
+	chain.Transact(ctx, t, key, func(opts *bind.TransactOpts) (*coregethtypes.Transaction, error) {
+		var (
+			tx  *coregethtypes.Transaction
+			err error
+		)
+		address, tx, _, err = bind.DeployContract(opts, abi.ABI{}, creationCode, chain.Backend.Client())
+		return tx, err
+	})
+	return address
+}
+
+// alertsRecorder a generic webhook recording the alerts it receives.
+type alertsRecorder struct {
+	mu     sync.Mutex
+	alerts []notify.Alert
+}
+
+func (a *alertsRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var alert notify.Alert
+	if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.alerts = append(a.alerts, alert)
+}
+
+func (a *alertsRecorder) recorded() []notify.Alert {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]notify.Alert{}, a.alerts...)
+}
+
+// checkResult the result of a mismatch check against a trusted core endpoint.
+type checkResult struct {
+	confirmed bool
+	err       error
+}
+
+func TestGuard(t *testing.T) {
+	errUnreachable := errors.New("unreachable endpoint")
+	tests := []struct {
+		name   string
+		quorum int
+		// checks the result of the mismatch check against each trusted core endpoint.
+		checks   []checkResult
+		frozen   bool
+		wantKind string
+		// wantFreezeTx true if a freeze transaction is expected to be sent.
+		wantFreezeTx bool
+	}{
+		{
+			name:         "majority quorum met",
+			checks:       []checkResult{{confirmed: true}, {confirmed: false}, {confirmed: true}},
+			wantKind:     notify.KindGuardianFreeze,
+			wantFreezeTx: true,
+		},
+		{
+			name:     "majority quorum met on an already frozen contract",
+			checks:   []checkResult{{confirmed: true}, {confirmed: true}, {confirmed: true}},
+			frozen:   true,
+			wantKind: notify.KindGuardianFreeze,
+		},
+		{
+			name:     "majority quorum not reached",
+			checks:   []checkResult{{confirmed: true}, {confirmed: false}, {confirmed: false}},
+			wantKind: notify.KindGuardianFreezeFailed,
+		},
+		{
+			name:     "unreachable endpoints don't confirm",
+			checks:   []checkResult{{confirmed: true}, {err: errUnreachable}, {err: errUnreachable}},
+			wantKind: notify.KindGuardianFreezeFailed,
+		},
+		{
+			name:     "explicit quorum not reached",
+			quorum:   3,
+			checks:   []checkResult{{confirmed: true}, {confirmed: true}, {confirmed: false}},
+			wantKind: notify.KindGuardianFreezeFailed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			source := simchain.New(ctx, t, key)
+			target := simchain.New(ctx, t, key)
+			target.Mine(t, 50*time.Millisecond)
+			stub := deployFreezeStub(ctx, t, target, key, test.frozen)
+
+			endpoints := newCoreEndpoints(t, len(test.checks))
+			guardian, err := NewGuardian(signer.NewKey(key), endpoints, test.quorum)
+			require.NoError(t, err)
+			replayer := newSimReplayer(t, source, target, key, Config{Guardian: guardian})
+			replayer.config.TargetBlobstreamContractAddress = stub.Hex()
+			recorder := &alertsRecorder{}
+			webhook := httptest.NewServer(recorder)
+			defer webhook.Close()
+			replayer.notifier, err = notify.New(tmlog.NewNopLogger(), []notify.Webhook{{URL: webhook.URL, Format: notify.FormatGeneric}}, 0)
+			require.NoError(t, err)
+			nonce, err := target.Backend.Client().NonceAt(ctx, guardian.Address(), nil)
+			require.NoError(t, err)
+
+			replayer.guard(ctx, "data commitment mismatch", func(_ context.Context, trpc *tmhttp.HTTP) (bool, error) {
+				for i, endpoint := range endpoints {
+					if endpoint == trpc {
+						return test.checks[i].confirmed, test.checks[i].err
+					}
+				}
+				return false, errors.New("unknown endpoint")
+			})
+
+			alerts := recorder.recorded()
+			require.Len(t, alerts, 1)
+			assert.Equal(t, test.wantKind, alerts[0].Kind)
+			assert.Equal(t, guardian.Address().Hex(), alerts[0].Details["guardian"])
+			_, sentFreezeTx := alerts[0].Details["tx_hash"]
+			assert.Equal(t, test.wantFreezeTx, sentFreezeTx)
+			freezeNonce, err := target.Backend.Client().NonceAt(ctx, guardian.Address(), nil)
+			require.NoError(t, err)
+			if test.wantFreezeTx {
+				assert.Equal(t, nonce+1, freezeNonce)
+			} else {
+				assert.Equal(t, nonce, freezeNonce)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/succinctlabs/succinctx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// The names of the preflight checks.
+const (
+	CheckSourceChainID = "source_chain_id"
+	CheckTargetChainID = "target_chain_id"
+	CheckStartBlock    = "start_block"
+	CheckTrustedHeader = "trusted_header"
+	CheckGateway       = "gateway"
+	CheckFunctionIDs   = "function_ids"
+	CheckSignerBalance = "signer_balance"
+)
+
+const (
+	// defaultPreflightProofs the default number of proofs the signer should be able to pay for.
+	defaultPreflightProofs = 10
+	// defaultGasPerProof an upper bound of the gas used by a proof submission.
+	defaultGasPerProof = 500000
+)
+
+// PreflightConfig the configuration of the checks run before replaying to a target contract.
+type PreflightConfig struct {
+	// SourceChainID and TargetChainID the expected chain IDs. Nil if they're not checked.
+	SourceChainID *big.Int
+	TargetChainID *big.Int
+	// Proofs the number of proofs the signer balance should be able to pay for.
+	Proofs int64
+	// GasPerProof the gas used by a proof submission, used to estimate the cost of the proofs.
+	GasPerProof uint64
+}
+
+// DefaultPreflightConfig returns the default preflight configuration.
+func DefaultPreflightConfig() PreflightConfig {
+	return PreflightConfig{
+		Proofs:      defaultPreflightProofs,
+		GasPerProof: defaultGasPerProof,
+	}
+}
+
+// PreflightCheck the result of a preflight check.
+type PreflightCheck struct {
+	Name   string
+	Passed bool
+	// Details describes the check result, or why it failed.
+	Details string
+}
+
+// PreflightReport the results of the preflight checks.
+type PreflightReport struct {
+	Checks []PreflightCheck
+}
+
+// Passed returns true if all the checks passed.
+func (report PreflightReport) Passed() bool {
+	for _, check := range report.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the checks that failed.
+func (report PreflightReport) Failed() []PreflightCheck {
+	var failed []PreflightCheck
+	for _, check := range report.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+func (report *PreflightReport) add(name string, passed bool, details string, args ...interface{}) {
+	report.Checks = append(report.Checks, PreflightCheck{
+		Name:    name,
+		Passed:  passed,
+		Details: fmt.Sprintf(details, args...),
+	})
+}
+
+// Preflight checks that the target deployment is compatible with the source one, i.e. that the
+// source proofs can be replayed to it:
+//   - the chain IDs are the expected ones;
+//   - the target contract latest block is the start block of a source proof;
+//   - the target trusted header matches the source one at that height;
+//   - the target contract uses the configured gateway;
+//   - the mapped function IDs are registered with verifiers in the target gateway;
+//   - the signer can pay for the configured number of proofs.
+//
+// The returned error is only set if the checks couldn't be run.
+func Preflight(
+	ctx context.Context,
+	logger tmlog.Logger,
+	config Config,
+	preflightConfig PreflightConfig,
+	sourceEVMClient *ethclient.Client,
+	targetEVMClient *ethclient.Client,
+) (PreflightReport, error) {
+	var report PreflightReport
+	opts := &bind.CallOpts{Context: ctx}
+
+	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(config.SourceBlobstreamContractAddress), sourceEVMClient)
+	if err != nil {
+		return report, err
+	}
+	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(config.TargetBlobstreamContractAddress), targetEVMClient)
+	if err != nil {
+		return report, err
+	}
+	gateway, err := bindings.NewSuccinctGatewayCaller(ethcmn.HexToAddress(config.TargetChainGatewayAddress), targetEVMClient)
+	if err != nil {
+		return report, err
+	}
+
+	if err := checkChainID(ctx, &report, CheckSourceChainID, sourceEVMClient, preflightConfig.SourceChainID); err != nil {
+		return report, err
+	}
+	if err := checkChainID(ctx, &report, CheckTargetChainID, targetEVMClient, preflightConfig.TargetChainID); err != nil {
+		return report, err
+	}
+
+	latestSourceContractBlock, err := sourceBlobstreamX.LatestBlock(opts)
+	if err != nil {
+		return report, err
+	}
+	latestTargetContractBlock, err := targetBlobstreamX.LatestBlock(opts)
+	if err != nil {
+		return report, err
+	}
+	switch {
+	case latestTargetContractBlock == latestSourceContractBlock:
+		report.add(CheckStartBlock, true, "the target contract is up to date with the source contract at block %d", latestTargetContractBlock)
+	case latestTargetContractBlock > latestSourceContractBlock:
+		report.add(CheckStartBlock, false, "the target contract latest block %d is ahead of the source contract latest block %d", latestTargetContractBlock, latestSourceContractBlock)
+	default:
+		lookupStartHeight, err := sourceEVMClient.BlockNumber(ctx)
+		if err != nil {
+			return report, err
+		}
+		latestSourceContractNonce, err := sourceBlobstreamX.StateProofNonce(opts)
+		if err != nil {
+			return report, err
+		}
+		events, err := getAllDataCommitmentStoredEvents(
+			ctx,
+			logger,
+			&sourceBlobstreamX.BlobstreamXFilterer,
+			int64(lookupStartHeight),
+			config.FilterRange,
+			latestSourceContractNonce.Int64(),
+			int64(latestTargetContractBlock),
+		)
+		if err != nil {
+			return report, err
+		}
+		if event, ok := events[int64(latestTargetContractBlock)]; ok {
+			report.add(CheckStartBlock, true, "the target contract latest block %d is the start block of the source proof nonce %d", latestTargetContractBlock, event.ProofNonce.Int64())
+		} else {
+			report.add(CheckStartBlock, false, "no source proof starts at the target contract latest block %d", latestTargetContractBlock)
+		}
+	}
+
+	sourceHeader, err := sourceBlobstreamX.BlockHeightToHeaderHash(opts, latestTargetContractBlock)
+	if err != nil {
+		return report, err
+	}
+	targetHeader, err := targetBlobstreamX.BlockHeightToHeaderHash(opts, latestTargetContractBlock)
+	if err != nil {
+		return report, err
+	}
+	switch {
+	case targetHeader == [32]byte{}:
+		report.add(CheckTrustedHeader, false, "the target contract has no trusted header at block %d", latestTargetContractBlock)
+	case sourceHeader == [32]byte{}:
+		report.add(CheckTrustedHeader, false, "the source contract has no header at block %d", latestTargetContractBlock)
+	case sourceHeader != targetHeader:
+		report.add(CheckTrustedHeader, false, "the target trusted header %s at block %d doesn't match the source header %s", ethcmn.Hash(targetHeader).Hex(), latestTargetContractBlock, ethcmn.Hash(sourceHeader).Hex())
+	default:
+		report.add(CheckTrustedHeader, true, "the target trusted header at block %d matches the source one: %s", latestTargetContractBlock, ethcmn.Hash(targetHeader).Hex())
+	}
+
+	targetGateway, err := targetBlobstreamX.Gateway(opts)
+	if err != nil {
+		return report, err
+	}
+	if targetGateway != ethcmn.HexToAddress(config.TargetChainGatewayAddress) {
+		report.add(CheckGateway, false, "the target contract uses the gateway %s instead of the configured %s", targetGateway.Hex(), config.TargetChainGatewayAddress)
+	} else {
+		report.add(CheckGateway, true, "the target contract uses the configured gateway %s", targetGateway.Hex())
+	}
+
+	if err := checkFunctionIDs(opts, &report, config.FunctionIDs, targetBlobstreamX, gateway); err != nil {
+		return report, err
+	}
+
+	if err := checkSignerBalance(ctx, &report, config, preflightConfig, targetEVMClient); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// checkChainID checks that the client chain ID is the expected one, if set.
+func checkChainID(ctx context.Context, report *PreflightReport, name string, client *ethclient.Client, expected *big.Int) error {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case expected == nil:
+		report.add(name, true, "chain ID %s, not checked", chainID)
+	case chainID.Cmp(expected) != 0:
+		report.add(name, false, "expected chain ID %s, got %s", expected, chainID)
+	default:
+		report.add(name, true, "chain ID %s", chainID)
+	}
+	return nil
+}
+
+// checkFunctionIDs checks that the mapped target function IDs are registered with verifiers in the
+// gateway, and that the target contract circuits function IDs are mapped.
+func checkFunctionIDs(
+	opts *bind.CallOpts,
+	report *PreflightReport,
+	functionIDs map[[32]byte][32]byte,
+	targetBlobstreamX *blobstreamxwrapper.BlobstreamX,
+	gateway *bindings.SuccinctGatewayCaller,
+) error {
+	mapped := make(map[[32]byte]struct{}, len(functionIDs))
+	for _, target := range functionIDs {
+		mapped[target] = struct{}{}
+	}
+	for target := range mapped {
+		verifier, err := gateway.Verifiers(opts, target)
+		if err != nil {
+			return err
+		}
+		if verifier == (ethcmn.Address{}) {
+			report.add(CheckFunctionIDs, false, "the function ID %s has no verifier registered in the target gateway", ethcmn.Hash(target).Hex())
+			return nil
+		}
+	}
+
+	headerRange, err := targetBlobstreamX.HeaderRangeFunctionId(opts)
+	if err != nil {
+		return err
+	}
+	nextHeader, err := targetBlobstreamX.NextHeaderFunctionId(opts)
+	if err != nil {
+		return err
+	}
+	for name, functionID := range map[string][32]byte{"header range": headerRange, "next header": nextHeader} {
+		if _, ok := mapped[functionID]; !ok {
+			report.add(CheckFunctionIDs, false, "the target contract %s function ID %s is not mapped to any source function ID", name, ethcmn.Hash(functionID).Hex())
+			return nil
+		}
+	}
+	report.add(CheckFunctionIDs, true, "the %d mapped function IDs have verifiers registered in the target gateway", len(mapped))
+	return nil
+}
+
+// checkSignerBalance checks that the signer balance can pay for the configured number of proofs at the
+// current gas price. The balance should also cover the gas limit of a single submission, otherwise the
+// transactions are rejected.
+func checkSignerBalance(
+	ctx context.Context,
+	report *PreflightReport,
+	config Config,
+	preflightConfig PreflightConfig,
+	targetEVMClient *ethclient.Client,
+) error {
+	signer := config.senderAddress()
+	if signer == (ethcmn.Address{}) {
+		report.add(CheckSignerBalance, true, "the sender address is unknown, the signer balance isn't checked")
+		return nil
+	}
+	balance, err := targetEVMClient.BalanceAt(ctx, signer, nil)
+	if err != nil {
+		return err
+	}
+	gasPrice, err := targetEVMClient.SuggestGasPrice(ctx)
+	if err != nil {
+		return err
+	}
+	gas := new(big.Int).Mul(big.NewInt(preflightConfig.Proofs), new(big.Int).SetUint64(preflightConfig.GasPerProof))
+	if gasLimit := new(big.Int).SetUint64(replayGasLimit); gas.Cmp(gasLimit) < 0 {
+		gas = gasLimit
+	}
+	required := new(big.Int).Mul(gas, gasPrice)
+	if balance.Cmp(required) < 0 {
+		report.add(CheckSignerBalance, false, "the signer %s balance %s wei is lower than the %s wei needed for %d proofs at %s wei per gas", signer.Hex(), balance, required, preflightConfig.Proofs, gasPrice)
+	} else {
+		report.add(CheckSignerBalance, true, "the signer %s balance %s wei covers %d proofs at %s wei per gas", signer.Hex(), balance, preflightConfig.Proofs, gasPrice)
+	}
+	return nil
+}
@@ -1,316 +1,424 @@
 package replay
 
 import (
-	"bytes"
 	"context"
-	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
+	"github.com/celestiaorg/blobstream-ops/auditlog"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/proofverify"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/celestiaorg/blobstream-ops/tracing"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
 	"github.com/succinctlabs/succinctx/bindings"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/rpc/client/http"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func Follow(
-	ctx context.Context,
+// Config the configuration of the replayer.
+type Config struct {
+	// Verify set to verify the data commitments against the core RPC before replaying their proofs.
+	Verify bool
+	// CoreRPC the trusted core RPC endpoint the data commitments are verified against. Only used for auditing.
+	CoreRPC                         string
+	SourceBlobstreamContractAddress string
+	TargetBlobstreamContractAddress string
+	TargetChainGatewayAddress       string
+	// SourceChainGatewayAddress the source chain succinct gateway the proofs are extracted from.
+	SourceChainGatewayAddress string
+	// Signer signs the transactions sent to the target chain. It can be nil if a sender not needing it is set.
+	Signer signer.Signer
+	// Sender sends the proof submission transactions. Defaults to signing them using the signer and sending
+	// them directly to the target chain.
+	Sender TxSender
+	// FunctionIDs maps the function IDs of the source gateway calls to the target gateway function IDs.
+	FunctionIDs map[[32]byte][32]byte
+	// Circuits maps the source function IDs to the encoding of their circuit input and output. The encoding
+	// of the function IDs that aren't set is detected from their input length.
+	Circuits map[[32]byte]CircuitEncoding
+	// ProofVerifier verifies the proofs off-chain before submitting them. Nil if disabled.
+	ProofVerifier *proofverify.Verifier
+	// Guardian freezes the target contract on a confirmed mismatch. Nil if disabled.
+	Guardian *Guardian
+	// FilterRange the eth_getLogs filter range used when querying the source and target contracts events.
+	FilterRange int64
+	// Archive the proofs archive replayed instead of the source chain proofs. Nil to replay from the source
+	// chain.
+	Archive *Archive
+	// LagAlertThreshold the lag behind the source contract above which an alert is sent. Zero disables the alert.
+	LagAlertThreshold time.Duration
+	// MinSignerBalance the signer balance, in wei, below which an alert is sent. Nil disables the alert.
+	MinSignerBalance *big.Int
+	// MaxSubmissionFailures the number of consecutive submission failures after which an alert is sent.
+	// Zero disables the alert.
+	MaxSubmissionFailures int
+}
+
+// Replayer replays the proofs of a source BlobstreamX contract to a target BlobstreamX contract.
+type Replayer struct {
+	logger          tmlog.Logger
+	config          Config
+	trpc            *http.HTTP
+	sourceEVMClient *ethclient.Client
+	targetEVMClient *ethclient.Client
+	metrics         *metrics.Replay
+	state           *state
+	notifier        *notify.Notifier
+	events          *eventstream.Stream
+	auditLog        *auditlog.Log
+
+	// submissionFailures the number of consecutive submission failures.
+	submissionFailures int
+	// behindSince the time since which the target contract is behind the source contract.
+	behindSince time.Time
+	// chainID the cached target chain ID.
+	chainID string
+
+	sourceBlobstreamX *blobstreamxwrapper.BlobstreamX
+	targetBlobstreamX *blobstreamxwrapper.BlobstreamX
+	gatewayABI        *abi.ABI
+	source            proofSource
+	sender            TxSender
+	circuits          circuits
+}
+
+// NewReplayer creates a new replayer. The tendermint RPC client is only used if the
+// verification is enabled. The audit log is optional, and can be nil.
+func NewReplayer(
 	logger tmlog.Logger,
-	verify bool,
+	config Config,
 	trpc *http.HTTP,
 	sourceEVMClient *ethclient.Client,
 	targetEVMClient *ethclient.Client,
-	sourceBlobstreamContractAddress string,
-	targetBlobstreamContractAddress string,
-	targetChainGatewayAddress string,
-	privateKey *ecdsa.PrivateKey,
-	headerRangeFunctionID [32]byte,
-	nextHeaderFunctionID [32]byte,
-	filterRange int64,
-) error {
-	logger.Info("listening for new proofs on the source chain")
-	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(sourceBlobstreamContractAddress), sourceEVMClient)
+	replayMetrics *metrics.Replay,
+	notifier *notify.Notifier,
+	events *eventstream.Stream,
+	auditLog *auditlog.Log,
+) (*Replayer, error) {
+	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(config.SourceBlobstreamContractAddress), sourceEVMClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(targetBlobstreamContractAddress), sourceEVMClient)
+	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(config.TargetBlobstreamContractAddress), targetEVMClient)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	newEvents := make(chan *blobstreamxwrapper.BlobstreamXDataCommitmentStored)
-	subscription, err := sourceBlobstreamX.WatchDataCommitmentStored(&bind.WatchOpts{Context: ctx}, newEvents, nil, nil, nil)
+	gatewayABI, err := bindings.SuccinctGatewayMetaData.GetAbi()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer subscription.Unsubscribe()
 
-	gateway, err := bindings.NewSuccinctGateway(ethcmn.HexToAddress(targetChainGatewayAddress), targetEVMClient)
-	if err != nil {
-		return err
+	// without a sender nor a signer, the replayer can only prepare the transactions
+	sender := config.Sender
+	if sender == nil && config.Signer != nil {
+		sender = NewDirectSender(config.Signer, targetEVMClient)
+	}
+	var source proofSource
+	if config.Archive != nil {
+		source = newArchiveSource(config.Archive)
+	} else {
+		// the source gateway calls are traced using the source EVM client if they're not sent through a known wrapper
+		extractor, err := fulfillcall.NewExtractor(ethcmn.HexToAddress(config.SourceChainGatewayAddress), sourceEVMClient.Client())
+		if err != nil {
+			return nil, err
+		}
+		source = &chainSource{
+			logger:      logger,
+			client:      sourceEVMClient,
+			blobstreamX: sourceBlobstreamX,
+			extractor:   extractor,
+			circuits:    config.Circuits,
+			filterRange: config.FilterRange,
+		}
 	}
-	abi, err := bindings.SuccinctGatewayMetaData.GetAbi()
+
+	return &Replayer{
+		logger:            logger,
+		config:            config,
+		trpc:              trpc,
+		sourceEVMClient:   sourceEVMClient,
+		targetEVMClient:   targetEVMClient,
+		metrics:           replayMetrics,
+		state:             newState(),
+		notifier:          notifier,
+		events:            events,
+		auditLog:          auditLog,
+		sourceBlobstreamX: sourceBlobstreamX,
+		targetBlobstreamX: targetBlobstreamX,
+		gatewayABI:        gatewayABI,
+		source:            source,
+		sender:            sender,
+		circuits:          config.Circuits,
+	}, nil
+}
+
+// Follow listens for new proofs on the source contract and replays them to the target contract.
+// While paused, the new proofs are not replayed until the replayer is resumed.
+func (r *Replayer) Follow(ctx context.Context) error {
+	if r.config.Archive != nil {
+		return errors.New("the new proofs can't be followed when replaying from an archive")
+	}
+	r.logger.Info("listening for new proofs on the source chain")
+	newEvents := make(chan *blobstreamxwrapper.BlobstreamXDataCommitmentStored)
+	subscription, err := r.sourceBlobstreamX.WatchDataCommitmentStored(&bind.WatchOpts{Context: ctx}, newEvents, nil, nil, nil)
 	if err != nil {
 		return err
 	}
+	defer subscription.Unsubscribe()
+
+	stallTicker := time.NewTicker(time.Minute)
+	defer stallTicker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case event := <-newEvents:
-			latestTargetContractBlock, err := targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+		case err := <-subscription.Err():
+			// the events emitted while disconnected would be missed, the replay should be restarted to catch up on them
+			return r.fail(metrics.FailureRPC, fmt.Errorf("the source contract events subscription failed: %w", err))
+		case <-stallTicker.C:
+			err := r.checkStall(ctx)
 			if err != nil {
 				return err
 			}
-			if event.StartBlock < latestTargetContractBlock {
-				logger.Info("the target contract is at a higher block, waiting for new events", "event_start_block", event.StartBlock, "target_contract_latest_block", latestTargetContractBlock)
-				continue
-			} else if event.StartBlock > latestTargetContractBlock {
-				logger.Info("the target contract needs to catchup", "event_start_block", event.StartBlock, "target_contract_latest_block", latestTargetContractBlock)
-				err = Catchup(
-					ctx,
-					logger,
-					verify,
-					trpc,
-					sourceEVMClient,
-					targetEVMClient,
-					sourceBlobstreamContractAddress,
-					targetBlobstreamContractAddress,
-					targetChainGatewayAddress,
-					privateKey,
-					headerRangeFunctionID,
-					nextHeaderFunctionID,
-					filterRange,
-				)
-				if err != nil {
-					return err
-				}
-				latestTargetContractBlock, err = targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
-				if err != nil {
-					return err
-				}
-				if event.EndBlock == latestTargetContractBlock {
-					// the contract is already up to date
-					logger.Info("contract up to date", "target_contract_latest_block", event.EndBlock)
-					continue
-				}
-			}
-			logger.Debug("getting transaction containing the proof", "nonce", event.ProofNonce.Int64(), "hash", event.Raw.TxHash.Hex(), "start_block", event.StartBlock)
-			tx, _, err := sourceEVMClient.TransactionByHash(ctx, event.Raw.TxHash)
+		case <-r.state.resumed:
+			latestSourceContractBlock, err := r.latestSourceBlock(ctx)
 			if err != nil {
 				return err
 			}
-
-			logger.Debug("decoding the proof")
-			rawMap := make(map[string]interface{})
-			inputArgs := abi.Methods["fulfillCall"].Inputs
-			err = inputArgs.UnpackIntoMap(rawMap, tx.Data()[4:])
+			latestTargetContractBlock, err := r.latestTargetBlock(ctx, latestSourceContractBlock)
 			if err != nil {
 				return err
 			}
-
-			decodedArgs, err := toFulfillCallArgs(rawMap)
-			if err != nil {
-				return err
-			}
-
-			// update the address to be the target blobstreamX contract for the callback
-			decodedArgs.CallbackAddress = ethcmn.HexToAddress(targetBlobstreamContractAddress)
-			if event.EndBlock-event.StartBlock > 1 {
-				// this is a header range proof
-				decodedArgs.FunctionID = headerRangeFunctionID
-			} else {
-				// this is a next header proof
-				decodedArgs.FunctionID = nextHeaderFunctionID
-			}
-
-			logger.Info("replaying the proof", "nonce", event.ProofNonce.Int64())
-			opts, err := newTransactOptsBuilder(privateKey)(ctx, targetEVMClient, 25000000)
-			if err != nil {
-				return err
+			if latestTargetContractBlock < latestSourceContractBlock {
+				r.logger.Info("replaying the proofs missed while paused", "target_contract_latest_block", latestTargetContractBlock)
+				err = r.Catchup(ctx)
+				if err != nil {
+					return err
+				}
 			}
-			err = submitProof(
-				ctx,
-				logger,
-				targetEVMClient,
-				opts,
-				gateway,
-				targetBlobstreamX,
-				decodedArgs,
-				event.ProofNonce.Int64(),
-				3*time.Minute,
-			)
+		case event := <-newEvents:
+			err := r.replayNewEvent(ctx, event)
 			if err != nil {
 				return err
 			}
-			logger.Info("successfully replayed proof", "nonce", event.ProofNonce.Int64())
 		}
 	}
 }
 
-func Catchup(
-	ctx context.Context,
-	logger tmlog.Logger,
-	verify bool,
-	trpc *http.HTTP,
-	sourceEVMClient *ethclient.Client,
-	targetEVMClient *ethclient.Client,
-	sourceBlobstreamContractAddress string,
-	targetBlobstreamContractAddress string,
-	targetChainGatewayAddress string,
-	privateKey *ecdsa.PrivateKey,
-	headerRangeFunctionID [32]byte,
-	nextHeaderFunctionID [32]byte,
-	filterRange int64,
-) error {
-	lookupStartHeight, err := sourceEVMClient.BlockNumber(ctx)
+// checkStall sends an alert if the target contract has been behind the source contract
+// for longer than the lag alert threshold.
+func (r *Replayer) checkStall(ctx context.Context) error {
+	if r.config.LagAlertThreshold <= 0 {
+		return nil
+	}
+	latestSourceContractBlock, err := r.latestSourceBlock(ctx)
 	if err != nil {
 		return err
 	}
-
-	sourceBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(sourceBlobstreamContractAddress), sourceEVMClient)
+	latestTargetContractBlock, err := r.latestTargetBlock(ctx, latestSourceContractBlock)
 	if err != nil {
 		return err
 	}
+	if latestTargetContractBlock >= latestSourceContractBlock {
+		r.behindSince = time.Time{}
+		return nil
+	}
+	if r.behindSince.IsZero() {
+		r.behindSince = time.Now()
+		return nil
+	}
+	if stalled := time.Since(r.behindSince); stalled > r.config.LagAlertThreshold {
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindLag,
+			Severity: notify.SeverityWarning,
+			Summary:  fmt.Sprintf("the target contract has been behind the source contract for %s", stalled.Round(time.Second)),
+			Details: map[string]string{
+				"target_contract":              r.config.TargetBlobstreamContractAddress,
+				"source_contract_latest_block": fmt.Sprint(latestSourceContractBlock),
+				"target_contract_latest_block": fmt.Sprint(latestTargetContractBlock),
+				"paused":                       fmt.Sprint(r.state.paused()),
+				"threshold":                    r.config.LagAlertThreshold.String(),
+			},
+		})
+	}
+	return nil
+}
 
-	targetBlobstreamX, err := blobstreamxwrapper.NewBlobstreamX(ethcmn.HexToAddress(targetBlobstreamContractAddress), sourceEVMClient)
+// replayNewEvent replays the proof of a new event emitted by the source contract, catching up first
+// if the target contract is behind the start block of the event.
+func (r *Replayer) replayNewEvent(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (err error) {
+	ctx, span := tracing.Start(ctx, "replay.follow.new_event", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	latestSourceContractBlock, err := r.latestSourceBlock(ctx)
 	if err != nil {
 		return err
 	}
-
-	latestSourceContractBlock, err := sourceBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+	latestTargetContractBlock, err := r.latestTargetBlock(ctx, latestSourceContractBlock)
 	if err != nil {
 		return err
 	}
-
-	latestTargetContractBlock, err := targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
-	if err != nil {
+	if r.state.paused() {
+		r.logger.Info("replay paused, not replaying the new proof", "nonce", event.ProofNonce.Int64(), "target_contract_latest_block", latestTargetContractBlock)
+		return nil
+	}
+	if event.StartBlock < latestTargetContractBlock {
+		r.logger.Info("the target contract is at a higher block, waiting for new events", "event_start_block", event.StartBlock, "target_contract_latest_block", latestTargetContractBlock)
+		return nil
+	} else if event.StartBlock > latestTargetContractBlock {
+		r.logger.Info("the target contract needs to catchup", "event_start_block", event.StartBlock, "target_contract_latest_block", latestTargetContractBlock)
+		err = r.Catchup(ctx)
+		if err != nil {
+			return err
+		}
+		if r.state.paused() {
+			// the catchup stopped on a pause, the new proof is replayed once resumed
+			r.logger.Info("replay paused during the catchup, not replaying the new proof", "nonce", event.ProofNonce.Int64())
+			return nil
+		}
+		latestTargetContractBlock, err = r.latestTargetBlock(ctx, latestSourceContractBlock)
+		if err != nil {
+			return err
+		}
+		if event.EndBlock == latestTargetContractBlock {
+			// the contract is already up to date
+			r.logger.Info("contract up to date", "target_contract_latest_block", event.EndBlock)
+			return nil
+		}
+	}
+	r.updateLagSeconds(ctx, event)
+	r.setCurrentProof(event)
+	r.publishEvent(eventstream.TypeDiscovered, nil)
+	startTime := time.Now()
+	if err := r.verifyProof(ctx, event, latestTargetContractBlock); err != nil {
 		return err
 	}
-
-	logger.Info("catching up", "latest_source_contract_block", latestSourceContractBlock, "latest_target_contract_block", latestTargetContractBlock)
-
-	latestSourceContractNonce, err := sourceBlobstreamX.StateProofNonce(&bind.CallOpts{Context: ctx})
+	decodedArgs, _, err := r.decodeProof(ctx, event, nil, "nonce", event.ProofNonce.Int64())
 	if err != nil {
 		return err
 	}
 
-	dataCommitmentEvents, err := getAllDataCommitmentStoredEvents(
+	r.logger.Info("replaying the proof", "nonce", event.ProofNonce.Int64())
+	receipt, err := r.submitProof(
 		ctx,
-		logger,
-		&sourceBlobstreamX.BlobstreamXFilterer,
-		int64(lookupStartHeight),
-		filterRange,
-		latestSourceContractNonce.Int64(),
-		int64(latestTargetContractBlock),
+		decodedArgs,
+		event.ProofNonce.Int64(),
+		3*time.Minute,
 	)
 	if err != nil {
 		return err
 	}
+	if err := r.verifyTargetState(ctx, event, receipt); err != nil {
+		return err
+	}
+	r.recordConfirmed(ctx, receipt)
+	r.metrics.ProofsReplayed.Inc()
+	r.metrics.ReplayLatency.Observe(time.Since(startTime).Seconds())
+	r.submissionFailures = 0
+	r.setLagSeconds(0)
+	r.clearCurrentProof()
+	r.updateSignerBalance(ctx)
+	if _, err := r.latestTargetBlock(ctx, latestSourceContractBlock); err != nil {
+		return err
+	}
+	r.logger.Info("successfully replayed proof", "nonce", event.ProofNonce.Int64())
+	return nil
+}
+
+// Catchup replays the proofs needed for the target contract to reach the latest block of the
+// source contract. The replayer is marked as ready once the target contract is up to date.
+// If the replayer is paused, it returns without replaying the remaining proofs.
+func (r *Replayer) Catchup(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "replay.catchup")
+	defer func() { tracing.End(span, err) }()
 
-	gateway, err := bindings.NewSuccinctGateway(ethcmn.HexToAddress(targetChainGatewayAddress), targetEVMClient)
+	latestSourceContractBlock, err := r.latestSourceBlock(ctx)
 	if err != nil {
 		return err
 	}
-	abi, err := bindings.SuccinctGatewayMetaData.GetAbi()
+
+	latestTargetContractBlock, err := r.latestTargetBlock(ctx, latestSourceContractBlock)
 	if err != nil {
 		return err
 	}
 
+	if latestTargetContractBlock >= latestSourceContractBlock {
+		r.logger.Info("target contract is already up to date", "latest_target_contract_block", latestTargetContractBlock)
+		r.setReady()
+		return nil
+	}
+
+	r.logger.Info("catching up", "latest_source_contract_block", latestSourceContractBlock, "latest_target_contract_block", latestTargetContractBlock)
+	r.updateSignerBalance(ctx)
+
+	scanCtx, scanSpan := tracing.Start(ctx, "replay.scan_events")
+	dataCommitmentEvents, err := r.source.events(scanCtx, latestTargetContractBlock)
+	tracing.End(scanSpan, err)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+
 	for startHeight := latestTargetContractBlock; startHeight < latestSourceContractBlock; {
 		event, exists := dataCommitmentEvents[int64(startHeight)]
 		if !exists {
 			return fmt.Errorf("couldn't find a proof that starts at height %d in events", startHeight)
 		}
+		r.updateLagSeconds(ctx, &event)
+		r.setCurrentProof(&event)
+		r.publishEvent(eventstream.TypeDiscovered, nil)
+		startTime := time.Now()
 
-		if verify {
-			logger.Info("verifying data root tuple root", "proof_nonce_in_source_contract", event.ProofNonce, "start_block", event.StartBlock, "end_block", event.EndBlock)
-			coreDataCommitment, err := trpc.DataCommitment(ctx, event.StartBlock, event.EndBlock)
-			if err != nil {
-				return err
-			}
-			if bytes.Equal(coreDataCommitment.DataCommitment.Bytes(), event.DataCommitment[:]) {
-				logger.Info("data commitment verified")
-			} else {
-				logger.Error(
-					"data commitment mismatch!! quitting",
-					"proof_nonce_in_source_contract",
-					event.ProofNonce,
-					"start_block",
-					event.StartBlock,
-					"end_block",
-					event.EndBlock,
-					"expected_data_commitment",
-					hex.EncodeToString(coreDataCommitment.DataCommitment.Bytes()),
-					"actual_data_commitment",
-					hex.EncodeToString(event.DataCommitment[:]),
-				)
-				return fmt.Errorf("data commitment mistmatch. start height %d end height %d", event.StartBlock, event.EndBlock)
-			}
+		if err := r.verifyProof(ctx, &event, latestTargetContractBlock); err != nil {
+			return err
 		}
 
-		latestSourceBlock, err := sourceBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+		latestSourceBlock, err := r.latestSourceBlock(ctx)
 		if err != nil {
 			return err
 		}
 
-		latestTargetContractBlock, err = targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+		latestTargetContractBlock, err = r.latestTargetBlock(ctx, latestSourceBlock)
 		if err != nil {
 			return err
 		}
 		if latestTargetContractBlock >= latestSourceBlock {
 			// contract already up to date
+			r.setLagSeconds(0)
+			r.clearCurrentProof()
+			r.setReady()
 			return nil
 		}
 
-		logger.Debug("getting transaction containing the proof", "startHeight", startHeight, "hash", event.Raw.TxHash.Hex())
-		tx, _, err := sourceEVMClient.TransactionByHash(ctx, event.Raw.TxHash)
-		if err != nil {
-			return err
-		}
-
-		logger.Debug("decoding the proof")
-		rawMap := make(map[string]interface{})
-		inputArgs := abi.Methods["fulfillCall"].Inputs
-		err = inputArgs.UnpackIntoMap(rawMap, tx.Data()[4:])
-		if err != nil {
-			return err
+		if r.state.paused() {
+			r.logger.Info("replay paused, stopping catchup", "latest_target_contract_block", latestTargetContractBlock)
+			r.clearCurrentProof()
+			return nil
 		}
 
-		decodedArgs, err := toFulfillCallArgs(rawMap)
+		decodedArgs, _, err := r.decodeProof(ctx, &event, nil, "startHeight", startHeight)
 		if err != nil {
 			return err
 		}
 
-		// update the address to be the target blobstreamX contract for the callback
-		decodedArgs.CallbackAddress = ethcmn.HexToAddress(targetBlobstreamContractAddress)
-
-		if event.EndBlock-event.StartBlock > 1 {
-			// this is a header range proof
-			decodedArgs.FunctionID = headerRangeFunctionID
-		} else {
-			// this is a next header proof
-			decodedArgs.FunctionID = nextHeaderFunctionID
-		}
-
-		logger.Info("replaying the proof", "startHeight", startHeight)
-		opts, err := newTransactOptsBuilder(privateKey)(ctx, targetEVMClient, 25000000)
-		if err != nil {
-			return err
-		}
-		err = submitProof(
+		r.logger.Info("replaying the proof", "startHeight", startHeight)
+		receipt, err := r.submitProof(
 			ctx,
-			logger,
-			targetEVMClient,
-			opts,
-			gateway,
-			targetBlobstreamX,
 			decodedArgs,
 			int64(startHeight),
 			3*time.Minute,
@@ -318,28 +426,310 @@ func Catchup(
 		if err != nil {
 			return err
 		}
+		r.updateSignerBalance(ctx)
 		// make sure the contract was updated
-		latestTargetContractBlock, err = targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+		latestTargetContractBlock, err = r.latestTargetBlock(ctx, latestSourceBlock)
 		if err != nil {
 			return err
 		}
 		if latestTargetContractBlock == event.EndBlock {
 			// contract updated successfully, we can advance
+			if err := r.verifyTargetState(ctx, &event, receipt); err != nil {
+				return err
+			}
+			r.recordConfirmed(ctx, receipt)
+			r.metrics.ProofsReplayed.Inc()
+			r.metrics.ReplayLatency.Observe(time.Since(startTime).Seconds())
+			r.submissionFailures = 0
 			startHeight = event.EndBlock
 		} else {
-			logger.Error("contract did not update successfully, retrying the same proof", "expected_target_height", event.EndBlock, "actual_target_height", latestTargetContractBlock)
+			r.recordFailure(metrics.FailureNotApplied)
+			r.logger.Error("contract did not update successfully, retrying the same proof", "expected_target_height", event.EndBlock, "actual_target_height", latestTargetContractBlock)
 		}
 	}
 
-	latestTargetContractBlock, err = targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+	latestTargetContractBlock, err = r.latestTargetBlock(ctx, latestSourceContractBlock)
 	if err != nil {
 		return err
 	}
+	r.setLagSeconds(0)
+	r.clearCurrentProof()
+	r.setReady()
 
-	logger.Info("contract up to date", "latest_target_contract_block", latestTargetContractBlock)
+	r.logger.Info("contract up to date", "latest_target_contract_block", latestTargetContractBlock)
 	return nil
 }
 
+// decodeProof gets the proof of the event from the source chain transaction, or the archive,
+// and decodes it into the fulfillCall arguments to submit to the target chain. The circuit
+// input and output are checked against the event before being returned, building on the provided
+// trusted header if set, or on the target contract one otherwise. The decoded circuit input and output
+// are returned along with the arguments, or nil if the circuit is unchecked.
+// The key and value are used to identify the proof in the logs.
+func (r *Replayer) decodeProof(
+	ctx context.Context,
+	event *blobstreamxwrapper.BlobstreamXDataCommitmentStored,
+	trustedHeader *[32]byte,
+	key string,
+	value interface{},
+) (_ fulfillCallArgs, _ *circuitIO, err error) {
+	ctx, span := tracing.Start(ctx, "replay.decode_proof", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	r.logger.Debug("getting the proof", key, value, "hash", event.Raw.TxHash.Hex())
+	decodedArgs, err := r.source.call(ctx, event)
+	if err != nil {
+		if errors.Is(err, errProofDecoding) {
+			return fulfillCallArgs{}, nil, r.fail(metrics.FailureDecode, err)
+		}
+		return fulfillCallArgs{}, nil, r.fail(metrics.FailureRPC, err)
+	}
+	io, err := r.checkCircuitIO(ctx, event, decodedArgs, trustedHeader)
+	if err != nil {
+		return fulfillCallArgs{}, nil, err
+	}
+
+	// update the address to be the target blobstreamX contract for the callback
+	decodedArgs.CallbackAddress = ethcmn.HexToAddress(r.config.TargetBlobstreamContractAddress)
+	targetFunctionID, ok := r.config.FunctionIDs[decodedArgs.FunctionID]
+	if !ok {
+		return fulfillCallArgs{}, nil, r.fail(
+			metrics.FailureDecode,
+			fmt.Errorf("no target function ID is mapped to the source function ID %s", hex.EncodeToString(decodedArgs.FunctionID[:])),
+		)
+	}
+	decodedArgs.FunctionID = targetFunctionID
+	if r.config.ProofVerifier != nil {
+		r.logger.Debug("verifying the proof off-chain", "function_id", hex.EncodeToString(decodedArgs.FunctionID[:]))
+		err := r.config.ProofVerifier.Verify(decodedArgs.FunctionID, decodedArgs.Input, decodedArgs.Output, decodedArgs.Proof)
+		if err != nil {
+			return fulfillCallArgs{}, nil, r.fail(metrics.FailureVerification, fmt.Errorf("off-chain proof verification failed: %w", err))
+		}
+	}
+	return decodedArgs, io, nil
+}
+
+// proofAttributes returns the span attributes identifying the proof of the event.
+func proofAttributes(event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		tracing.AttributeNonce.Int64(event.ProofNonce.Int64()),
+		tracing.AttributeStartBlock.Int64(int64(event.StartBlock)),
+		tracing.AttributeEndBlock.Int64(int64(event.EndBlock)),
+		tracing.AttributeTxHash.String(event.Raw.TxHash.Hex()),
+	}
+}
+
+// latestSourceBlock returns the latest block of the source contract and updates the corresponding metric.
+func (r *Replayer) latestSourceBlock(ctx context.Context) (uint64, error) {
+	latestBlock, err := r.source.latestBlock(ctx)
+	if err != nil {
+		return 0, r.fail(metrics.FailureRPC, err)
+	}
+	r.metrics.SourceLatestBlock.Set(float64(latestBlock))
+	r.state.update(func(status *Status) {
+		status.SourceLatestBlock = latestBlock
+	})
+	return latestBlock, nil
+}
+
+// latestTargetBlock returns the latest block of the target contract and updates the corresponding
+// metric, along with the lag to the provided latest source block.
+func (r *Replayer) latestTargetBlock(ctx context.Context, latestSourceBlock uint64) (uint64, error) {
+	latestBlock, err := r.targetBlobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return 0, r.fail(metrics.FailureRPC, err)
+	}
+	var lag uint64
+	if latestSourceBlock > latestBlock {
+		lag = latestSourceBlock - latestBlock
+	}
+	r.metrics.TargetLatestBlock.Set(float64(latestBlock))
+	r.metrics.LagBlocks.Set(float64(lag))
+	r.state.update(func(status *Status) {
+		status.TargetLatestBlock = latestBlock
+		status.LagBlocks = lag
+	})
+	return latestBlock, nil
+}
+
+// updateLagSeconds sets the lag in seconds to the time elapsed since the event was emitted in the source chain.
+func (r *Replayer) updateLagSeconds(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) {
+	eventTime, err := r.source.eventTime(ctx, event)
+	if err != nil {
+		r.logger.Debug("couldn't get the source block of the event", "block", event.Raw.BlockNumber, "err", err.Error())
+		return
+	}
+	lag := time.Since(eventTime)
+	r.setLagSeconds(lag.Seconds())
+	if r.config.LagAlertThreshold > 0 && lag > r.config.LagAlertThreshold {
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindLag,
+			Severity: notify.SeverityWarning,
+			Summary:  fmt.Sprintf("the target contract is lagging %s behind the source contract", lag.Round(time.Second)),
+			Details: map[string]string{
+				"target_contract": r.config.TargetBlobstreamContractAddress,
+				"proof_nonce":     event.ProofNonce.String(),
+				"lag":             lag.Round(time.Second).String(),
+				"threshold":       r.config.LagAlertThreshold.String(),
+			},
+		})
+	}
+}
+
+// setLagSeconds sets the lag in seconds in the metrics and the status.
+func (r *Replayer) setLagSeconds(lag float64) {
+	r.metrics.LagSeconds.Set(lag)
+	r.state.update(func(status *Status) {
+		status.LagSeconds = lag
+	})
+}
+
+// setCurrentProof sets the proof of the event as the one currently being replayed.
+func (r *Replayer) setCurrentProof(event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) {
+	r.state.update(func(status *Status) {
+		status.CurrentProof = &ProofStatus{
+			Nonce:          event.ProofNonce.Int64(),
+			StartBlock:     event.StartBlock,
+			EndBlock:       event.EndBlock,
+			DataCommitment: hex.EncodeToString(event.DataCommitment[:]),
+			SourceTxHash:   event.Raw.TxHash.Hex(),
+		}
+	})
+}
+
+func (r *Replayer) clearCurrentProof() {
+	r.state.update(func(status *Status) {
+		status.CurrentProof = nil
+	})
+}
+
+// setReady marks the replayer as ready, i.e. the target contract caught up with the source contract.
+func (r *Replayer) setReady() {
+	r.state.update(func(status *Status) {
+		if !status.Ready {
+			r.logger.Info("target contract caught up, replayer ready")
+		}
+		status.Ready = true
+	})
+}
+
+// updateSignerBalance updates the signer balance metric. It's skipped if the sender address is unknown.
+func (r *Replayer) updateSignerBalance(ctx context.Context) {
+	if r.sender == nil {
+		return
+	}
+	signerAddress := r.sender.Address()
+	if signerAddress == (ethcmn.Address{}) {
+		return
+	}
+	balance, err := r.targetEVMClient.BalanceAt(ctx, signerAddress, nil)
+	if err != nil {
+		r.logger.Debug("couldn't get the signer balance", "address", signerAddress.Hex(), "err", err.Error())
+		return
+	}
+	r.metrics.SignerBalance.Set(metrics.ToFloat(balance))
+	if r.config.MinSignerBalance != nil && balance.Cmp(r.config.MinSignerBalance) < 0 {
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindLowBalance,
+			Severity: notify.SeverityWarning,
+			Summary:  fmt.Sprintf("the signer %s balance is low", signerAddress.Hex()),
+			Details: map[string]string{
+				"signer":          signerAddress.Hex(),
+				"balance_wei":     balance.String(),
+				"min_balance_wei": r.config.MinSignerBalance.String(),
+			},
+		})
+	}
+}
+
+// fail records a failure of the provided class and returns the error.
+func (r *Replayer) fail(class string, err error) error {
+	r.recordFailure(class)
+	r.publishEvent(eventstream.TypeFailed, func(event *eventstream.Event) {
+		event.Error = fmt.Sprintf("%s: %s", class, err.Error())
+	})
+	return err
+}
+
+// publishEvent publishes an event of the provided type for the proof currently being replayed, if any.
+// The set function allows setting the fields that are specific to the event type.
+func (r *Replayer) publishEvent(eventType eventstream.Type, set func(event *eventstream.Event)) {
+	proof := r.state.snapshot().CurrentProof
+	if proof == nil {
+		return
+	}
+	event := eventstream.Event{
+		Type:           eventType,
+		StartBlock:     proof.StartBlock,
+		EndBlock:       proof.EndBlock,
+		DataCommitment: proof.DataCommitment,
+		SourceNonce:    proof.Nonce,
+		SourceTxHash:   proof.SourceTxHash,
+	}
+	if set != nil {
+		set(&event)
+	}
+	r.events.Publish(event)
+}
+
+// recordConfirmed publishes the confirmation of the proof currently being replayed, and appends it
+// to the audit log. The target nonce and transaction hash are taken from the receipt, which is nil
+// if the proof was committed by another transaction.
+func (r *Replayer) recordConfirmed(ctx context.Context, receipt *coregethtypes.Receipt) {
+	var (
+		targetTxHash string
+		targetNonce  int64
+	)
+	if receipt != nil {
+		targetTxHash = receipt.TxHash.Hex()
+		if stored := r.storedEvent(receipt, nil); stored != nil {
+			targetNonce = stored.ProofNonce.Int64()
+		}
+	}
+	r.publishEvent(eventstream.TypeConfirmed, func(event *eventstream.Event) {
+		event.TargetTxHash = targetTxHash
+		event.TargetNonce = targetNonce
+	})
+	if receipt != nil {
+		// the proofs committed by other transactions weren't submitted by this replayer
+		r.appendAuditEntry(ctx, auditlog.KindReplayed, targetTxHash)
+	}
+}
+
+// recordFailure increments the failures metric of the provided class, and sends an alert
+// if the submission failed too many times in a row.
+func (r *Replayer) recordFailure(class string) {
+	r.metrics.Failures.WithLabelValues(class).Inc()
+	switch class {
+	case metrics.FailureSubmission, metrics.FailureTimeout, metrics.FailureNotApplied:
+	default:
+		return
+	}
+	r.submissionFailures++
+	if r.config.MaxSubmissionFailures > 0 && r.submissionFailures >= r.config.MaxSubmissionFailures {
+		// the failure may stop the replay, so the alert is sent regardless of the replay context
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		r.notify(ctx, notify.Alert{
+			Kind:     notify.KindSubmissionFailures,
+			Severity: notify.SeverityCritical,
+			Summary:  fmt.Sprintf("the proofs submission to the target contract failed %d times in a row", r.submissionFailures),
+			Details: map[string]string{
+				"target_contract": r.config.TargetBlobstreamContractAddress,
+				"last_failure":    class,
+				"failures":        fmt.Sprint(r.submissionFailures),
+			},
+		})
+	}
+}
+
+// notify sends the alert. The errors are only logged as the alerting shouldn't stop the replay.
+func (r *Replayer) notify(ctx context.Context, alert notify.Alert) {
+	if err := r.notifier.Notify(ctx, alert); err != nil {
+		r.logger.Error("couldn't send alert", "kind", alert.Kind, "err", err.Error())
+	}
+}
+
 func getAllDataCommitmentStoredEvents(
 	ctx context.Context,
 	logger tmlog.Logger,
@@ -353,7 +743,7 @@ func getAllDataCommitmentStoredEvents(
 	dataCommitmentEvents := make(map[int64]blobstreamxwrapper.BlobstreamXDataCommitmentStored)
 	for eventLookupEnd := lookupStartHeight; eventLookupEnd > 0; eventLookupEnd -= filterRange {
 		logger.Debug("querying all the data commitment stored events", "evm_block_start", eventLookupEnd, "evm_block_end", eventLookupEnd-filterRange)
-		rangeStart := eventLookupEnd - filterRange
+		rangeStart := max(eventLookupEnd-filterRange, 0)
 		rangeEnd := uint64(eventLookupEnd)
 		events, err := blobstreamLogFilterer.FilterDataCommitmentStored(
 			&bind.FilterOpts{
@@ -372,11 +762,10 @@ func getAllDataCommitmentStoredEvents(
 		gatheredTheNecessaryEvents := false
 		for {
 			if events.Event != nil {
-				_, exists := dataCommitmentEvents[int64(events.Event.StartBlock)]
-				if exists {
-					continue
+				// the events of the blocks shared by two consecutive ranges are returned twice
+				if _, exists := dataCommitmentEvents[int64(events.Event.StartBlock)]; !exists {
+					dataCommitmentEvents[int64(events.Event.StartBlock)] = *events.Event
 				}
-				dataCommitmentEvents[int64(events.Event.StartBlock)] = *events.Event
 				if int64(events.Event.StartBlock) < latestTargetContractBlock {
 					gatheredTheNecessaryEvents = true
 				}
@@ -0,0 +1,66 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// pausingSink pauses the replayer once a proof is confirmed.
+type pausingSink struct {
+	replayer *Replayer
+}
+
+func (s pausingSink) Publish(line []byte) {
+	if bytes.Contains(line, []byte(`"type":"`+eventstream.TypeConfirmed+`"`)) {
+		s.replayer.Pause()
+	}
+}
+
+func (pausingSink) Close() error {
+	return nil
+}
+
+// TestReplayNewEventPausedDuringCatchup follows a new proof while the target contract is behind, and pauses
+// the replay once the catchup replayed a first proof. The new proof shouldn't be submitted while paused.
+func TestReplayNewEventPausedDuringCatchup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	target.Mine(t, 50*time.Millisecond)
+	for start := uint64(simchain.GenesisHeight); start < simchain.GenesisHeight+30; start += 10 {
+		source.CommitHeaderRange(ctx, t, key, start, start+10)
+	}
+	replayer := newSimReplayer(t, source, target, key, Config{})
+	replayer.events = eventstream.New(tmlog.NewNopLogger(), pausingSink{replayer: replayer})
+
+	filterer, err := blobstreamxwrapper.NewBlobstreamXFilterer(source.Manifest.BlobstreamX, source.Backend.Client())
+	require.NoError(t, err)
+	events, err := filterer.FilterDataCommitmentStored(&bind.FilterOpts{Context: ctx}, nil, nil, nil)
+	require.NoError(t, err)
+	defer events.Close()
+	var newEvent *blobstreamxwrapper.BlobstreamXDataCommitmentStored
+	for events.Next() {
+		newEvent = events.Event
+	}
+	require.NoError(t, events.Error())
+	require.NotNil(t, newEvent)
+	require.Equal(t, uint64(simchain.GenesisHeight+20), newEvent.StartBlock)
+
+	require.NoError(t, replayer.replayNewEvent(ctx, newEvent))
+	assert.True(t, replayer.Status().Paused)
+	assert.Equal(t, uint64(simchain.GenesisHeight+10), target.LatestBlock(ctx, t))
+}
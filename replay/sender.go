@@ -0,0 +1,230 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/signer"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// TxRequest a transaction to send to the target chain.
+type TxRequest struct {
+	To       ethcmn.Address
+	Data     []byte
+	GasLimit uint64
+	// GasPrice the gas price suggested by the target chain, increased when accelerating a stuck transaction.
+	GasPrice *big.Int
+	// Replaces the stuck transaction replaced by this one when accelerating. Nil for a new transaction.
+	Replaces *SentTx
+}
+
+// SentTx a transaction sent to the target chain, tracked by the replayer until it's included.
+type SentTx struct {
+	Hash ethcmn.Hash
+	// Nonce the transaction nonce. Zero if it's unknown, e.g. if the relayer didn't return it.
+	Nonce    uint64
+	GasPrice *big.Int
+}
+
+// TxSender sends the proof submission transactions to the target chain. The confirmation of the sent
+// transactions is tracked the same way regardless of the sender.
+type TxSender interface {
+	// Address returns the address of the account sending the transactions. Zero if it's unknown.
+	Address() ethcmn.Address
+	// Send sends the transaction without waiting for it to be included.
+	Send(ctx context.Context, req TxRequest) (SentTx, error)
+}
+
+// senderAddress returns the address of the account sending the proof submission transactions. Zero if it's
+// unknown.
+func (config Config) senderAddress() ethcmn.Address {
+	if config.Sender != nil {
+		return config.Sender.Address()
+	}
+	if config.Signer != nil {
+		return config.Signer.Address()
+	}
+	return ethcmn.Address{}
+}
+
+var (
+	_ TxSender = &DirectSender{}
+	_ TxSender = &RelayerSender{}
+)
+
+// DirectSender signs the transactions using the replay signer and broadcasts them.
+type DirectSender struct {
+	signer signer.Signer
+	// client the target chain client the nonces and chain ID are read from.
+	client *ethclient.Client
+	// broadcaster the client the signed transactions are sent to.
+	broadcaster *ethclient.Client
+}
+
+// NewDirectSender creates a new sender signing the transactions and sending them to the target chain.
+func NewDirectSender(txSigner signer.Signer, client *ethclient.Client) *DirectSender {
+	return &DirectSender{
+		signer:      txSigner,
+		client:      client,
+		broadcaster: client,
+	}
+}
+
+// NewPrivateSender creates a new sender signing the transactions and sending them to a private transactions
+// endpoint accepting eth_sendRawTransaction, instead of the public mempool of the target chain. The nonces
+// and chain ID are still read from the target chain client.
+func NewPrivateSender(txSigner signer.Signer, client *ethclient.Client, privateClient *ethclient.Client) *DirectSender {
+	return &DirectSender{
+		signer:      txSigner,
+		client:      client,
+		broadcaster: privateClient,
+	}
+}
+
+// Address returns the signer address.
+func (s *DirectSender) Address() ethcmn.Address {
+	return s.signer.Address()
+}
+
+// Send signs the transaction and broadcasts it. A transaction replacing a stuck one reuses its nonce,
+// otherwise the next pending nonce of the signer is used.
+func (s *DirectSender) Send(ctx context.Context, req TxRequest) (SentTx, error) {
+	var nonce uint64
+	if req.Replaces != nil {
+		nonce = req.Replaces.Nonce
+	} else {
+		var err error
+		nonce, err = s.client.PendingNonceAt(ctx, s.signer.Address())
+		if err != nil {
+			return SentTx{}, err
+		}
+	}
+	chainID, err := s.client.ChainID(ctx)
+	if err != nil {
+		return SentTx{}, fmt.Errorf("failed to get Ethereum chain ID: %w", err)
+	}
+
+	to := req.To
+	tx, err := s.signer.SignTx(ctx, coregethtypes.NewTx(&coregethtypes.LegacyTx{
+		Nonce:    nonce,
+		To:       &to,
+		Gas:      req.GasLimit,
+		GasPrice: req.GasPrice,
+		Data:     req.Data,
+	}), chainID)
+	if err != nil {
+		return SentTx{}, fmt.Errorf("failed to sign the transaction: %w", err)
+	}
+	if err := s.broadcaster.SendTransaction(ctx, tx); err != nil {
+		return SentTx{}, err
+	}
+	return SentTx{
+		Hash:     tx.Hash(),
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasPrice(),
+	}, nil
+}
+
+// RelayerSender posts the unsigned transactions to an HTTP relayer service, which signs and sends them, then
+// returns their hash. The replay host doesn't hold any key.
+//
+// The relayer receives a JSON object containing the "to" address, the hex encoded "data", the "gas_limit",
+// the suggested "gas_price" in wei as a decimal string, and the hash of the transaction it "replaces" when
+// accelerating a stuck one. It should respond with a JSON object containing the "tx_hash" of the sent
+// transaction, and optionally its "nonce".
+type RelayerSender struct {
+	url     string
+	token   string
+	address ethcmn.Address
+	client  *http.Client
+}
+
+// relayRequest the payload posted to the relayer.
+type relayRequest struct {
+	To       ethcmn.Address `json:"to"`
+	Data     hexutil.Bytes  `json:"data"`
+	GasLimit uint64         `json:"gas_limit"`
+	GasPrice string         `json:"gas_price"`
+	Replaces *ethcmn.Hash   `json:"replaces,omitempty"`
+}
+
+// relayResponse the relayer response.
+type relayResponse struct {
+	TxHash ethcmn.Hash `json:"tx_hash"`
+	Nonce  uint64      `json:"nonce"`
+}
+
+// NewRelayerSender creates a new sender posting the transactions to the relayer URL. The token, if set, is
+// sent as a bearer token. The address of the relayer account is only used to track its balance, and can be
+// zero if it's unknown.
+func NewRelayerSender(url string, token string, address ethcmn.Address) *RelayerSender {
+	return &RelayerSender{
+		url:     url,
+		token:   token,
+		address: address,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Address returns the relayer account address, if known.
+func (s *RelayerSender) Address() ethcmn.Address {
+	return s.address
+}
+
+// Send posts the transaction to the relayer and returns the hash of the transaction it sent.
+func (s *RelayerSender) Send(ctx context.Context, req TxRequest) (SentTx, error) {
+	payload := relayRequest{
+		To:       req.To,
+		Data:     req.Data,
+		GasLimit: req.GasLimit,
+		GasPrice: req.GasPrice.String(),
+	}
+	if req.Replaces != nil {
+		payload.Replaces = &req.Replaces.Hash
+	}
+	bz, err := json.Marshal(payload)
+	if err != nil {
+		return SentTx{}, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(bz))
+	if err != nil {
+		return SentTx{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return SentTx{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return SentTx{}, fmt.Errorf("relayer responded with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var relayed relayResponse
+	if err := json.NewDecoder(resp.Body).Decode(&relayed); err != nil {
+		return SentTx{}, fmt.Errorf("invalid relayer response: %w", err)
+	}
+	if relayed.TxHash == (ethcmn.Hash{}) {
+		return SentTx{}, errors.New("the relayer didn't return the transaction hash")
+	}
+	return SentTx{
+		Hash:     relayed.TxHash,
+		Nonce:    relayed.Nonce,
+		GasPrice: req.GasPrice,
+	}, nil
+}
@@ -0,0 +1,221 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/celestiaorg/blobstream-ops/signer"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testRelayedTxHash = ethcmn.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111")
+	testTxTarget      = ethcmn.HexToAddress("0x000000000000000000000000000000000000beef")
+)
+
+func TestRelayerSender(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		req   TxRequest
+		// status and response the relayer response.
+		status   int
+		response string
+		want     SentTx
+		// wantRequest the JSON request expected by the relayer.
+		wantRequest string
+		wantErr     string
+	}{
+		{
+			name:        "relayed",
+			token:       "secret",
+			req:         TxRequest{To: testTxTarget, Data: []byte{1, 2}, GasLimit: 100000, GasPrice: big.NewInt(10)},
+			status:      http.StatusOK,
+			response:    `{"tx_hash":"` + testRelayedTxHash.Hex() + `","nonce":7}`,
+			want:        SentTx{Hash: testRelayedTxHash, Nonce: 7, GasPrice: big.NewInt(10)},
+			wantRequest: `{"to":"0x000000000000000000000000000000000000beef","data":"0x0102","gas_limit":100000,"gas_price":"10"}`,
+		},
+		{
+			name: "replacement without a token",
+			req: TxRequest{
+				To:       testTxTarget,
+				Data:     []byte{1, 2},
+				GasLimit: 100000,
+				GasPrice: big.NewInt(12),
+				Replaces: &SentTx{Hash: testRelayedTxHash, Nonce: 7, GasPrice: big.NewInt(10)},
+			},
+			status:      http.StatusAccepted,
+			response:    `{"tx_hash":"0x2222222222222222222222222222222222222222222222222222222222222222"}`,
+			want:        SentTx{Hash: ethcmn.HexToHash("0x2222222222222222222222222222222222222222222222222222222222222222"), GasPrice: big.NewInt(12)},
+			wantRequest: `{"to":"0x000000000000000000000000000000000000beef","data":"0x0102","gas_limit":100000,"gas_price":"12","replaces":"` + testRelayedTxHash.Hex() + `"}`,
+		},
+		{
+			name:     "error status",
+			req:      TxRequest{To: testTxTarget, GasPrice: big.NewInt(10)},
+			status:   http.StatusBadGateway,
+			response: "insufficient funds\n",
+			wantErr:  "relayer responded with status 502: insufficient funds",
+		},
+		{
+			name:     "invalid response",
+			req:      TxRequest{To: testTxTarget, GasPrice: big.NewInt(10)},
+			status:   http.StatusOK,
+			response: "sent",
+			wantErr:  "invalid relayer response",
+		},
+		{
+			name:     "missing transaction hash",
+			req:      TxRequest{To: testTxTarget, GasPrice: big.NewInt(10)},
+			status:   http.StatusOK,
+			response: `{"nonce":7}`,
+			wantErr:  "the relayer didn't return the transaction hash",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				gotRequest       json.RawMessage
+				gotAuthorization string
+				gotContentType   string
+			)
+			relayer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotAuthorization = r.Header.Get("Authorization")
+				gotContentType = r.Header.Get("Content-Type")
+				if err := json.NewDecoder(r.Body).Decode(&gotRequest); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer relayer.Close()
+
+			address := ethcmn.HexToAddress("0x000000000000000000000000000000000000abcd")
+			sender := NewRelayerSender(relayer.URL, test.token, address)
+			assert.Equal(t, address, sender.Address())
+			sent, err := sender.Send(context.Background(), test.req)
+			assert.Equal(t, "application/json", gotContentType)
+			if test.token != "" {
+				assert.Equal(t, "Bearer "+test.token, gotAuthorization)
+			} else {
+				assert.Empty(t, gotAuthorization)
+			}
+			if test.wantErr != "" {
+				require.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, test.wantRequest, string(gotRequest))
+			assert.Equal(t, test.want, sent)
+		})
+	}
+}
+
+// ethService a stubbed eth namespace, serving the signer nonce and chain ID, and recording the raw
+// transactions it receives.
+type ethService struct {
+	nonce   uint64
+	chainID *big.Int
+
+	mu  sync.Mutex
+	txs []*coregethtypes.Transaction
+}
+
+func (s *ethService) ChainId() *hexutil.Big { //nolint:revive,stylecheck
+	return (*hexutil.Big)(s.chainID)
+}
+
+func (s *ethService) GetTransactionCount(_ ethcmn.Address, _ string) hexutil.Uint64 {
+	return hexutil.Uint64(s.nonce)
+}
+
+func (s *ethService) SendRawTransaction(raw hexutil.Bytes) (ethcmn.Hash, error) {
+	tx := new(coregethtypes.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return ethcmn.Hash{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.txs = append(s.txs, tx)
+	return tx.Hash(), nil
+}
+
+func (s *ethService) sent() []*coregethtypes.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*coregethtypes.Transaction{}, s.txs...)
+}
+
+// newEthEndpoint serves the stubbed eth namespace over HTTP, and returns a client dialled to it.
+func newEthEndpoint(t *testing.T, service *ethService) *ethclient.Client {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", service))
+	t.Cleanup(server.Stop)
+	endpoint := httptest.NewServer(server)
+	t.Cleanup(endpoint.Close)
+	client, err := ethclient.Dial(endpoint.URL)
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+// TestPrivateSender sends the transactions to a private endpoint. The nonces and chain ID are read from the
+// target chain endpoint, which never receives the transactions.
+func TestPrivateSender(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	stuck := &SentTx{Hash: testRelayedTxHash, Nonce: 3, GasPrice: big.NewInt(10)}
+	tests := []struct {
+		name      string
+		req       TxRequest
+		wantNonce uint64
+	}{
+		{
+			name:      "new transaction",
+			req:       TxRequest{To: testTxTarget, Data: []byte{1, 2}, GasLimit: 100000, GasPrice: big.NewInt(10)},
+			wantNonce: 5,
+		},
+		{
+			name:      "replacement",
+			req:       TxRequest{To: testTxTarget, Data: []byte{1, 2}, GasLimit: 100000, GasPrice: big.NewInt(12), Replaces: stuck},
+			wantNonce: stuck.Nonce,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			targetService := &ethService{nonce: 5, chainID: big.NewInt(1337)}
+			privateService := &ethService{chainID: big.NewInt(1)}
+			sender := NewPrivateSender(signer.NewKey(key), newEthEndpoint(t, targetService), newEthEndpoint(t, privateService))
+			assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender.Address())
+
+			sent, err := sender.Send(context.Background(), test.req)
+			require.NoError(t, err)
+			assert.Empty(t, targetService.sent())
+			txs := privateService.sent()
+			require.Len(t, txs, 1)
+			tx := txs[0]
+			assert.Equal(t, SentTx{Hash: tx.Hash(), Nonce: test.wantNonce, GasPrice: test.req.GasPrice}, sent)
+			assert.Equal(t, test.wantNonce, tx.Nonce())
+			assert.Equal(t, test.req.GasPrice, tx.GasPrice())
+			assert.Equal(t, test.req.GasLimit, tx.Gas())
+			assert.Equal(t, test.req.Data, tx.Data())
+			assert.Equal(t, testTxTarget, *tx.To())
+			// signed for the target chain, not the private endpoint one
+			from, err := coregethtypes.Sender(coregethtypes.LatestSignerForChainID(big.NewInt(1337)), tx)
+			require.NoError(t, err)
+			assert.Equal(t, sender.Address(), from)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/fulfillcall"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/ethclient"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// errProofDecoding returned when the proof couldn't be decoded from its source transaction, as opposed to
+// the source being unreachable.
+var errProofDecoding = errors.New("couldn't decode the proof")
+
+// proofSource provides the source proofs to replay: either the live source chain, or a proofs archive.
+type proofSource interface {
+	// latestBlock returns the latest block the source contract committed to.
+	latestBlock(ctx context.Context) (uint64, error)
+	// events returns the data commitment stored events needed to replay the proofs after the provided
+	// latest target contract block, indexed by their start block.
+	events(ctx context.Context, latestTargetContractBlock uint64) (map[int64]blobstreamxwrapper.BlobstreamXDataCommitmentStored, error)
+	// call returns the source fulfillCall arguments of the proof of the event, before mapping them to the
+	// target chain.
+	call(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (fulfillCallArgs, error)
+	// headerHash returns the header hash the source contract committed to at the provided height.
+	headerHash(ctx context.Context, height uint64) ([32]byte, error)
+	// eventTime returns the time of the source block the event was emitted in.
+	eventTime(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (time.Time, error)
+}
+
+var (
+	_ proofSource = &chainSource{}
+	_ proofSource = &archiveSource{}
+)
+
+// chainSource reads the proofs from the live source chain.
+type chainSource struct {
+	logger      tmlog.Logger
+	client      *ethclient.Client
+	blobstreamX *blobstreamxwrapper.BlobstreamX
+	extractor   *fulfillcall.Extractor
+	circuits    circuits
+	filterRange int64
+}
+
+func (s *chainSource) latestBlock(ctx context.Context) (uint64, error) {
+	return s.blobstreamX.LatestBlock(&bind.CallOpts{Context: ctx})
+}
+
+func (s *chainSource) events(ctx context.Context, latestTargetContractBlock uint64) (map[int64]blobstreamxwrapper.BlobstreamXDataCommitmentStored, error) {
+	lookupStartHeight, err := s.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latestSourceContractNonce, err := s.blobstreamX.StateProofNonce(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	return getAllDataCommitmentStoredEvents(
+		ctx,
+		s.logger,
+		&s.blobstreamX.BlobstreamXFilterer,
+		int64(lookupStartHeight),
+		s.filterRange,
+		latestSourceContractNonce.Int64(),
+		int64(latestTargetContractBlock),
+	)
+}
+
+func (s *chainSource) call(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (fulfillCallArgs, error) {
+	tx, _, err := s.client.TransactionByHash(ctx, event.Raw.TxHash)
+	if err != nil {
+		return fulfillCallArgs{}, err
+	}
+
+	s.logger.Debug("decoding the proof")
+	calls, err := s.extractor.Extract(ctx, tx)
+	if err != nil {
+		return fulfillCallArgs{}, fmt.Errorf("%w: %w", errProofDecoding, err)
+	}
+	return fulfillCallArgs(s.circuits.selectCall(event, calls)), nil
+}
+
+func (s *chainSource) headerHash(ctx context.Context, height uint64) ([32]byte, error) {
+	return s.blobstreamX.BlockHeightToHeaderHash(&bind.CallOpts{Context: ctx}, height)
+}
+
+func (s *chainSource) eventTime(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (time.Time, error) {
+	header, err := s.client.HeaderByNumber(ctx, new(big.Int).SetUint64(event.Raw.BlockNumber))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+// archiveSource reads the proofs from a proofs archive, without connecting to the source chain.
+type archiveSource struct {
+	archive *Archive
+	// byStartBlock the archived proofs indexed by their start block.
+	byStartBlock map[uint64]*ArchivedProof
+	// byEndBlock the archived proofs indexed by their end block.
+	byEndBlock map[uint64]*ArchivedProof
+}
+
+func newArchiveSource(archive *Archive) *archiveSource {
+	s := &archiveSource{
+		archive:      archive,
+		byStartBlock: make(map[uint64]*ArchivedProof, len(archive.Proofs)),
+		byEndBlock:   make(map[uint64]*ArchivedProof, len(archive.Proofs)),
+	}
+	for i := range archive.Proofs {
+		proof := &archive.Proofs[i]
+		s.byStartBlock[proof.StartBlock] = proof
+		s.byEndBlock[proof.EndBlock] = proof
+	}
+	return s
+}
+
+func (s *archiveSource) latestBlock(_ context.Context) (uint64, error) {
+	return s.archive.LatestBlock(), nil
+}
+
+func (s *archiveSource) events(_ context.Context, _ uint64) (map[int64]blobstreamxwrapper.BlobstreamXDataCommitmentStored, error) {
+	events := make(map[int64]blobstreamxwrapper.BlobstreamXDataCommitmentStored, len(s.archive.Proofs))
+	for _, proof := range s.archive.Proofs {
+		events[int64(proof.StartBlock)] = proof.event(s.archive.SourceContract)
+	}
+	return events, nil
+}
+
+func (s *archiveSource) call(_ context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (fulfillCallArgs, error) {
+	proof, err := s.proof(event)
+	if err != nil {
+		return fulfillCallArgs{}, err
+	}
+	return proof.Call.args(), nil
+}
+
+func (s *archiveSource) headerHash(_ context.Context, height uint64) ([32]byte, error) {
+	proof, ok := s.byEndBlock[height]
+	if !ok {
+		return [32]byte{}, fmt.Errorf("no archived proof ends at height %d", height)
+	}
+	return proof.TargetHeader, nil
+}
+
+func (s *archiveSource) eventTime(_ context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (time.Time, error) {
+	proof, err := s.proof(event)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(proof.SourceBlockTime), 0), nil
+}
+
+// proof returns the archived proof of the event.
+func (s *archiveSource) proof(event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (*ArchivedProof, error) {
+	proof, ok := s.byStartBlock[event.StartBlock]
+	if !ok || proof.ProofNonce != event.ProofNonce.Int64() {
+		return nil, fmt.Errorf("no archived proof of nonce %d starts at height %d", event.ProofNonce.Int64(), event.StartBlock)
+	}
+	return proof, nil
+}
@@ -0,0 +1,122 @@
+package replay
+
+import (
+	"sync"
+	"time"
+)
+
+// Status a snapshot of the replayer state.
+type Status struct {
+	// Ready true once the target contract has caught up with the source contract.
+	Ready bool `json:"ready"`
+	// Paused true if the proofs submission is paused.
+	Paused            bool         `json:"paused"`
+	SourceLatestBlock uint64       `json:"source_latest_block"`
+	TargetLatestBlock uint64       `json:"target_latest_block"`
+	LagBlocks         uint64       `json:"lag_blocks"`
+	LagSeconds        float64      `json:"lag_seconds"`
+	CurrentProof      *ProofStatus `json:"current_proof,omitempty"`
+	PendingTx         *TxStatus    `json:"pending_tx,omitempty"`
+	// GovernanceChanges the governance changes pausing the replay until they're acknowledged.
+	GovernanceChanges []GovernanceChange `json:"governance_changes,omitempty"`
+}
+
+// ProofStatus the proof currently being replayed.
+type ProofStatus struct {
+	Nonce          int64  `json:"nonce"`
+	StartBlock     uint64 `json:"start_block"`
+	EndBlock       uint64 `json:"end_block"`
+	DataCommitment string `json:"data_commitment"`
+	SourceTxHash   string `json:"source_tx_hash"`
+	// TrustedDataCommitment the data commitment generated by the trusted core endpoint, if verified.
+	TrustedDataCommitment string `json:"trusted_data_commitment,omitempty"`
+}
+
+// TxStatus a transaction submitted to the target chain and waiting to be included.
+type TxStatus struct {
+	Hash        string    `json:"hash"`
+	Nonce       uint64    `json:"nonce"`
+	GasPrice    string    `json:"gas_price"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// state the replayer state shared with the admin API.
+type state struct {
+	mu      sync.RWMutex
+	status  Status
+	resumed chan struct{}
+}
+
+func newState() *state {
+	return &state{resumed: make(chan struct{}, 1)}
+}
+
+func (s *state) snapshot() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status := s.status
+	if s.status.CurrentProof != nil {
+		proof := *s.status.CurrentProof
+		status.CurrentProof = &proof
+	}
+	if s.status.PendingTx != nil {
+		tx := *s.status.PendingTx
+		status.PendingTx = &tx
+	}
+	status.GovernanceChanges = append([]GovernanceChange(nil), s.status.GovernanceChanges...)
+	return status
+}
+
+func (s *state) update(fn func(status *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.status)
+}
+
+func (s *state) paused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status.Paused
+}
+
+func (s *state) setPaused(paused bool) {
+	s.mu.Lock()
+	wasPaused := s.status.Paused
+	s.status.Paused = paused
+	s.mu.Unlock()
+	if wasPaused && !paused {
+		// notify the follow loop without blocking if a notification is already pending
+		select {
+		case s.resumed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Status returns a snapshot of the replayer state.
+func (r *Replayer) Status() Status {
+	return r.state.snapshot()
+}
+
+// Ready returns true once the target contract has caught up with the source contract.
+func (r *Replayer) Ready() bool {
+	return r.state.snapshot().Ready
+}
+
+// Pause stops submitting proofs to the target contract. The source contract events are
+// still followed, and the missed proofs are replayed once resumed.
+func (r *Replayer) Pause() {
+	r.logger.Info("pausing the proofs submission")
+	r.state.setPaused(true)
+}
+
+// Resume resumes submitting proofs to the target contract. The replay stays paused while there are
+// unacknowledged governance changes.
+func (r *Replayer) Resume() {
+	if changes := len(r.state.snapshot().GovernanceChanges); changes != 0 {
+		r.logger.Info("not resuming the proofs submission, the governance changes need to be acknowledged first", "changes", changes)
+		return
+	}
+	r.logger.Info("resuming the proofs submission")
+	r.state.setPaused(false)
+}
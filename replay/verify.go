@@ -0,0 +1,335 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/celestiaorg/blobstream-ops/auditlog"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/tracing"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	blobstreamxwrapper "github.com/succinctlabs/blobstreamx/bindings"
+	"github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// verifyProof verifies the proof of the event against the trusted core endpoint before replaying it:
+// the target contract header at its latest block, which the proof builds on, must match the Celestia
+// header, and the event data commitment must match the one generated by the core endpoint.
+// All the replayed proofs go through this stage, which is skipped if the verification is disabled.
+func (r *Replayer) verifyProof(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, latestTargetContractBlock uint64) (err error) {
+	if !r.config.Verify {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "replay.verify_proof", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	if err := r.verifyTrustedHeader(ctx, latestTargetContractBlock); err != nil {
+		return err
+	}
+	if err := r.verifyDataCommitment(ctx, event); err != nil {
+		return err
+	}
+	r.publishEvent(eventstream.TypeVerified, nil)
+	return nil
+}
+
+// verifyPreparedProof verifies a proof building on a proof not submitted yet: its trusted header, taken from
+// the previous proof, should match the Celestia header, and its data commitment the one generated by the core
+// endpoint.
+func (r *Replayer) verifyPreparedProof(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, trustedHeader [32]byte) (err error) {
+	if !r.config.Verify {
+		return nil
+	}
+	ctx, span := tracing.Start(ctx, "replay.verify_prepared_proof", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	height := int64(event.StartBlock)
+	coreHeader, err := r.trpc.Header(ctx, &height)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	if !bytes.Equal(coreHeader.Header.Hash(), trustedHeader[:]) {
+		return r.fail(
+			metrics.FailureVerification,
+			fmt.Errorf(
+				"the previous proof target header %s doesn't match the Celestia header %s at height %d",
+				hex.EncodeToString(trustedHeader[:]),
+				hex.EncodeToString(coreHeader.Header.Hash()),
+				height,
+			),
+		)
+	}
+	return r.verifyDataCommitment(ctx, event)
+}
+
+// verifyTrustedHeader checks that the header hash stored in the target contract at the provided height
+// matches the hash of the Celestia header returned by the core endpoint.
+func (r *Replayer) verifyTrustedHeader(ctx context.Context, height uint64) (err error) {
+	ctx, span := tracing.Start(ctx, "replay.verify_trusted_header", tracing.AttributeStartBlock.Int64(int64(height)))
+	defer func() { tracing.End(span, err) }()
+
+	r.logger.Info("verifying the target contract trusted header", "height", height)
+	targetHeaderHash, err := r.targetBlobstreamX.BlockHeightToHeaderHash(&bind.CallOpts{Context: ctx}, height)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	coreHeight := int64(height)
+	coreHeader, err := r.trpc.Header(ctx, &coreHeight)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	coreHeaderHash := coreHeader.Header.Hash()
+	if bytes.Equal(coreHeaderHash, targetHeaderHash[:]) {
+		r.logger.Info("trusted header verified", "height", height)
+		return nil
+	}
+
+	r.logger.Error(
+		"trusted header mismatch!! quitting",
+		"height",
+		height,
+		"expected_header_hash",
+		hex.EncodeToString(coreHeaderHash),
+		"actual_header_hash",
+		hex.EncodeToString(targetHeaderHash[:]),
+	)
+	r.guard(ctx, "trusted header mismatch", func(ctx context.Context, trpc *http.HTTP) (bool, error) {
+		trustedHeader, err := trpc.Header(ctx, &coreHeight)
+		if err != nil {
+			return false, err
+		}
+		return !bytes.Equal(trustedHeader.Header.Hash(), targetHeaderHash[:]), nil
+	})
+	r.notify(ctx, notify.Alert{
+		Kind:     notify.KindHeaderMismatch,
+		Severity: notify.SeverityCritical,
+		Summary:  fmt.Sprintf("the target contract trusted header at height %d doesn't match the Celestia header, the replay stopped", height),
+		Details: map[string]string{
+			"target_contract":      r.config.TargetBlobstreamContractAddress,
+			"height":               fmt.Sprint(height),
+			"expected_header_hash": hex.EncodeToString(coreHeaderHash),
+			"actual_header_hash":   hex.EncodeToString(targetHeaderHash[:]),
+		},
+		DedupKey: fmt.Sprintf("%s-%s-%d", notify.KindHeaderMismatch, r.config.TargetBlobstreamContractAddress, height),
+	})
+	return r.fail(metrics.FailureVerification, fmt.Errorf("trusted header mismatch. height %d", height))
+}
+
+// verifyDataCommitment checks that the data commitment of the event matches the one generated
+// by the core endpoint for the same range.
+func (r *Replayer) verifyDataCommitment(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (err error) {
+	ctx, span := tracing.Start(ctx, "replay.verify_data_commitment", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	r.logger.Info("verifying data root tuple root", "proof_nonce_in_source_contract", event.ProofNonce, "start_block", event.StartBlock, "end_block", event.EndBlock)
+	coreDataCommitment, err := r.trpc.DataCommitment(ctx, event.StartBlock, event.EndBlock)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	r.state.update(func(status *Status) {
+		if status.CurrentProof != nil {
+			status.CurrentProof.TrustedDataCommitment = hex.EncodeToString(coreDataCommitment.DataCommitment.Bytes())
+		}
+	})
+	if bytes.Equal(coreDataCommitment.DataCommitment.Bytes(), event.DataCommitment[:]) {
+		r.logger.Info("data commitment verified")
+		return nil
+	}
+
+	r.logger.Error(
+		"data commitment mismatch!! quitting",
+		"proof_nonce_in_source_contract",
+		event.ProofNonce,
+		"start_block",
+		event.StartBlock,
+		"end_block",
+		event.EndBlock,
+		"expected_data_commitment",
+		hex.EncodeToString(coreDataCommitment.DataCommitment.Bytes()),
+		"actual_data_commitment",
+		hex.EncodeToString(event.DataCommitment[:]),
+	)
+	r.appendAuditEntry(ctx, auditlog.KindMismatch, "")
+	r.guard(ctx, "data commitment mismatch", func(ctx context.Context, trpc *http.HTTP) (bool, error) {
+		trustedDataCommitment, err := trpc.DataCommitment(ctx, event.StartBlock, event.EndBlock)
+		if err != nil {
+			return false, err
+		}
+		return !bytes.Equal(trustedDataCommitment.DataCommitment.Bytes(), event.DataCommitment[:]), nil
+	})
+	r.notify(ctx, notify.Alert{
+		Kind:     notify.KindMismatch,
+		Severity: notify.SeverityCritical,
+		Summary:  fmt.Sprintf("data commitment mismatch for the source contract proof nonce %d, the replay stopped", event.ProofNonce.Int64()),
+		Details: map[string]string{
+			"source_contract":          r.config.SourceBlobstreamContractAddress,
+			"proof_nonce":              event.ProofNonce.String(),
+			"start_block":              fmt.Sprint(event.StartBlock),
+			"end_block":                fmt.Sprint(event.EndBlock),
+			"expected_data_commitment": hex.EncodeToString(coreDataCommitment.DataCommitment.Bytes()),
+			"actual_data_commitment":   hex.EncodeToString(event.DataCommitment[:]),
+		},
+		DedupKey: fmt.Sprintf("%s-%s-%d", notify.KindMismatch, r.config.SourceBlobstreamContractAddress, event.ProofNonce.Int64()),
+	})
+	return r.fail(metrics.FailureVerification, fmt.Errorf("data commitment mistmatch. start height %d end height %d", event.StartBlock, event.EndBlock))
+}
+
+// verifyTargetState checks, once the proof of the event is replayed, that the target contract committed to
+// the same data commitment as the source contract, and stores the same header hash at the end block.
+// The target nonce the commitment was stored at is taken from the receipt of the replayed proof, or looked up
+// by the event range if the proof was committed by another transaction, as other relayers could have
+// advanced the target contract in the meantime.
+func (r *Replayer) verifyTargetState(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, receipt *coregethtypes.Receipt) (err error) {
+	ctx, span := tracing.Start(ctx, "replay.verify_target_state", proofAttributes(event)...)
+	defer func() { tracing.End(span, err) }()
+
+	stored := r.storedEvent(receipt, event)
+	if stored == nil {
+		stored, err = r.lookupStoredEvent(ctx, event)
+		if err != nil {
+			return r.fail(metrics.FailureRPC, err)
+		}
+	}
+	if stored == nil {
+		r.logger.Info(
+			"the target contract committed to the proof range using different proofs, skipping its state verification",
+			"start_block", event.StartBlock,
+			"end_block", event.EndBlock,
+		)
+		return nil
+	}
+
+	opts := &bind.CallOpts{Context: ctx}
+	targetNonce := stored.ProofNonce
+	targetDataCommitment, err := r.targetBlobstreamX.StateDataCommitments(opts, targetNonce)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	if targetDataCommitment != event.DataCommitment {
+		return r.targetStateMismatch(
+			ctx,
+			event,
+			fmt.Sprintf("the target contract data commitment for nonce %d doesn't match the source contract one", targetNonce.Int64()),
+			map[string]string{
+				"target_nonce":             targetNonce.String(),
+				"expected_data_commitment": hex.EncodeToString(event.DataCommitment[:]),
+				"actual_data_commitment":   hex.EncodeToString(targetDataCommitment[:]),
+			},
+		)
+	}
+
+	sourceHeaderHash, err := r.source.headerHash(ctx, event.EndBlock)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	targetHeaderHash, err := r.targetBlobstreamX.BlockHeightToHeaderHash(opts, event.EndBlock)
+	if err != nil {
+		return r.fail(metrics.FailureRPC, err)
+	}
+	if targetHeaderHash != sourceHeaderHash {
+		return r.targetStateMismatch(
+			ctx,
+			event,
+			fmt.Sprintf("the target contract header hash at height %d doesn't match the source contract one", event.EndBlock),
+			map[string]string{
+				"height":               fmt.Sprint(event.EndBlock),
+				"expected_header_hash": hex.EncodeToString(sourceHeaderHash[:]),
+				"actual_header_hash":   hex.EncodeToString(targetHeaderHash[:]),
+			},
+		)
+	}
+	r.logger.Debug("target contract state verified", "target_nonce", targetNonce.Int64(), "end_block", event.EndBlock)
+	return nil
+}
+
+// storedEvent returns the data commitment stored event emitted by the target contract for the range of the
+// source event in the receipt, or nil if the receipt is nil or doesn't contain it.
+func (r *Replayer) storedEvent(receipt *coregethtypes.Receipt, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) *blobstreamxwrapper.BlobstreamXDataCommitmentStored {
+	if receipt == nil {
+		return nil
+	}
+	targetAddress := ethcmn.HexToAddress(r.config.TargetBlobstreamContractAddress)
+	for _, log := range receipt.Logs {
+		if log.Address != targetAddress {
+			continue
+		}
+		stored, err := r.targetBlobstreamX.ParseDataCommitmentStored(*log)
+		if err != nil {
+			continue
+		}
+		if event == nil || (stored.StartBlock == event.StartBlock && stored.EndBlock == event.EndBlock) {
+			return stored
+		}
+	}
+	return nil
+}
+
+// lookupStoredEvent queries the target contract data commitment stored event for the range of the source
+// event, going back from the latest target chain block using the filter range. It returns nil if the target
+// contract didn't commit to this exact range.
+func (r *Replayer) lookupStoredEvent(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored) (*blobstreamxwrapper.BlobstreamXDataCommitmentStored, error) {
+	latestHeight, err := r.targetEVMClient.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filterRange := uint64(r.config.FilterRange)
+	if filterRange == 0 {
+		filterRange = latestHeight + 1
+	}
+	for end := latestHeight; ; end -= filterRange {
+		var start uint64
+		if end >= filterRange {
+			start = end - filterRange + 1
+		}
+		rangeEnd := end
+		events, err := r.targetBlobstreamX.FilterDataCommitmentStored(
+			&bind.FilterOpts{Context: ctx, Start: start, End: &rangeEnd},
+			[]uint64{event.StartBlock},
+			[]uint64{event.EndBlock},
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		var stored *blobstreamxwrapper.BlobstreamXDataCommitmentStored
+		for events.Next() {
+			// the latest one is kept in case the range was committed several times
+			stored = events.Event
+		}
+		if err := events.Error(); err != nil {
+			return nil, err
+		}
+		_ = events.Close()
+		if stored != nil {
+			return stored, nil
+		}
+		if start == 0 {
+			return nil, nil
+		}
+	}
+}
+
+// targetStateMismatch logs and alerts about a divergence between the target and source contracts states,
+// and returns the corresponding error.
+func (r *Replayer) targetStateMismatch(ctx context.Context, event *blobstreamxwrapper.BlobstreamXDataCommitmentStored, summary string, details map[string]string) error {
+	details["source_contract"] = r.config.SourceBlobstreamContractAddress
+	details["target_contract"] = r.config.TargetBlobstreamContractAddress
+	details["source_nonce"] = event.ProofNonce.String()
+	details["start_block"] = fmt.Sprint(event.StartBlock)
+	details["end_block"] = fmt.Sprint(event.EndBlock)
+	r.logger.Error("target contract state mismatch!! quitting", "summary", summary, "source_nonce", event.ProofNonce, "end_block", event.EndBlock)
+	r.notify(ctx, notify.Alert{
+		Kind:     notify.KindTargetStateMismatch,
+		Severity: notify.SeverityCritical,
+		Summary:  summary + ", the replay stopped",
+		Details:  details,
+		DedupKey: fmt.Sprintf("%s-%s-%d", notify.KindTargetStateMismatch, r.config.TargetBlobstreamContractAddress, event.EndBlock),
+	})
+	return r.fail(metrics.FailureVerification, fmt.Errorf("target contract state mismatch: %s", summary))
+}
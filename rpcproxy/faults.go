@@ -0,0 +1,176 @@
+package rpcproxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Action is the kind of fault injected when a rule fires.
+type Action string
+
+const (
+	// ActionError answers the request with a JSON-RPC error instead of forwarding it.
+	ActionError Action = "error"
+	// ActionDelay forwards the request after waiting for the rule's delay.
+	ActionDelay Action = "delay"
+	// ActionDrop closes the connection without answering. On websocket connections,
+	// this drops all the subscriptions of the client.
+	ActionDrop Action = "drop"
+	// ActionReorder shuffles the logs returned by an eth_getLogs request.
+	ActionReorder Action = "reorder"
+	// ActionTruncate only returns the first part of the logs returned by an eth_getLogs request.
+	ActionTruncate Action = "truncate"
+	// ActionStale answers the request with the previous result that was returned for the same request.
+	ActionStale Action = "stale"
+)
+
+// Duration is a time.Duration that is (un)marshalled from a string, e.g. "1.5s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(bz []byte) error {
+	var s string
+	if err := json.Unmarshal(bz, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Rule describes a fault to inject into the requests matching it.
+type Rule struct {
+	// Method the JSON-RPC method the rule applies to. Empty or "*" matches all the methods.
+	// Subscription notifications sent through websocket connections use the "eth_subscription" method.
+	Method string `json:"method"`
+	// Probability the probability, between 0 and 1, of the rule firing on a matching request.
+	Probability float64 `json:"probability"`
+	// Action the fault to inject.
+	Action Action `json:"action"`
+	// Delay the time to wait before forwarding the request. Used by the delay action.
+	Delay Duration `json:"delay,omitempty"`
+	// ErrorCode the JSON-RPC error code returned by the error action.
+	ErrorCode int `json:"error_code,omitempty"`
+	// ErrorMessage the JSON-RPC error message returned by the error action.
+	ErrorMessage string `json:"error_message,omitempty"`
+	// Keep the fraction, between 0 and 1, of the logs kept by the truncate action. It must be set for
+	// the truncate action, so that a rule missing it doesn't silently empty all the logs.
+	Keep *float64 `json:"keep,omitempty"`
+}
+
+func (r Rule) matches(method string) bool {
+	return r.Method == "" || r.Method == "*" || r.Method == method
+}
+
+func (r Rule) ValidateBasics() error {
+	if r.Probability < 0 || r.Probability > 1 {
+		return fmt.Errorf("rule %s on method %q: probability should be between 0 and 1", r.Action, r.Method)
+	}
+	switch r.Action {
+	case ActionError:
+		if r.ErrorMessage == "" {
+			return fmt.Errorf("rule %s on method %q: the error message cannot be empty", r.Action, r.Method)
+		}
+	case ActionDelay:
+		if r.Delay <= 0 {
+			return fmt.Errorf("rule %s on method %q: the delay should be positive", r.Action, r.Method)
+		}
+	case ActionTruncate:
+		if r.Keep == nil {
+			return fmt.Errorf("rule %s on method %q: the kept fraction should be set", r.Action, r.Method)
+		}
+		if *r.Keep < 0 || *r.Keep >= 1 {
+			return fmt.Errorf("rule %s on method %q: the kept fraction should be between 0 and 1", r.Action, r.Method)
+		}
+	case ActionDrop, ActionReorder, ActionStale:
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	return nil
+}
+
+// Faults the set of rules applied by the proxy.
+// The rules are evaluated in order, and all the rules that fire are applied.
+type Faults struct {
+	Rules []Rule `json:"rules"`
+}
+
+func (f Faults) ValidateBasics() error {
+	for _, rule := range f.Rules {
+		if err := rule.ValidateBasics(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFaults reads the faults from a JSON file.
+func LoadFaults(path string) (Faults, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return Faults{}, err
+	}
+	var faults Faults
+	if err := json.Unmarshal(bz, &faults); err != nil {
+		return Faults{}, fmt.Errorf("failed to decode faults file %s: %w", path, err)
+	}
+	if err := faults.ValidateBasics(); err != nil {
+		return Faults{}, err
+	}
+	return faults, nil
+}
+
+// Presets are named sets of rules reproducing the provider failures that the replayer
+// should withstand.
+var Presets = map[string][]Rule{
+	"nonce-too-low": {
+		{Method: "eth_sendRawTransaction", Probability: 0.3, Action: ActionError, ErrorCode: -32000, ErrorMessage: "nonce too low"},
+	},
+	"replacement-underpriced": {
+		{Method: "eth_sendRawTransaction", Probability: 0.3, Action: ActionError, ErrorCode: -32000, ErrorMessage: "replacement transaction underpriced"},
+	},
+	"stale-reads": {
+		{Method: "eth_call", Probability: 0.3, Action: ActionStale},
+		{Method: "eth_blockNumber", Probability: 0.3, Action: ActionStale},
+	},
+	"dropped-subscriptions": {
+		{Method: "eth_subscription", Probability: 0.2, Action: ActionDrop},
+	},
+	"flaky-logs": {
+		{Method: "eth_getLogs", Probability: 0.3, Action: ActionReorder},
+		{Method: "eth_getLogs", Probability: 0.2, Action: ActionTruncate, Keep: fraction(0.5)},
+	},
+	"slow": {
+		{Probability: 0.2, Action: ActionDelay, Delay: Duration(2 * time.Second)},
+	},
+	"unavailable": {
+		{Probability: 0.05, Action: ActionError, ErrorCode: -32603, ErrorMessage: "upstream unavailable"},
+		{Probability: 0.02, Action: ActionDrop},
+	},
+}
+
+// fraction returns a pointer to the provided fraction, to set the kept fraction of the presets.
+func fraction(f float64) *float64 {
+	return &f
+}
+
+// PresetRules returns the rules of the provided presets names.
+func PresetRules(names ...string) ([]Rule, error) {
+	var rules []Rule
+	for _, name := range names {
+		preset, exists := Presets[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown faults preset %q", name)
+		}
+		rules = append(rules, preset...)
+	}
+	return rules, nil
+}
@@ -0,0 +1,439 @@
+package rpcproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// subscriptionMethod the method used by the notifications sent over websocket subscriptions.
+const subscriptionMethod = "eth_subscription"
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Error   *jsonrpcError   `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+func (msg *jsonrpcMessage) isNotification() bool {
+	return msg.ID == nil && msg.Method != ""
+}
+
+func (msg *jsonrpcMessage) key() string {
+	return msg.Method + string(msg.Params)
+}
+
+// verdict the outcome of evaluating the faults rules against a request.
+type verdict struct {
+	// drop set if the connection should be closed without answering.
+	drop bool
+	// response set if the request should be answered without forwarding it.
+	response *jsonrpcMessage
+	// post the rules to apply on the response returned by the upstream.
+	post []Rule
+}
+
+// resultHistory the two last distinct results returned for a request.
+type resultHistory struct {
+	previous json.RawMessage
+	latest   json.RawMessage
+}
+
+// Proxy a JSON-RPC proxy that forwards requests to an EVM endpoint and injects
+// the configured faults in the traffic.
+// It supports both HTTP and websocket connections.
+type Proxy struct {
+	logger     tmlog.Logger
+	upstream   string
+	upstreamWS string
+	faults     Faults
+	client     *http.Client
+	upgrader   websocket.Upgrader
+
+	mu      sync.Mutex
+	rand    *rand.Rand
+	history map[string]*resultHistory
+}
+
+// New creates a new proxy forwarding HTTP requests to the upstream and websocket connections
+// to the upstream websocket endpoint. If the upstream websocket endpoint is empty, websocket
+// connections are refused.
+// The seed makes the injected faults deterministic for a given sequence of requests.
+func New(logger tmlog.Logger, upstream string, upstreamWS string, faults Faults, seed int64) *Proxy {
+	return &Proxy{
+		logger:     logger,
+		upstream:   upstream,
+		upstreamWS: upstreamWS,
+		faults:     faults,
+		client:     &http.Client{Timeout: time.Minute},
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+		rand:    rand.New(rand.NewSource(seed)), //nolint:gosec
+		history: make(map[string]*resultHistory),
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		p.serveWebsocket(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// batches are forwarded untouched
+	if len(bytes.TrimSpace(body)) > 0 && bytes.TrimSpace(body)[0] == '[' {
+		response, status, err := p.forward(r.Context(), body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeResponse(w, status, response)
+		return
+	}
+
+	var request jsonrpcMessage
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	v := p.inspect(r.Context(), &request)
+	if v.drop {
+		p.logger.Debug("dropping connection", "method", request.Method)
+		dropConnection(w)
+		return
+	}
+	if v.response != nil {
+		bz, err := json.Marshal(v.response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeResponse(w, http.StatusOK, bz)
+		return
+	}
+
+	bz, status, err := p.forward(r.Context(), body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	var response jsonrpcMessage
+	if err := json.Unmarshal(bz, &response); err != nil {
+		// not a JSON-RPC response, forwarding it as is
+		writeResponse(w, status, bz)
+		return
+	}
+	if p.alter(&request, &response, v.post) {
+		bz, err = json.Marshal(response)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeResponse(w, status, bz)
+}
+
+// forward sends the raw request to the upstream and returns its raw response.
+func (p *Proxy) forward(ctx context.Context, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.upstream, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	bz, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bz, resp.StatusCode, nil
+}
+
+// inspect evaluates the faults rules against the message, and applies the delays.
+func (p *Proxy) inspect(ctx context.Context, msg *jsonrpcMessage) verdict {
+	method := msg.Method
+	if msg.isNotification() {
+		method = subscriptionMethod
+	}
+
+	var v verdict
+	for _, rule := range p.fired(method) {
+		switch rule.Action {
+		case ActionDelay:
+			p.logger.Debug("delaying message", "method", method, "delay", time.Duration(rule.Delay).String())
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(rule.Delay)):
+			}
+		case ActionDrop:
+			v.drop = true
+			return v
+		case ActionError:
+			if msg.isNotification() {
+				continue
+			}
+			p.logger.Debug("injecting error", "method", method, "error", rule.ErrorMessage)
+			v.response = &jsonrpcMessage{
+				Version: "2.0",
+				ID:      msg.ID,
+				Error:   &jsonrpcError{Code: rule.ErrorCode, Message: rule.ErrorMessage},
+			}
+			return v
+		case ActionStale:
+			if msg.isNotification() {
+				continue
+			}
+			if stale := p.staleResult(msg.key()); stale != nil {
+				p.logger.Debug("serving stale result", "method", method)
+				v.response = &jsonrpcMessage{Version: "2.0", ID: msg.ID, Result: stale}
+				return v
+			}
+		case ActionReorder, ActionTruncate:
+			v.post = append(v.post, rule)
+		}
+	}
+	return v
+}
+
+// alter records the result of the response, then applies the post rules to it.
+// Returns true if the response was changed.
+func (p *Proxy) alter(request *jsonrpcMessage, response *jsonrpcMessage, post []Rule) bool {
+	if response.Result == nil {
+		return false
+	}
+	p.record(request.key(), response.Result)
+	if len(post) == 0 {
+		return false
+	}
+
+	var logs []json.RawMessage
+	if err := json.Unmarshal(response.Result, &logs); err != nil || len(logs) == 0 {
+		return false
+	}
+	for _, rule := range post {
+		switch rule.Action {
+		case ActionReorder:
+			p.logger.Debug("reordering logs", "method", request.Method, "count", len(logs))
+			p.mu.Lock()
+			p.rand.Shuffle(len(logs), func(i, j int) { logs[i], logs[j] = logs[j], logs[i] })
+			p.mu.Unlock()
+		case ActionTruncate:
+			kept := int(math.Ceil(float64(len(logs)) * *rule.Keep))
+			p.logger.Debug("truncating logs", "method", request.Method, "count", len(logs), "kept", kept)
+			logs = logs[:kept]
+		}
+	}
+	bz, err := json.Marshal(logs)
+	if err != nil {
+		return false
+	}
+	response.Result = bz
+	return true
+}
+
+// fired returns the rules matching the method that fire for this message.
+func (p *Proxy) fired(method string) []Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var rules []Rule
+	for _, rule := range p.faults.Rules {
+		if rule.matches(method) && p.rand.Float64() < rule.Probability {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (p *Proxy) record(key string, result json.RawMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, exists := p.history[key]
+	if !exists {
+		p.history[key] = &resultHistory{latest: result}
+		return
+	}
+	if !bytes.Equal(h.latest, result) {
+		h.previous = h.latest
+		h.latest = result
+	}
+}
+
+func (p *Proxy) staleResult(key string) json.RawMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	h, exists := p.history[key]
+	if !exists {
+		return nil
+	}
+	return h.previous
+}
+
+// serveWebsocket proxies a websocket connection to the upstream websocket endpoint.
+func (p *Proxy) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	if p.upstreamWS == "" {
+		http.Error(w, "websocket upstream not configured", http.StatusBadRequest)
+		return
+	}
+	upstreamConn, _, err := websocket.DefaultDialer.DialContext(r.Context(), p.upstreamWS, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to dial the websocket upstream: %s", err.Error()), http.StatusBadGateway)
+		return
+	}
+	defer upstreamConn.Close()
+	clientConn, err := p.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		p.logger.Error("failed to upgrade connection", "err", err.Error())
+		return
+	}
+	defer clientConn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	client := &lockedConn{Conn: clientConn}
+
+	// the methods of the in-flight requests by ID
+	var pendingMu sync.Mutex
+	pending := make(map[string]pendingRequest)
+
+	go func() {
+		defer cancel()
+		for {
+			_, bz, err := upstreamConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg jsonrpcMessage
+			if err := json.Unmarshal(bz, &msg); err != nil {
+				if err := client.write(bz); err != nil {
+					return
+				}
+				continue
+			}
+			if msg.isNotification() {
+				v := p.inspect(ctx, &msg)
+				if v.drop {
+					p.logger.Debug("dropping websocket connection")
+					return
+				}
+			} else {
+				pendingMu.Lock()
+				req, exists := pending[string(msg.ID)]
+				delete(pending, string(msg.ID))
+				pendingMu.Unlock()
+				if exists && p.alter(req.request, &msg, req.post) {
+					if bz, err = json.Marshal(msg); err != nil {
+						return
+					}
+				}
+			}
+			if err := client.write(bz); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		for {
+			_, bz, err := clientConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg jsonrpcMessage
+			if err := json.Unmarshal(bz, &msg); err != nil {
+				if err := upstreamConn.WriteMessage(websocket.TextMessage, bz); err != nil {
+					return
+				}
+				continue
+			}
+			v := p.inspect(ctx, &msg)
+			if v.drop {
+				p.logger.Debug("dropping websocket connection", "method", msg.Method)
+				return
+			}
+			if v.response != nil {
+				response, err := json.Marshal(v.response)
+				if err != nil {
+					return
+				}
+				if err := client.write(response); err != nil {
+					return
+				}
+				continue
+			}
+			pendingMu.Lock()
+			pending[string(msg.ID)] = pendingRequest{request: &msg, post: v.post}
+			pendingMu.Unlock()
+			if err := upstreamConn.WriteMessage(websocket.TextMessage, bz); err != nil {
+				return
+			}
+		}
+	}()
+
+	<-ctx.Done()
+}
+
+type pendingRequest struct {
+	request *jsonrpcMessage
+	post    []Rule
+}
+
+// lockedConn a websocket connection that supports concurrent writers.
+type lockedConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *lockedConn) write(bz []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.WriteMessage(websocket.TextMessage, bz)
+}
+
+func writeResponse(w http.ResponseWriter, status int, bz []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(bz)
+}
+
+// dropConnection closes the underlying connection without writing a response.
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		// the connection cannot be taken over, aborting the handler instead
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	_ = conn.Close()
+}
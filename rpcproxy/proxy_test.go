@@ -0,0 +1,181 @@
+package rpcproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+const genesisHeight = simchain.GenesisHeight
+
+// serveProxy serves a proxy injecting the rules in front of the chain, and returns its address.
+func serveProxy(t *testing.T, c *simchain.Chain, rules []Rule) string {
+	t.Helper()
+	faults := Faults{Rules: rules}
+	require.NoError(t, faults.ValidateBasics())
+	server := httptest.NewServer(New(tmlog.NewNopLogger(), "http://"+c.Endpoint, "ws://"+c.Endpoint, faults, 1))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// TestReplayThroughFaults replays the proofs of a source chain to a target chain through proxies injecting
+// faults in both chains traffic: the source chain is read through websocket, as when following the new proofs,
+// and the transactions are sent to the target chain over HTTP.
+// The faults the replayer withstands let it catch up then follow the source contract, and the others fail
+// the replay without committing a wrong state to the target contract.
+func TestReplayThroughFaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		sourceRules []Rule
+		targetRules []Rule
+		// wantCatchupErr the error the catchup fails with. Empty if it succeeds.
+		wantCatchupErr string
+		// wantTargetBlock the target contract latest block after the catchup.
+		wantTargetBlock uint64
+		// wantFollowErr the error the follower fails with once a new proof is emitted. Empty if it replays it.
+		wantFollowErr string
+	}{
+		{
+			name:            "no faults",
+			wantTargetBlock: genesisHeight + 20,
+		},
+		{
+			name:            "delayed requests and notifications",
+			sourceRules:     []Rule{{Probability: 1, Action: ActionDelay, Delay: Duration(20 * time.Millisecond)}},
+			targetRules:     []Rule{{Probability: 0.5, Action: ActionDelay, Delay: Duration(20 * time.Millisecond)}},
+			wantTargetBlock: genesisHeight + 20,
+		},
+		{
+			name: "error on the transaction submission",
+			targetRules: []Rule{
+				{Method: "eth_sendRawTransaction", Probability: 1, Action: ActionError, ErrorCode: -32000, ErrorMessage: "nonce too low"},
+			},
+			wantCatchupErr:  "nonce too low",
+			wantTargetBlock: genesisHeight,
+		},
+		{
+			name:            "error on the source logs",
+			sourceRules:     []Rule{{Method: "eth_getLogs", Probability: 1, Action: ActionError, ErrorCode: -32603, ErrorMessage: "upstream unavailable"}},
+			wantCatchupErr:  "upstream unavailable",
+			wantTargetBlock: genesisHeight,
+		},
+		{
+			name:            "dropped transaction submission",
+			targetRules:     []Rule{{Method: "eth_sendRawTransaction", Probability: 1, Action: ActionDrop}},
+			wantCatchupErr:  "EOF",
+			wantTargetBlock: genesisHeight,
+		},
+		{
+			name:            "dropped subscription",
+			sourceRules:     []Rule{{Method: "eth_subscription", Probability: 1, Action: ActionDrop}},
+			wantTargetBlock: genesisHeight + 20,
+			wantFollowErr:   "the source contract events subscription failed",
+		},
+		{
+			name:            "truncated source logs",
+			sourceRules:     []Rule{{Method: "eth_getLogs", Probability: 1, Action: ActionTruncate, Keep: fraction(0.5)}},
+			wantCatchupErr:  fmt.Sprintf("couldn't find a proof that starts at height %d", genesisHeight+10),
+			wantTargetBlock: genesisHeight + 10,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+			defer cancel()
+
+			key, err := crypto.GenerateKey()
+			require.NoError(t, err)
+			source := simchain.New(ctx, t, key)
+			target := simchain.New(ctx, t, key)
+			target.Mine(t, 50*time.Millisecond)
+			source.CommitHeaderRange(ctx, t, key, genesisHeight, genesisHeight+10)
+			source.CommitHeaderRange(ctx, t, key, genesisHeight+10, genesisHeight+20)
+
+			sourceClient, err := ethclient.DialContext(ctx, "ws://"+serveProxy(t, source, test.sourceRules))
+			require.NoError(t, err)
+			defer sourceClient.Close()
+			targetClient, err := ethclient.DialContext(ctx, "http://"+serveProxy(t, target, test.targetRules))
+			require.NoError(t, err)
+			defer targetClient.Close()
+
+			logger := tmlog.NewNopLogger()
+			notifier, err := notify.New(logger, nil, 0)
+			require.NoError(t, err)
+			replayer, err := replay.NewReplayer(
+				logger,
+				replay.Config{
+					SourceBlobstreamContractAddress: source.Manifest.BlobstreamX.Hex(),
+					TargetBlobstreamContractAddress: target.Manifest.BlobstreamX.Hex(),
+					SourceChainGatewayAddress:       source.Manifest.Gateway.Hex(),
+					TargetChainGatewayAddress:       target.Manifest.Gateway.Hex(),
+					Signer:                          signer.NewKey(key),
+					FunctionIDs: map[[32]byte][32]byte{
+						source.Manifest.HeaderRangeFunctionID: target.Manifest.HeaderRangeFunctionID,
+						source.Manifest.NextHeaderFunctionID:  target.Manifest.NextHeaderFunctionID,
+					},
+					FilterRange: 5000,
+				},
+				nil,
+				sourceClient,
+				targetClient,
+				metrics.NewReplay(prometheus.NewRegistry()),
+				notifier,
+				eventstream.New(logger),
+				nil,
+			)
+			require.NoError(t, err)
+
+			err = replayer.Catchup(ctx)
+			assert.Equal(t, test.wantTargetBlock, target.LatestBlock(ctx, t))
+			if test.wantCatchupErr != "" {
+				require.ErrorContains(t, err, test.wantCatchupErr)
+				return
+			}
+			require.NoError(t, err)
+
+			followCtx, stopFollow := context.WithCancel(ctx)
+			defer stopFollow()
+			followed := make(chan error, 1)
+			go func() { followed <- replayer.Follow(followCtx) }()
+			// the subscription is set up before the new proof is emitted
+			time.Sleep(500 * time.Millisecond)
+			source.CommitHeaderRange(ctx, t, key, genesisHeight+20, genesisHeight+30)
+
+			if test.wantFollowErr != "" {
+				select {
+				case err := <-followed:
+					require.ErrorContains(t, err, test.wantFollowErr)
+				case <-ctx.Done():
+					require.FailNow(t, "the follower didn't fail")
+				}
+				assert.Equal(t, uint64(genesisHeight+20), target.LatestBlock(ctx, t))
+				return
+			}
+			require.Eventually(t, func() bool {
+				return target.LatestBlock(ctx, t) == genesisHeight+30
+			}, time.Minute, 100*time.Millisecond)
+			// the follower can be stopped while reading the state following the replayed proof
+			stopFollow()
+			if err := <-followed; err != nil {
+				require.ErrorIs(t, err, context.Canceled)
+			}
+		})
+	}
+}
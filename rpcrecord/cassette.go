@@ -0,0 +1,282 @@
+package rpcrecord
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// interaction a line of a cassette. It contains either a request along with its response,
+// or a subscription notification.
+type interaction struct {
+	Request      json.RawMessage `json:"request,omitempty"`
+	Response     json.RawMessage `json:"response,omitempty"`
+	Notification json.RawMessage `json:"notification,omitempty"`
+	// Subscription identifies the subscription created by the request, or the one the notification belongs to.
+	Subscription string `json:"subscription,omitempty"`
+	// Offset the time elapsed since the recording started, in nanoseconds.
+	Offset time.Duration `json:"offset"`
+}
+
+// cassettePath returns the path of the cassette of the endpoint with the provided name.
+func cassettePath(dir string, name string) string {
+	return filepath.Join(dir, name+".jsonl")
+}
+
+// cassetteWriter appends interactions to a cassette file.
+type cassetteWriter struct {
+	mu    sync.Mutex
+	file  *os.File
+	start time.Time
+}
+
+func newCassetteWriter(dir string, name string) (*cassetteWriter, error) {
+	file, err := os.OpenFile(cassettePath(dir, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &cassetteWriter{file: file, start: time.Now()}, nil
+}
+
+// write appends the interaction, setting its offset to the time elapsed since the writer was created.
+func (w *cassetteWriter) write(i interaction) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i.Offset = time.Since(w.start)
+	bz, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	_, err = w.file.Write(append(bz, '\n'))
+	return err
+}
+
+func (w *cassetteWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.file.Close()
+}
+
+// recordedCall a recorded request along with its response and the notifications
+// of the subscription it created.
+type recordedCall struct {
+	request       json.RawMessage
+	response      json.RawMessage
+	offset        time.Duration
+	notifications []recordedNotification
+}
+
+// recordedNotification a subscription notification along with the time it was received
+// after the response creating the subscription.
+type recordedNotification struct {
+	message json.RawMessage
+	delay   time.Duration
+}
+
+// cassette a loaded cassette serving the recorded responses.
+// The calls with the same request, regardless of their IDs, are served in the
+// order they were recorded. Once all of them are served, the last one is served again.
+type cassette struct {
+	name string
+
+	mu     sync.Mutex
+	calls  map[string][]*recordedCall
+	served map[string]int
+}
+
+func loadCassette(dir string, name string) (*cassette, error) {
+	file, err := os.Open(cassettePath(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c := &cassette{
+		name:   name,
+		calls:  make(map[string][]*recordedCall),
+		served: make(map[string]int),
+	}
+	var last *recordedCall
+	subscriptions := make(map[string]*recordedCall)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 256*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var i interaction
+		if err := json.Unmarshal(scanner.Bytes(), &i); err != nil {
+			return nil, fmt.Errorf("cassette %s line %d: %w", name, line, err)
+		}
+		if i.Notification != nil {
+			// the notifications recorded without their subscription follow the request creating it
+			call, exists := subscriptions[i.Subscription]
+			if !exists {
+				call = last
+			}
+			if call != nil {
+				call.notifications = append(call.notifications, recordedNotification{
+					message: i.Notification,
+					delay:   max(i.Offset-call.offset, 0),
+				})
+			}
+			continue
+		}
+		key, err := requestKey(i.Request)
+		if err != nil {
+			return nil, fmt.Errorf("cassette %s line %d: %w", name, line, err)
+		}
+		last = &recordedCall{request: i.Request, response: i.Response, offset: i.Offset}
+		c.calls[key] = append(c.calls[key], last)
+		if i.Subscription != "" {
+			subscriptions[i.Subscription] = last
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// next returns the recorded response to the request, with its IDs set to the ones of the request,
+// and the notifications of the subscription it created.
+func (c *cassette) next(request json.RawMessage) (json.RawMessage, []recordedNotification, error) {
+	key, err := requestKey(request)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	calls, exists := c.calls[key]
+	if !exists {
+		c.mu.Unlock()
+		return nil, nil, fmt.Errorf("cassette %s: no recorded interaction for request %s", c.name, string(request))
+	}
+	index := c.served[key]
+	var notifications []recordedNotification
+	if index < len(calls) {
+		c.served[key] = index + 1
+		notifications = calls[index].notifications
+	} else {
+		index = len(calls) - 1
+	}
+	call := calls[index]
+	c.mu.Unlock()
+
+	response, err := rewriteIDs(call.request, call.response, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, notifications, nil
+}
+
+// playNotifications writes the notifications in the order they were recorded, each at its recorded delay
+// from the time it's called. It stops once the done channel is closed or a write fails.
+func playNotifications(notifications []recordedNotification, write func(json.RawMessage) error, done <-chan struct{}) {
+	start := time.Now()
+	for _, notification := range notifications {
+		timer := time.NewTimer(time.Until(start.Add(notification.delay)))
+		select {
+		case <-done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := write(notification.message); err != nil {
+			return
+		}
+	}
+}
+
+// requestKey returns the request, or batch of requests, without their IDs.
+func requestKey(request json.RawMessage) (string, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(request, &decoded); err != nil {
+		return "", err
+	}
+	switch value := decoded.(type) {
+	case map[string]interface{}:
+		delete(value, "id")
+	case []interface{}:
+		for _, elem := range value {
+			if obj, ok := elem.(map[string]interface{}); ok {
+				delete(obj, "id")
+			}
+		}
+	}
+	// maps are marshalled with sorted keys, which makes the key canonical
+	bz, err := json.Marshal(decoded)
+	if err != nil {
+		return "", err
+	}
+	return string(bz), nil
+}
+
+// rewriteIDs replaces the IDs of the recorded response with the ones of the new request.
+// The IDs of a batch are matched using the position of the requests in the batch.
+func rewriteIDs(recordedRequest json.RawMessage, recordedResponse json.RawMessage, request json.RawMessage) (json.RawMessage, error) {
+	recordedIDs, err := messageIDs(recordedRequest)
+	if err != nil {
+		return nil, err
+	}
+	newIDs, err := messageIDs(request)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]json.RawMessage, len(recordedIDs))
+	for i := range recordedIDs {
+		if i < len(newIDs) {
+			ids[string(recordedIDs[i])] = newIDs[i]
+		}
+	}
+
+	replace := func(msg map[string]json.RawMessage) {
+		if id, exists := ids[string(msg["id"])]; exists {
+			msg["id"] = id
+		}
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(recordedResponse), []byte("[")) {
+		var batch []map[string]json.RawMessage
+		if err := json.Unmarshal(recordedResponse, &batch); err != nil {
+			return nil, err
+		}
+		for _, msg := range batch {
+			replace(msg)
+		}
+		return json.Marshal(batch)
+	}
+	var msg map[string]json.RawMessage
+	if err := json.Unmarshal(recordedResponse, &msg); err != nil {
+		return nil, err
+	}
+	replace(msg)
+	return json.Marshal(msg)
+}
+
+// messageIDs returns the IDs of a message, or batch of messages, in order.
+func messageIDs(raw json.RawMessage) ([]json.RawMessage, error) {
+	var msgs []struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		if err := json.Unmarshal(raw, &msgs); err != nil {
+			return nil, err
+		}
+	} else {
+		msgs = make([]struct {
+			ID json.RawMessage `json:"id"`
+		}, 1)
+		if err := json.Unmarshal(raw, &msgs[0]); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]json.RawMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		ids = append(ids, msg.ID)
+	}
+	return ids, nil
+}
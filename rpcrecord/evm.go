@@ -0,0 +1,334 @@
+package rpcrecord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ipcServer serves the JSON-RPC messages of the clients connected to a unix socket.
+// Going through a stream instead of an HTTP transport allows recording and serving
+// the subscriptions notifications.
+type ipcServer struct {
+	dir      string
+	listener net.Listener
+
+	mu    sync.Mutex
+	conns []*ipcConn
+}
+
+// ipcConn a client connection to the IPC server.
+type ipcConn struct {
+	conn net.Conn
+	// done closed once the connection is closed.
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu sync.Mutex
+}
+
+// dialIPC creates an RPC client whose messages are handled by the provided handler.
+// The messages of a connection are handled sequentially, in the order they are received.
+func dialIPC(ctx context.Context, handle func(c *ipcConn, request json.RawMessage)) (*rpc.Client, *ipcServer, error) {
+	dir, err := os.MkdirTemp("", "rpcrecord")
+	if err != nil {
+		return nil, nil, err
+	}
+	path := filepath.Join(dir, "rpc.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	server := &ipcServer{dir: dir, listener: listener}
+	go server.serve(handle)
+
+	client, err := rpc.DialIPC(ctx, path)
+	if err != nil {
+		server.close()
+		return nil, nil, err
+	}
+	return client, server, nil
+}
+
+func (s *ipcServer) serve(handle func(c *ipcConn, request json.RawMessage)) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &ipcConn{conn: conn, done: make(chan struct{})}
+		s.mu.Lock()
+		s.conns = append(s.conns, c)
+		s.mu.Unlock()
+
+		go func() {
+			decoder := json.NewDecoder(conn)
+			for {
+				var request json.RawMessage
+				if err := decoder.Decode(&request); err != nil {
+					c.close()
+					return
+				}
+				handle(c, request)
+			}
+		}()
+	}
+}
+
+func (s *ipcServer) close() {
+	_ = s.listener.Close()
+	s.mu.Lock()
+	for _, c := range s.conns {
+		c.close()
+	}
+	s.mu.Unlock()
+	_ = os.RemoveAll(s.dir)
+}
+
+func (c *ipcConn) write(msg json.RawMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(append(bytes.TrimSpace(msg), '\n'))
+	return err
+}
+
+func (c *ipcConn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.Close()
+	})
+}
+
+type messageError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type message struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Error   *messageError   `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+// recordingHandler forwards the messages to the upstream endpoint and records
+// the exchanges along with the subscriptions notifications.
+type recordingHandler struct {
+	upstream *rpc.Client
+	writer   *cassetteWriter
+
+	mu            sync.Mutex
+	subscriptions map[string]*rpc.ClientSubscription
+	nextID        uint64
+}
+
+func newRecordingHandler(upstream *rpc.Client, writer *cassetteWriter) *recordingHandler {
+	return &recordingHandler{
+		upstream:      upstream,
+		writer:        writer,
+		subscriptions: make(map[string]*rpc.ClientSubscription),
+	}
+}
+
+func (h *recordingHandler) handle(c *ipcConn, request json.RawMessage) {
+	// the requests are forwarded concurrently, as they can block for a long time
+	go func() {
+		var (
+			response     json.RawMessage
+			subscription string
+			onSent       func()
+			err          error
+		)
+		if bytes.HasPrefix(bytes.TrimSpace(request), []byte("[")) {
+			response, err = h.forwardBatch(request)
+		} else {
+			response, subscription, onSent, err = h.forward(c, request)
+		}
+		if err != nil {
+			c.close()
+			return
+		}
+		// the exchange is recorded before it's sent, so that it's recorded before
+		// any notification it triggers.
+		if err := h.writer.write(interaction{Request: request, Response: response, Subscription: subscription}); err != nil {
+			c.close()
+			return
+		}
+		if err := c.write(response); err != nil {
+			return
+		}
+		if onSent != nil {
+			onSent()
+		}
+	}()
+}
+
+// forward sends a single request to the upstream and returns the response. If the request
+// creates a subscription, its ID is returned along with a function starting to forward its notifications.
+func (h *recordingHandler) forward(c *ipcConn, request json.RawMessage) (json.RawMessage, string, func(), error) {
+	var msg message
+	if err := json.Unmarshal(request, &msg); err != nil {
+		return nil, "", nil, err
+	}
+	args, err := toArgs(msg.Params)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	response := message{Version: "2.0", ID: msg.ID}
+
+	switch msg.Method {
+	case "eth_subscribe":
+		notifications := make(chan json.RawMessage)
+		sub, err := h.upstream.EthSubscribe(context.Background(), notifications, args...)
+		if err != nil {
+			response.Error = toMessageError(err)
+			bz, err := json.Marshal(response)
+			return bz, "", nil, err
+		}
+		h.mu.Lock()
+		h.nextID++
+		id := fmt.Sprintf("0x%x", h.nextID)
+		h.subscriptions[id] = sub
+		h.mu.Unlock()
+		response.Result, err = json.Marshal(id)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bz, err := json.Marshal(response)
+		return bz, id, func() { go h.forwardNotifications(c, id, sub, notifications) }, err
+	case "eth_unsubscribe":
+		var ids []string
+		if err := json.Unmarshal(msg.Params, &ids); err != nil || len(ids) != 1 {
+			response.Error = &messageError{Code: -32602, Message: "invalid subscription ID"}
+			bz, err := json.Marshal(response)
+			return bz, "", nil, err
+		}
+		h.mu.Lock()
+		sub, exists := h.subscriptions[ids[0]]
+		delete(h.subscriptions, ids[0])
+		h.mu.Unlock()
+		if exists {
+			sub.Unsubscribe()
+		}
+		response.Result, err = json.Marshal(exists)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		bz, err := json.Marshal(response)
+		return bz, "", nil, err
+	default:
+		var result json.RawMessage
+		if err := h.upstream.CallContext(context.Background(), &result, msg.Method, args...); err != nil {
+			response.Error = toMessageError(err)
+		} else {
+			response.Result = result
+		}
+		bz, err := json.Marshal(response)
+		return bz, "", nil, err
+	}
+}
+
+func (h *recordingHandler) forwardBatch(request json.RawMessage) (json.RawMessage, error) {
+	var msgs []message
+	if err := json.Unmarshal(request, &msgs); err != nil {
+		return nil, err
+	}
+	elems := make([]rpc.BatchElem, len(msgs))
+	results := make([]json.RawMessage, len(msgs))
+	for i, msg := range msgs {
+		args, err := toArgs(msg.Params)
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = rpc.BatchElem{Method: msg.Method, Args: args, Result: &results[i]}
+	}
+	batchErr := h.upstream.BatchCallContext(context.Background(), elems)
+
+	responses := make([]message, len(msgs))
+	for i, msg := range msgs {
+		responses[i] = message{Version: "2.0", ID: msg.ID}
+		switch {
+		case batchErr != nil:
+			responses[i].Error = toMessageError(batchErr)
+		case elems[i].Error != nil:
+			responses[i].Error = toMessageError(elems[i].Error)
+		default:
+			responses[i].Result = results[i]
+		}
+	}
+	return json.Marshal(responses)
+}
+
+func (h *recordingHandler) forwardNotifications(c *ipcConn, id string, sub *rpc.ClientSubscription, notifications chan json.RawMessage) {
+	for {
+		select {
+		case result := <-notifications:
+			params, err := json.Marshal(struct {
+				Subscription string          `json:"subscription"`
+				Result       json.RawMessage `json:"result"`
+			}{id, result})
+			if err != nil {
+				c.close()
+				return
+			}
+			notification, err := json.Marshal(message{Version: "2.0", Method: "eth_subscription", Params: params})
+			if err != nil {
+				c.close()
+				return
+			}
+			if err := h.writer.write(interaction{Notification: notification, Subscription: id}); err != nil {
+				c.close()
+				return
+			}
+			if err := c.write(notification); err != nil {
+				return
+			}
+		case err := <-sub.Err():
+			if err != nil {
+				// the upstream subscription was dropped, dropping the client one too
+				c.close()
+			}
+			return
+		}
+	}
+}
+
+func toArgs(params json.RawMessage) ([]interface{}, error) {
+	if len(params) == 0 || string(params) == "null" {
+		return nil, nil
+	}
+	var rawArgs []json.RawMessage
+	if err := json.Unmarshal(params, &rawArgs); err != nil {
+		return nil, err
+	}
+	args := make([]interface{}, len(rawArgs))
+	for i := range rawArgs {
+		args[i] = rawArgs[i]
+	}
+	return args, nil
+}
+
+func toMessageError(err error) *messageError {
+	msgErr := &messageError{Code: -32603, Message: err.Error()}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		msgErr.Code = rpcErr.ErrorCode()
+	}
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		msgErr.Data = dataErr.ErrorData()
+	}
+	return msgErr
+}
@@ -0,0 +1,220 @@
+package rpcrecord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Tape creates the RPC clients used to connect to the EVM chains and the Celestia consensus
+// network. Depending on its mode, a tape either connects to the endpoints directly, records
+// all the JSON-RPC requests and responses into cassettes, or serves the responses from
+// previously recorded cassettes without any network access.
+//
+// Each endpoint is identified by a name, e.g. "source-evm", and has a cassette file
+// <name>.jsonl in the cassettes directory.
+type Tape interface {
+	// DialEVM creates an RPC client connected to the EVM endpoint identified by the name.
+	DialEVM(ctx context.Context, name string, url string) (*rpc.Client, error)
+	// TendermintRemote returns the address the tendermint RPC client of the endpoint identified
+	// by the name connects to, so that its HTTP requests and websocket connection go through the tape.
+	TendermintRemote(name string, remote string) (string, error)
+	// Close stops recording or serving the cassettes.
+	Close() error
+}
+
+// New creates a new tape. If the record directory is set, the traffic is recorded into it.
+// If the playback directory is set, the traffic is served from the cassettes in it.
+// Otherwise, the tape connects to the endpoints directly.
+func New(recordDir string, playbackDir string) (Tape, error) {
+	switch {
+	case recordDir != "" && playbackDir != "":
+		return nil, errors.New("cannot record and playback the RPC traffic at the same time")
+	case recordDir != "":
+		if err := os.MkdirAll(recordDir, 0o755); err != nil {
+			return nil, err
+		}
+		return &recorder{dir: recordDir}, nil
+	case playbackDir != "":
+		info, err := os.Stat(playbackDir)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, errors.New("the RPC playback path should be a directory")
+		}
+		return &player{dir: playbackDir}, nil
+	default:
+		return live{}, nil
+	}
+}
+
+// live connects to the endpoints directly.
+type live struct{}
+
+var _ Tape = live{}
+
+func (live) DialEVM(ctx context.Context, _ string, url string) (*rpc.Client, error) {
+	return rpc.DialContext(ctx, url)
+}
+
+func (live) TendermintRemote(_ string, remote string) (string, error) {
+	return remote, nil
+}
+
+func (live) Close() error {
+	return nil
+}
+
+// recorder connects to the endpoints and records their traffic.
+type recorder struct {
+	dir string
+
+	mu          sync.Mutex
+	writers     []*cassetteWriter
+	servers     []*ipcServer
+	httpServers []*localServer
+	upstream    []*rpc.Client
+}
+
+var _ Tape = &recorder{}
+
+func (r *recorder) DialEVM(ctx context.Context, name string, url string) (*rpc.Client, error) {
+	upstream, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := r.newWriter(name)
+	if err != nil {
+		upstream.Close()
+		return nil, err
+	}
+	handler := newRecordingHandler(upstream, writer)
+	client, server, err := dialIPC(ctx, handler.handle)
+	if err != nil {
+		upstream.Close()
+		return nil, err
+	}
+	r.mu.Lock()
+	r.servers = append(r.servers, server)
+	r.upstream = append(r.upstream, upstream)
+	r.mu.Unlock()
+	return client, nil
+}
+
+func (r *recorder) TendermintRemote(name string, remote string) (string, error) {
+	handler, err := newTendermintRecorder(remote)
+	if err != nil {
+		return "", err
+	}
+	writer, err := r.newWriter(name)
+	if err != nil {
+		return "", err
+	}
+	handler.writer = writer
+	server, err := serveLocal(handler)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.httpServers = append(r.httpServers, server)
+	r.mu.Unlock()
+	return server.url(), nil
+}
+
+func (r *recorder) newWriter(name string) (*cassetteWriter, error) {
+	writer, err := newCassetteWriter(r.dir, name)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.writers = append(r.writers, writer)
+	r.mu.Unlock()
+	return writer, nil
+}
+
+func (r *recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, server := range r.servers {
+		server.close()
+	}
+	for _, server := range r.httpServers {
+		server.close()
+	}
+	for _, upstream := range r.upstream {
+		upstream.Close()
+	}
+	var errs []error
+	for _, writer := range r.writers {
+		errs = append(errs, writer.close())
+	}
+	return errors.Join(errs...)
+}
+
+// player serves the traffic from recorded cassettes.
+type player struct {
+	dir string
+
+	mu          sync.Mutex
+	servers     []*ipcServer
+	httpServers []*localServer
+}
+
+var _ Tape = &player{}
+
+func (p *player) DialEVM(ctx context.Context, name string, _ string) (*rpc.Client, error) {
+	c, err := loadCassette(p.dir, name)
+	if err != nil {
+		return nil, err
+	}
+	client, server, err := dialIPC(ctx, func(conn *ipcConn, request json.RawMessage) {
+		response, notifications, err := c.next(request)
+		if err != nil {
+			conn.close()
+			return
+		}
+		if err := conn.write(response); err != nil {
+			return
+		}
+		go playNotifications(notifications, conn.write, conn.done)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.servers = append(p.servers, server)
+	p.mu.Unlock()
+	return client, nil
+}
+
+func (p *player) TendermintRemote(name string, _ string) (string, error) {
+	c, err := loadCassette(p.dir, name)
+	if err != nil {
+		return "", err
+	}
+	server, err := serveLocal(newTendermintPlayer(c))
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	p.httpServers = append(p.httpServers, server)
+	p.mu.Unlock()
+	return server.url(), nil
+}
+
+func (p *player) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, server := range p.servers {
+		server.close()
+	}
+	for _, server := range p.httpServers {
+		server.close()
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package rpcrecord_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/deploy"
+	"github.com/celestiaorg/blobstream-ops/eventstream"
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/metrics"
+	"github.com/celestiaorg/blobstream-ops/notify"
+	"github.com/celestiaorg/blobstream-ops/replay"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/celestiaorg/blobstream-ops/signer"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+var record = flag.Bool("record", false, "record the fixture tapes against simulated endpoints before playing them back")
+
+// testKey the deployer, prover and replayer key. It's fixed for the replayed transactions to match the recorded ones.
+const testKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// endpoints the addresses of the recorded endpoints. They're ignored when playing back a tape.
+type endpoints struct {
+	source string
+	target string
+	core   string
+}
+
+// TestReplayPlayback catches up then follows a source contract, verifying the proofs against the core
+// endpoint, from a recorded tape. The proof emitted while following is played back at its recorded offset.
+// Run with -record to record the tape again against simulated chains.
+func TestReplayPlayback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	dir := filepath.Join("testdata", "replay")
+	if *record {
+		recordReplay(ctx, t, dir)
+	}
+
+	source, err := deploy.LoadManifest(filepath.Join(dir, "source-manifest.json"))
+	require.NoError(t, err)
+	target, err := deploy.LoadManifest(filepath.Join(dir, "target-manifest.json"))
+	require.NoError(t, err)
+	tape, err := rpcrecord.New("", dir)
+	require.NoError(t, err)
+	defer tape.Close()
+
+	replayed := replayThroughTape(ctx, t, tape, endpoints{}, source, target, nil)
+	delays := recordedDelays(t, filepath.Join(dir, "source-evm.jsonl"))
+	require.Len(t, delays, 1)
+	assert.GreaterOrEqual(t, replayed, delays[0], "the new proof was played back before its recorded offset")
+}
+
+// recordReplay records the replay tape against simulated chains and a fake core endpoint.
+func recordReplay(ctx context.Context, t *testing.T, dir string) {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+	source := simchain.New(ctx, t, key)
+	target := simchain.New(ctx, t, key)
+	target.Mine(t, 50*time.Millisecond)
+	core := simchain.NewCore(t)
+	source.CommitHeaderRange(ctx, t, key, simchain.GenesisHeight, simchain.GenesisHeight+10)
+	source.CommitHeaderRange(ctx, t, key, simchain.GenesisHeight+10, simchain.GenesisHeight+20)
+
+	tape, err := rpcrecord.New(dir, "")
+	require.NoError(t, err)
+	replayThroughTape(ctx, t, tape, endpoints{source: source.Endpoint, target: target.Endpoint, core: core.URL}, source.Manifest, target.Manifest, func() {
+		// the subscription is set up before the new proof is emitted
+		time.Sleep(500 * time.Millisecond)
+		source.CommitHeaderRange(ctx, t, key, simchain.GenesisHeight+20, simchain.GenesisHeight+30)
+	})
+	require.NoError(t, tape.Close())
+	require.NoError(t, source.Manifest.Write(filepath.Join(dir, "source-manifest.json")))
+	require.NoError(t, target.Manifest.Write(filepath.Join(dir, "target-manifest.json")))
+}
+
+// replayThroughTape catches up then follows the source contract with the clients of the tape, and returns the
+// time it took, since it started following, to replay the proof emitted by emit.
+func replayThroughTape(
+	ctx context.Context,
+	t *testing.T,
+	tape rpcrecord.Tape,
+	endpoints endpoints,
+	source, target deploy.Manifest,
+	emit func(),
+) time.Duration {
+	key, err := crypto.HexToECDSA(testKey)
+	require.NoError(t, err)
+	sourceRPC, err := tape.DialEVM(ctx, "source-evm", "ws://"+endpoints.source)
+	require.NoError(t, err)
+	sourceClient := ethclient.NewClient(sourceRPC)
+	defer sourceClient.Close()
+	targetRPC, err := tape.DialEVM(ctx, "target-evm", "http://"+endpoints.target)
+	require.NoError(t, err)
+	targetClient := ethclient.NewClient(targetRPC)
+	defer targetClient.Close()
+	coreRemote, err := tape.TendermintRemote("core", endpoints.core)
+	require.NoError(t, err)
+	trpc, err := tmhttp.New(coreRemote, "/websocket")
+	require.NoError(t, err)
+	require.NoError(t, trpc.Start())
+	defer func() { _ = trpc.Stop() }()
+
+	logger := tmlog.NewNopLogger()
+	notifier, err := notify.New(logger, nil, 0)
+	require.NoError(t, err)
+	replayer, err := replay.NewReplayer(
+		logger,
+		replay.Config{
+			SourceBlobstreamContractAddress: source.BlobstreamX.Hex(),
+			TargetBlobstreamContractAddress: target.BlobstreamX.Hex(),
+			SourceChainGatewayAddress:       source.Gateway.Hex(),
+			TargetChainGatewayAddress:       target.Gateway.Hex(),
+			Signer:                          signer.NewKey(key),
+			FunctionIDs: map[[32]byte][32]byte{
+				source.HeaderRangeFunctionID: target.HeaderRangeFunctionID,
+				source.NextHeaderFunctionID:  target.NextHeaderFunctionID,
+			},
+			FilterRange: 5000,
+			Verify:      true,
+		},
+		trpc,
+		sourceClient,
+		targetClient,
+		metrics.NewReplay(prometheus.NewRegistry()),
+		notifier,
+		eventstream.New(logger),
+		nil,
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, replayer.Catchup(ctx))
+	require.Equal(t, uint64(simchain.GenesisHeight+20), replayer.Status().TargetLatestBlock)
+
+	followCtx, stopFollow := context.WithCancel(ctx)
+	defer stopFollow()
+	followed := make(chan error, 1)
+	followStart := time.Now()
+	go func() { followed <- replayer.Follow(followCtx) }()
+	if emit != nil {
+		emit()
+	}
+	require.Eventually(t, func() bool {
+		return replayer.Status().TargetLatestBlock == simchain.GenesisHeight+30
+	}, time.Minute, 10*time.Millisecond)
+	replayed := time.Since(followStart)
+	stopFollow()
+	if err := <-followed; err != nil {
+		require.ErrorIs(t, err, context.Canceled)
+	}
+	return replayed
+}
+
+// recordedDelays returns the delays, in the order they were recorded, between the notifications of the cassette
+// and the requests creating their subscriptions.
+func recordedDelays(t *testing.T, path string) []time.Duration {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	subscribed := make(map[string]time.Duration)
+	var delays []time.Duration
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			Request      json.RawMessage `json:"request"`
+			Notification json.RawMessage `json:"notification"`
+			Subscription string          `json:"subscription"`
+			Offset       time.Duration   `json:"offset"`
+		}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		switch {
+		case line.Subscription == "":
+		case line.Notification != nil:
+			subscribedAt, exists := subscribed[line.Subscription]
+			require.True(t, exists, "notification of the unknown subscription %s", line.Subscription)
+			delays = append(delays, line.Offset-subscribedAt)
+		default:
+			subscribed[line.Subscription] = line.Offset
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return delays
+}
@@ -0,0 +1,326 @@
+package rpcrecord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// localServer an HTTP server listening on a local port, standing in for a tendermint RPC endpoint.
+type localServer struct {
+	listener net.Listener
+	server   *http.Server
+	// done closed once the server is closed, to stop the websocket connections.
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// localHandler a handler served by a local server, provided a channel closed once the server is closed.
+type localHandler interface {
+	http.Handler
+	setDone(done <-chan struct{})
+}
+
+// serveLocal serves the handler on a free local port.
+func serveLocal(handler localHandler) (*localServer, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &localServer{
+		listener: listener,
+		server:   &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second},
+		done:     make(chan struct{}),
+	}
+	handler.setDone(s.done)
+	go func() { _ = s.server.Serve(listener) }()
+	return s, nil
+}
+
+// url returns the URL the tendermint RPC client connects to.
+func (s *localServer) url() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+func (s *localServer) close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.server.Close()
+	})
+}
+
+// tendermintRecorder forwards the HTTP requests and websocket connections of a tendermint RPC client to
+// the endpoint, and records the exchanges along with the subscriptions events.
+type tendermintRecorder struct {
+	upstream   *url.URL
+	upstreamWS *url.URL
+	client     *http.Client
+	writer     *cassetteWriter
+	upgrader   websocket.Upgrader
+	done       <-chan struct{}
+}
+
+// newTendermintRecorder creates a recorder forwarding the traffic to the remote, e.g. tcp://localhost:26657.
+func newTendermintRecorder(remote string) (*tendermintRecorder, error) {
+	if !strings.Contains(remote, "://") {
+		remote = "tcp://" + remote
+	}
+	upstream, err := url.Parse(remote)
+	if err != nil {
+		return nil, err
+	}
+	upstreamWS := *upstream
+	switch upstream.Scheme {
+	case "tcp", "http":
+		upstream.Scheme, upstreamWS.Scheme = "http", "ws"
+	case "https":
+		upstreamWS.Scheme = "wss"
+	default:
+		return nil, fmt.Errorf("cannot record the traffic of the %s endpoint %s", upstream.Scheme, remote)
+	}
+	return &tendermintRecorder{
+		upstream:   upstream,
+		upstreamWS: &upstreamWS,
+		client:     &http.Client{Timeout: time.Minute},
+		upgrader:   websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}, nil
+}
+
+func (h *tendermintRecorder) setDone(done <-chan struct{}) {
+	h.done = done
+}
+
+func (h *tendermintRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebsocket(w, r)
+		return
+	}
+	request, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	upstream := *h.upstream
+	upstream.Path = strings.TrimSuffix(upstream.Path, "/") + r.URL.Path
+	upstream.RawQuery = r.URL.RawQuery
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, upstream.String(), bytes.NewReader(request))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+	resp, err := h.client.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	response, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	// only JSON-RPC exchanges are recorded
+	if json.Valid(request) && json.Valid(response) {
+		if err := h.writer.write(interaction{Request: request, Response: response}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(response)
+}
+
+// serveWebsocket forwards the websocket connection to the endpoint. The messages received from the endpoint
+// answering a pending request are recorded along with it, and the other ones are recorded as the events
+// of the subscription created by the request with the same ID.
+func (h *tendermintRecorder) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	upstream := *h.upstreamWS
+	upstream.Path = strings.TrimSuffix(upstream.Path, "/") + r.URL.Path
+	upstreamConn, _, err := websocket.DefaultDialer.DialContext(r.Context(), upstream.String(), nil) //nolint:bodyclose
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to dial the websocket endpoint: %s", err.Error()), http.StatusBadGateway)
+		return
+	}
+	defer upstreamConn.Close()
+	clientConn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer clientConn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	var (
+		pendingMu sync.Mutex
+		// pending the requests waiting for a response by ID
+		pending = make(map[string]json.RawMessage)
+	)
+
+	go func() {
+		defer cancel()
+		for {
+			_, bz, err := upstreamConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg message
+			if json.Unmarshal(bz, &msg) == nil {
+				id := idKey(msg.ID)
+				pendingMu.Lock()
+				request, exists := pending[id]
+				delete(pending, id)
+				pendingMu.Unlock()
+				i := interaction{Notification: bz, Subscription: id}
+				if exists {
+					i = interaction{Request: request, Response: bz, Subscription: id}
+				}
+				if err := h.writer.write(i); err != nil {
+					return
+				}
+			}
+			if err := clientConn.WriteMessage(websocket.TextMessage, bz); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer cancel()
+		for {
+			_, bz, err := clientConn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg message
+			if json.Unmarshal(bz, &msg) == nil && msg.ID != nil {
+				pendingMu.Lock()
+				pending[idKey(msg.ID)] = bz
+				pendingMu.Unlock()
+			}
+			if err := upstreamConn.WriteMessage(websocket.TextMessage, bz); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-h.done:
+	}
+}
+
+// tendermintPlayer serves the HTTP requests and websocket connections of a tendermint RPC client from
+// a cassette. The subscriptions events are sent at the offsets they were recorded at.
+type tendermintPlayer struct {
+	cassette *cassette
+	upgrader websocket.Upgrader
+	done     <-chan struct{}
+}
+
+func newTendermintPlayer(c *cassette) *tendermintPlayer {
+	return &tendermintPlayer{
+		cassette: c,
+		upgrader: websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+	}
+}
+
+func (h *tendermintPlayer) setDone(done <-chan struct{}) {
+	h.done = done
+}
+
+func (h *tendermintPlayer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		h.serveWebsocket(w, r)
+		return
+	}
+	request, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, _, err := h.cassette.next(request)
+	if err != nil {
+		// answering with a JSON-RPC error, for the client to surface the missing interaction
+		var msg message
+		if bytes.HasPrefix(bytes.TrimSpace(request), []byte("[")) || json.Unmarshal(request, &msg) != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		response, err = json.Marshal(message{
+			Version: "2.0",
+			ID:      msg.ID,
+			Error:   &messageError{Code: -32603, Message: err.Error()},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(response)
+}
+
+// serveWebsocket answers the requests received over the websocket connection in order, then sends the
+// events of the subscriptions they created.
+func (h *tendermintPlayer) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	var writeMu sync.Mutex
+	write := func(msg json.RawMessage) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return conn.WriteMessage(websocket.TextMessage, msg)
+	}
+	go func() {
+		select {
+		case <-done:
+		case <-h.done:
+			// unblocking the read
+			_ = conn.Close()
+		}
+	}()
+
+	for {
+		_, request, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		response, notifications, err := h.cassette.next(request)
+		if err != nil {
+			return
+		}
+		if err := write(response); err != nil {
+			return
+		}
+		go playNotifications(notifications, write, done)
+	}
+}
+
+// idKey returns the JSON-RPC ID as a string, without the quotes of the string IDs.
+func idKey(id json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(id, &s); err == nil {
+		// some tendermint versions suffix the ID of the subscription request for its events
+		return strings.TrimSuffix(s, "#event")
+	}
+	return string(bytes.TrimSpace(id))
+}
@@ -0,0 +1,86 @@
+package rpcrecord_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/celestiaorg/blobstream-ops/internal/simchain"
+	"github.com/celestiaorg/blobstream-ops/rpcrecord"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmhttp "github.com/tendermint/tendermint/rpc/client/http"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// eventHeights the heights of the new block headers events emitted after subscribing.
+var eventHeights = []uint64{simchain.GenesisHeight + 1, simchain.GenesisHeight + 2, simchain.GenesisHeight + 3}
+
+// TestTendermintPlayback plays back the HTTP requests and the websocket events of a tendermint RPC client.
+// The events are played back in the order they were recorded, each no earlier than its recorded offset.
+// Run with -record to record the tape again against a fake core endpoint.
+func TestTendermintPlayback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	dir := filepath.Join("testdata", "tendermint")
+	if *record {
+		core := simchain.NewCore(t)
+		tape, err := rpcrecord.New(dir, "")
+		require.NoError(t, err)
+		subscribeThroughTape(ctx, t, tape, core.URL, func() {
+			require.Eventually(t, func() bool { return core.Subscribers() == 1 }, 10*time.Second, 10*time.Millisecond)
+			for _, height := range eventHeights {
+				time.Sleep(100 * time.Millisecond)
+				core.EmitNewBlockHeader(ctx, t, height)
+			}
+		})
+		require.NoError(t, tape.Close())
+	}
+
+	tape, err := rpcrecord.New("", dir)
+	require.NoError(t, err)
+	defer tape.Close()
+	received := subscribeThroughTape(ctx, t, tape, "", nil)
+	delays := recordedDelays(t, filepath.Join(dir, "core.jsonl"))
+	require.Len(t, delays, len(eventHeights))
+	for i, delay := range delays {
+		assert.GreaterOrEqual(t, received[i], delay, "event %d was played back before its recorded offset", i)
+	}
+}
+
+// subscribeThroughTape queries a header then subscribes to the new block headers with the client of the tape,
+// and returns the time each event was received at since subscribing. The events are emitted by emit.
+func subscribeThroughTape(ctx context.Context, t *testing.T, tape rpcrecord.Tape, remote string, emit func()) []time.Duration {
+	coreRemote, err := tape.TendermintRemote("core", remote)
+	require.NoError(t, err)
+	trpc, err := tmhttp.New(coreRemote, "/websocket")
+	require.NoError(t, err)
+	require.NoError(t, trpc.Start())
+	defer func() { _ = trpc.Stop() }()
+
+	height := int64(simchain.GenesisHeight)
+	header, err := trpc.Header(ctx, &height)
+	require.NoError(t, err)
+	assert.Equal(t, simchain.HeaderHash(simchain.GenesisHeight), [32]byte(header.Header.Hash()))
+
+	subscribed := time.Now()
+	events, err := trpc.Subscribe(ctx, "rpcrecord", tmtypes.QueryForEvent(tmtypes.EventNewBlockHeader).String())
+	require.NoError(t, err)
+	if emit != nil {
+		go emit()
+	}
+	received := make([]time.Duration, 0, len(eventHeights))
+	for _, height := range eventHeights {
+		select {
+		case event := <-events:
+			received = append(received, time.Since(subscribed))
+			data, ok := event.Data.(tmtypes.EventDataNewBlockHeader)
+			require.True(t, ok)
+			assert.Equal(t, int64(height), data.Header.Height)
+		case <-ctx.Done():
+			require.FailNow(t, "the new block header event wasn't received", "height %d", height)
+		}
+	}
+	return received
+}
@@ -0,0 +1,105 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/text/unicode/norm"
+)
+
+// DefaultDerivationPath the derivation path of the first account of the BIP-44 Ethereum wallets.
+const DefaultDerivationPath = "m/44'/60'/0'/0/0"
+
+// FromMnemonicFile creates a new signer from the BIP-39 mnemonic stored in the provided file.
+func FromMnemonicFile(path string, derivationPath string) (*Key, error) {
+	mnemonic, err := readSecretFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the mnemonic file: %w", err)
+	}
+	return FromMnemonic(mnemonic, derivationPath)
+}
+
+// FromMnemonic creates a new signer from the key derived from a BIP-39 mnemonic, without passphrase, using
+// the provided BIP-32 derivation path. The words aren't checked against the BIP-39 word lists, so a
+// mistyped mnemonic derives a different key: the signer address should be checked before funding it.
+func FromMnemonic(mnemonic string, derivationPath string) (*Key, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("invalid mnemonic: expected 12, 15, 18, 21 or 24 words, got %d", len(words))
+	}
+	path, err := accounts.ParseDerivationPath(derivationPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path %q: %w", derivationPath, err)
+	}
+
+	seed, err := pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte("mnemonic"), 2048, 64)
+	if err != nil {
+		return nil, err
+	}
+	key, chainCode, err := splitExtendedKey(hmacSHA512([]byte("Bitcoin seed"), seed), nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		key, chainCode, err = deriveChild(key, chainCode, index)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't derive the key at the path %s: %w", path, err)
+		}
+	}
+
+	privateKey, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+	if err != nil {
+		return nil, err
+	}
+	return NewKey(privateKey), nil
+}
+
+// deriveChild derives the BIP-32 child private key at the provided index.
+func deriveChild(parent *big.Int, chainCode []byte, index uint32) (*big.Int, []byte, error) {
+	var data []byte
+	if index >= 0x80000000 {
+		data = append([]byte{0}, math.PaddedBigBytes(parent, 32)...)
+	} else {
+		privateKey, err := crypto.ToECDSA(math.PaddedBigBytes(parent, 32))
+		if err != nil {
+			return nil, nil, err
+		}
+		data = crypto.CompressPubkey(&privateKey.PublicKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	return splitExtendedKey(hmacSHA512(chainCode, data), parent)
+}
+
+// splitExtendedKey splits the HMAC output into the private key tweak and the chain code, then adds the tweak
+// to the parent private key if any.
+func splitExtendedKey(bz []byte, parent *big.Int) (*big.Int, []byte, error) {
+	n := crypto.S256().Params().N
+	key := new(big.Int).SetBytes(bz[:32])
+	if key.Cmp(n) >= 0 {
+		return nil, nil, errors.New("the derived key is out of the curve order")
+	}
+	if parent != nil {
+		key.Add(key, parent).Mod(key, n)
+	}
+	if key.Sign() == 0 {
+		return nil, nil, errors.New("the derived key is zero")
+	}
+	return key, bz[32:], nil
+}
+
+func hmacSHA512(key []byte, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,154 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// Remote signs the transactions using an external JSON-RPC signer compatible with clef's
+// account_signTransaction method.
+type Remote struct {
+	client  *rpc.Client
+	address ethcmn.Address
+}
+
+// signTxResponse the account_signTransaction response.
+type signTxResponse struct {
+	Raw hexutil.Bytes              `json:"raw"`
+	Tx  *coregethtypes.Transaction `json:"tx"`
+}
+
+// DialRemote creates a new remote signer signing with the provided account of the external signer.
+func DialRemote(ctx context.Context, url string, address ethcmn.Address) (*Remote, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't dial the remote signer: %w", err)
+	}
+	return &Remote{client: client, address: address}, nil
+}
+
+// Close closes the connection to the external signer.
+func (r *Remote) Close() {
+	r.client.Close()
+}
+
+// Address returns the address of the signing account.
+func (r *Remote) Address() ethcmn.Address {
+	return r.address
+}
+
+// SignTx asks the external signer to sign the transaction, then checks that the returned transaction is the
+// requested one, signed by the expected account.
+func (r *Remote) SignTx(ctx context.Context, tx *coregethtypes.Transaction, chainID *big.Int) (*coregethtypes.Transaction, error) {
+	args, err := sendTxArgs(r.address, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	var res signTxResponse
+	if err := r.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("the remote signer couldn't sign the transaction: %w", err)
+	}
+	signed := new(coregethtypes.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, fmt.Errorf("invalid transaction returned by the remote signer: %w", err)
+	}
+
+	txSigner := coregethtypes.LatestSignerForChainID(chainID)
+	if txSigner.Hash(signed) != txSigner.Hash(tx) {
+		return nil, errors.New("the remote signer returned a different transaction than the requested one")
+	}
+	sender, err := coregethtypes.Sender(txSigner, signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature returned by the remote signer: %w", err)
+	}
+	if sender != r.address {
+		return nil, fmt.Errorf("the remote signer signed with %s instead of %s", sender.Hex(), r.address.Hex())
+	}
+	return signed, nil
+}
+
+// sendTxArgs converts the transaction to the account_signTransaction arguments.
+func sendTxArgs(from ethcmn.Address, tx *coregethtypes.Transaction, chainID *big.Int) (*apitypes.SendTxArgs, error) {
+	data := hexutil.Bytes(tx.Data())
+	var to *ethcmn.MixedcaseAddress
+	if tx.To() != nil {
+		mixedTo := ethcmn.NewMixedcaseAddress(*tx.To())
+		to = &mixedTo
+	}
+	args := &apitypes.SendTxArgs{
+		From:    ethcmn.NewMixedcaseAddress(from),
+		To:      to,
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &data,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case coregethtypes.LegacyTxType, coregethtypes.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case coregethtypes.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+	if tx.Type() != coregethtypes.LegacyTxType {
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	return args, nil
+}
+
+// NewServer creates a JSON-RPC server exposing the signer using clef's account_list and
+// account_signTransaction methods. It's a local stand-in for an external signer, to run the remote signer
+// backend without clef.
+func NewServer(signer Signer) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", &accountAPI{signer: signer}); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// accountAPI the account namespace of the local stand-in signer.
+type accountAPI struct {
+	signer Signer
+}
+
+// List returns the address of the signing account.
+func (api *accountAPI) List() []ethcmn.Address {
+	return []ethcmn.Address{api.signer.Address()}
+}
+
+// SignTransaction signs the transaction described by the arguments. The method selector is accepted for
+// compatibility with clef but ignored.
+func (api *accountAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, _ *string) (*signTxResponse, error) {
+	if args.From.Address() != api.signer.Address() {
+		return nil, fmt.Errorf("unknown account %s", args.From.Address().Hex())
+	}
+	if args.ChainID == nil {
+		return nil, errors.New("the chain ID is required")
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := api.signer.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTxResponse{Raw: raw, Tx: signed}, nil
+}
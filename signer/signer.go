@@ -0,0 +1,107 @@
+package signer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs the EVM transactions sent by an account.
+type Signer interface {
+	// Address returns the address of the signing account.
+	Address() ethcmn.Address
+	// SignTx signs the transaction for the provided chain ID.
+	SignTx(ctx context.Context, tx *coregethtypes.Transaction, chainID *big.Int) (*coregethtypes.Transaction, error)
+}
+
+var (
+	_ Signer = &Key{}
+	_ Signer = &Remote{}
+)
+
+// Key signs the transactions using a private key held in memory. The private key, keystore and mnemonic
+// backends all resolve to a Key.
+type Key struct {
+	privateKey *ecdsa.PrivateKey
+}
+
+// NewKey creates a new signer from the provided private key.
+func NewKey(privateKey *ecdsa.PrivateKey) *Key {
+	return &Key{privateKey: privateKey}
+}
+
+// ParseKey creates a new signer from a hex encoded private key, with or without the 0x prefix.
+func ParseKey(rawPrivateKey string) (*Key, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(rawPrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to hex-decode Ethereum ECDSA Private Key: %w", err)
+	}
+	return NewKey(privateKey), nil
+}
+
+// LoadKeystore creates a new signer from a geth encrypted JSON keystore file. The passphrase is read from
+// the passphrase file, without the trailing new line.
+func LoadKeystore(path string, passphraseFile string) (*Key, error) {
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the keystore file: %w", err)
+	}
+	passphrase, err := readSecretFile(passphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the keystore passphrase file: %w", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't decrypt the keystore file %s: %w", path, err)
+	}
+	return NewKey(key.PrivateKey), nil
+}
+
+// PrivateKey returns the signer private key.
+func (k *Key) PrivateKey() *ecdsa.PrivateKey {
+	return k.privateKey
+}
+
+// Address returns the address of the signer private key.
+func (k *Key) Address() ethcmn.Address {
+	return crypto.PubkeyToAddress(k.privateKey.PublicKey)
+}
+
+// SignTx signs the transaction using the latest signer of the provided chain ID.
+func (k *Key) SignTx(_ context.Context, tx *coregethtypes.Transaction, chainID *big.Int) (*coregethtypes.Transaction, error) {
+	return coregethtypes.SignTx(tx, coregethtypes.LatestSignerForChainID(chainID), k.privateKey)
+}
+
+// readSecretFile reads a secret stored in a file, trimming the trailing new lines.
+func readSecretFile(path string) (string, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(bz), "\r\n"), nil
+}
+
+// NewTransactOpts creates the options of the transactions signed by the signer for the provided chain ID,
+// leaving the nonce, gas price and gas limit to the backend.
+func NewTransactOpts(ctx context.Context, txSigner Signer, chainID *big.Int) *bind.TransactOpts {
+	from := txSigner.Address()
+	return &bind.TransactOpts{
+		From: from,
+		Signer: func(address ethcmn.Address, tx *coregethtypes.Transaction) (*coregethtypes.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return txSigner.SignTx(ctx, tx, chainID)
+		},
+		Context: ctx,
+	}
+}
@@ -0,0 +1,257 @@
+package signer
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	ethcmn "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	coregethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func TestLoadKeystore(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Address:    crypto.PubkeyToAddress(privateKey.PublicKey),
+		PrivateKey: privateKey,
+	}, "correct horse", keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, err)
+	keystorePath := writeFile(t, "keystore.json", keyJSON)
+
+	tests := []struct {
+		name       string
+		keystore   string
+		passphrase []byte
+		wantErr    string
+	}{
+		{
+			name:       "passphrase with trailing new line",
+			keystore:   keystorePath,
+			passphrase: []byte("correct horse\n"),
+		},
+		{
+			name:       "passphrase without trailing new line",
+			keystore:   keystorePath,
+			passphrase: []byte("correct horse"),
+		},
+		{
+			name:       "wrong passphrase",
+			keystore:   keystorePath,
+			passphrase: []byte("wrong horse\n"),
+			wantErr:    "couldn't decrypt the keystore file",
+		},
+		{
+			name:       "missing keystore",
+			keystore:   filepath.Join(t.TempDir(), "missing.json"),
+			passphrase: []byte("correct horse\n"),
+			wantErr:    "couldn't read the keystore file",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := LoadKeystore(test.keystore, writeFile(t, "passphrase", test.passphrase))
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey), key.Address())
+		})
+	}
+}
+
+func TestFromMnemonic(t *testing.T) {
+	tests := []struct {
+		name           string
+		mnemonic       string
+		derivationPath string
+		wantAddress    string
+		wantPrivateKey string
+		wantErr        string
+	}{
+		{
+			name:           "first account",
+			mnemonic:       "test test test test test test test test test test test junk",
+			derivationPath: DefaultDerivationPath,
+			wantAddress:    "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+			wantPrivateKey: "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80",
+		},
+		{
+			name:           "second account",
+			mnemonic:       "test test test test test test test test test test test junk",
+			derivationPath: "m/44'/60'/0'/0/1",
+			wantAddress:    "0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+			wantPrivateKey: "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+		},
+		{
+			name:           "extra whitespaces",
+			mnemonic:       "  abandon abandon abandon abandon abandon abandon\nabandon abandon abandon abandon abandon about \n",
+			derivationPath: DefaultDerivationPath,
+			wantAddress:    "0x9858EfFD232B4033E47d90003D41EC34EcaEda94",
+		},
+		{
+			name:           "invalid words count",
+			mnemonic:       "test test test test test test test test test test junk",
+			derivationPath: DefaultDerivationPath,
+			wantErr:        "expected 12, 15, 18, 21 or 24 words, got 11",
+		},
+		{
+			name:           "invalid derivation path",
+			mnemonic:       "test test test test test test test test test test test junk",
+			derivationPath: "m/44'/x",
+			wantErr:        "invalid derivation path",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key, err := FromMnemonicFile(writeFile(t, "mnemonic", []byte(test.mnemonic)), test.derivationPath)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantAddress, key.Address().Hex())
+			if test.wantPrivateKey != "" {
+				assert.Equal(t, test.wantPrivateKey, hexutil.Encode(crypto.FromECDSA(key.PrivateKey())))
+			}
+		})
+	}
+}
+
+// misbehavingAPI an external signer account namespace signing with the provided key, regardless of the
+// requested account, and optionally changing the transaction before signing it.
+type misbehavingAPI struct {
+	key      *Key
+	tamperTx bool
+}
+
+func (api *misbehavingAPI) SignTransaction(ctx context.Context, args apitypes.SendTxArgs, _ *string) (*signTxResponse, error) {
+	if api.tamperTx {
+		args.Nonce++
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signed, err := api.key.SignTx(ctx, tx, args.ChainID.ToInt())
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTxResponse{Raw: raw, Tx: signed}, nil
+}
+
+func TestRemoteSignTx(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	key := NewKey(privateKey)
+	otherPrivateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	otherKey := NewKey(otherPrivateKey)
+
+	newServer := func(t *testing.T, api interface{}) string {
+		server := rpc.NewServer()
+		require.NoError(t, server.RegisterName("account", api))
+		httpServer := httptest.NewServer(server)
+		t.Cleanup(httpServer.Close)
+		return httpServer.URL
+	}
+	clefServer, err := NewServer(key)
+	require.NoError(t, err)
+	clef := httptest.NewServer(clefServer)
+	defer clef.Close()
+
+	chainID := big.NewInt(1337)
+	to := ethcmn.HexToAddress("0x1000000000000000000000000000000000000001")
+	txs := map[string]*coregethtypes.Transaction{
+		"legacy": coregethtypes.NewTx(&coregethtypes.LegacyTx{
+			Nonce:    1,
+			GasPrice: big.NewInt(2),
+			Gas:      21000,
+			To:       &to,
+			Value:    big.NewInt(3),
+		}),
+		"dynamic fee": coregethtypes.NewTx(&coregethtypes.DynamicFeeTx{
+			ChainID:   chainID,
+			Nonce:     4,
+			GasTipCap: big.NewInt(5),
+			GasFeeCap: big.NewInt(6),
+			Gas:       100000,
+			To:        &to,
+			Data:      []byte{0xca, 0xfe},
+		}),
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		address ethcmn.Address
+		wantErr string
+	}{
+		{
+			name:    "signed by the account",
+			url:     clef.URL,
+			address: key.Address(),
+		},
+		{
+			name:    "unknown account",
+			url:     clef.URL,
+			address: otherKey.Address(),
+			wantErr: "unknown account",
+		},
+		{
+			name:    "signed by another account",
+			url:     newServer(t, &misbehavingAPI{key: otherKey}),
+			address: key.Address(),
+			wantErr: "the remote signer signed with " + otherKey.Address().Hex(),
+		},
+		{
+			name:    "different transaction",
+			url:     newServer(t, &misbehavingAPI{key: key, tamperTx: true}),
+			address: key.Address(),
+			wantErr: "different transaction",
+		},
+	}
+	for _, test := range tests {
+		for txType, tx := range txs {
+			t.Run(test.name+"/"+txType, func(t *testing.T) {
+				remote, err := DialRemote(context.Background(), test.url, test.address)
+				require.NoError(t, err)
+				defer remote.Close()
+
+				signed, err := remote.SignTx(context.Background(), tx, chainID)
+				if test.wantErr != "" {
+					assert.ErrorContains(t, err, test.wantErr)
+					return
+				}
+				require.NoError(t, err)
+				txSigner := coregethtypes.LatestSignerForChainID(chainID)
+				assert.Equal(t, txSigner.Hash(tx), txSigner.Hash(signed))
+				sender, err := coregethtypes.Sender(txSigner, signed)
+				require.NoError(t, err)
+				assert.Equal(t, key.Address(), sender)
+			})
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/celestiaorg/blobstream-ops"
+
+// The supported trace exporters.
+const (
+	// ExporterNone the traces are not exported.
+	ExporterNone = "none"
+	// ExporterOTLPGRPC the traces are exported to an OTLP collector over gRPC.
+	ExporterOTLPGRPC = "otlp-grpc"
+	// ExporterOTLPHTTP the traces are exported to an OTLP collector over HTTP.
+	ExporterOTLPHTTP = "otlp-http"
+	// ExporterFile the traces are written as JSON to a local file.
+	ExporterFile = "file"
+)
+
+// The attributes set on the spans.
+const (
+	AttributeNonce      = attribute.Key("blobstream.nonce")
+	AttributeStartBlock = attribute.Key("blobstream.start_block")
+	AttributeEndBlock   = attribute.Key("blobstream.end_block")
+	AttributeTxHash     = attribute.Key("evm.tx_hash")
+)
+
+// Config the tracing configuration.
+type Config struct {
+	// Exporter one of ExporterNone, ExporterOTLPGRPC, ExporterOTLPHTTP or ExporterFile.
+	Exporter string
+	// Endpoint the OTLP collector URL, e.g. http://localhost:4317. If empty, the OTLP exporters
+	// default, or the OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variable, is used.
+	Endpoint string
+	// File the path of the file the traces are written to when using the file exporter.
+	File string
+}
+
+func (cfg Config) ValidateBasics() error {
+	switch cfg.Exporter {
+	case ExporterNone, ExporterOTLPGRPC, ExporterOTLPHTTP:
+		return nil
+	case ExporterFile:
+		if cfg.File == "" {
+			return errors.New("the traces file path is required when using the file exporter")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown trace exporter %q. Supported exporters: %s, %s, %s and %s", cfg.Exporter, ExporterNone, ExporterOTLPGRPC, ExporterOTLPHTTP, ExporterFile)
+	}
+}
+
+// Setup configures the global tracer provider to export the traces using the configured exporter.
+// The returned function flushes the pending spans and stops the exporter.
+func Setup(ctx context.Context, serviceName string, serviceVersion string, cfg Config) (func(context.Context) error, error) {
+	var (
+		exporter sdktrace.SpanExporter
+		closer   func() error
+		err      error
+	)
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLPGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterFile:
+		file, fileErr := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if fileErr != nil {
+			return nil, fileErr
+		}
+		closer = file.Close
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(serviceVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a new span with the provided attributes, as a child of the span in the context if any.
+// If tracing is not set up, the span is a no-op.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End ends the span, and records the error if any.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}